@@ -0,0 +1,307 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"time"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+
+	// Modules
+	input "github.com/djthorpe/gopi-input/sys/input"
+	_ "github.com/djthorpe/gopi/sys/logger"
+)
+
+var (
+	start   = make(chan struct{})
+	samples = make(chan gopi.Point)
+
+	// The event which is sampled when a touch is released, which is
+	// set before the start flag is sent
+	release_event gopi.InputEventType
+	release_key   gopi.KeyCode
+
+	// Target positions as a proportion of the screen
+	targets = []struct {
+		position    gopi.Point
+		description string
+	}{
+		{gopi.Point{X: 0.1, Y: 0.1}, "top left corner"},
+		{gopi.Point{X: 0.9, Y: 0.1}, "top right corner"},
+		{gopi.Point{X: 0.9, Y: 0.9}, "bottom right corner"},
+		{gopi.Point{X: 0.1, Y: 0.9}, "bottom left corner"},
+		{gopi.Point{X: 0.5, Y: 0.5}, "centre"},
+	}
+
+	// Colours of the background and the targets
+	color_background = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	color_target     = color.RGBA{0xFF, 0x00, 0x00, 0xFF}
+)
+
+const (
+	// Size of a target drawn on the display
+	TARGET_SIZE = 24
+
+	// Touches released within this time of the previous sample
+	// are ignored, so that a touch isn't sampled twice
+	TARGET_DEBOUNCE = 750 * time.Millisecond
+)
+
+///////////////////////////////////////////////////////////////////////////////
+
+func EventLoop(app *gopi.AppInstance, done <-chan struct{}) error {
+	// Subscribe to events
+	evt_input := app.Input.Subscribe()
+
+	// Wait for calibration to start
+	select {
+	case <-start:
+		break
+	case <-done:
+		app.Input.Unsubscribe(evt_input)
+		return nil
+	}
+
+FOR_LOOP:
+	for {
+		select {
+		case <-done:
+			break FOR_LOOP
+		case event := <-evt_input:
+			if evt, ok := event.(gopi.InputEvent); ok == false {
+				continue
+			} else if evt.EventType() == release_event && (release_key == gopi.KEYCODE_NONE || evt.KeyCode() == release_key) {
+				// Send the position where the touch was released
+				select {
+				case samples <- evt.Position():
+				default:
+				}
+			}
+		}
+	}
+
+	// Unsubscribe from events
+	app.Input.Unsubscribe(evt_input)
+
+	// Return success
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// GetAxes returns the range of the axes for the device positions
+func GetAxes(device input.AbsDevice) (input.AbsInfo, input.AbsInfo, error) {
+	abs_info := device.AbsInfo()
+	if x, exists := abs_info[input.ABS_MT_POSITION_X]; exists {
+		if y, exists := abs_info[input.ABS_MT_POSITION_Y]; exists {
+			return x, y, nil
+		}
+	}
+	if x, exists := abs_info[input.ABS_X]; exists {
+		if y, exists := abs_info[input.ABS_Y]; exists {
+			return x, y, nil
+		}
+	}
+	return input.AbsInfo{}, input.AbsInfo{}, fmt.Errorf("Device %v does not report absolute positions", device.Name())
+}
+
+// GetReleaseEvent returns the event which is sampled when a touch is
+// released. Multi-touch devices also report BTN_TOUCH, so only the
+// release of the contact is sampled
+func GetReleaseEvent(device input.AbsDevice) (gopi.InputEventType, gopi.KeyCode) {
+	if _, exists := device.AbsInfo()[input.ABS_MT_POSITION_X]; exists {
+		return gopi.INPUT_EVENT_TOUCHRELEASE, gopi.KEYCODE_NONE
+	} else {
+		return gopi.INPUT_EVENT_KEYRELEASE, gopi.KEYCODE_BTNTOUCH
+	}
+}
+
+// GetDevice returns the touchscreen to calibrate
+func GetDevice(app *gopi.AppInstance) (gopi.InputDevice, error) {
+	device_name, _ := app.AppFlags.GetString("name")
+	if devices, err := app.Input.OpenDevicesByName(device_name, gopi.INPUT_TYPE_TOUCHSCREEN, gopi.INPUT_BUS_ANY); err != nil {
+		return nil, err
+	} else if len(devices) == 0 {
+		return nil, errors.New("No touchscreen opened")
+	} else if len(devices) > 1 {
+		return nil, errors.New("More than one touchscreen opened, use -name to select a device")
+	} else {
+		return devices[0], nil
+	}
+}
+
+// DrawBackground draws a background over the display, so that the
+// targets can be seen. Returns nil if there is no display
+func DrawBackground(graphics gopi.SurfaceManager) (gopi.Surface, error) {
+	if graphics == nil {
+		return nil, nil
+	}
+	w, h := graphics.Display().Size()
+	return DrawSurface(graphics, gopi.SURFACE_LAYER_DEFAULT, gopi.ZeroPoint, gopi.Size{W: float32(w), H: float32(h)}, color_background)
+}
+
+// DrawTarget draws a target centred on a position as a proportion of
+// the display. Returns nil if there is no display
+func DrawTarget(graphics gopi.SurfaceManager, position gopi.Point) (gopi.Surface, error) {
+	if graphics == nil {
+		return nil, nil
+	}
+	w, h := graphics.Display().Size()
+	origin := gopi.Point{
+		X: position.X*float32(w) - TARGET_SIZE/2,
+		Y: position.Y*float32(h) - TARGET_SIZE/2,
+	}
+	return DrawSurface(graphics, gopi.SURFACE_LAYER_DEFAULT+1, origin, gopi.Size{W: TARGET_SIZE, H: TARGET_SIZE}, color_target)
+}
+
+// DrawSurface creates a surface filled with a colour
+func DrawSurface(graphics gopi.SurfaceManager, layer uint16, origin gopi.Point, size gopi.Size, fill color.RGBA) (gopi.Surface, error) {
+	bitmap, err := graphics.CreateBitmap(gopi.SURFACE_TYPE_RGBA32, size)
+	if err != nil {
+		return nil, err
+	} else if err := bitmap.ClearToColorRGBA(fill); err != nil {
+		graphics.DestroyBitmap(bitmap)
+		return nil, err
+	}
+	var surface gopi.Surface
+	if err := graphics.Do(func(manager gopi.SurfaceManager) error {
+		var err error
+		surface, err = manager.CreateSurfaceWithBitmap(bitmap, gopi.SURFACE_FLAG_NONE, 1.0, layer, origin, size)
+		return err
+	}); err != nil {
+		graphics.DestroyBitmap(bitmap)
+		return nil, err
+	}
+	return surface, nil
+}
+
+// DestroySurface removes a surface from the display
+func DestroySurface(graphics gopi.SurfaceManager, surface gopi.Surface) error {
+	if graphics == nil || surface == nil {
+		return nil
+	}
+	return graphics.Do(func(manager gopi.SurfaceManager) error {
+		return manager.DestroySurface(surface)
+	})
+}
+
+// Calibrate draws each target and collects a sample for it, and
+// returns the calibration which transforms the samples to the
+// targets, using the range of the device axes
+func Calibrate(graphics gopi.SurfaceManager, x, y input.AbsInfo, done <-chan struct{}) (input.Calibration, error) {
+	sample_points := make([]gopi.Point, 0, len(targets))
+	target_points := make([]gopi.Point, 0, len(targets))
+	sampled := time.Now()
+	for _, target := range targets {
+		position := gopi.Point{
+			X: float32(x.Minimum) + target.position.X*float32(x.Maximum-x.Minimum),
+			Y: float32(y.Minimum) + target.position.Y*float32(y.Maximum-y.Minimum),
+		}
+		surface, err := DrawTarget(graphics, target.position)
+		if err != nil {
+			return input.IdentityCalibration, err
+		}
+		fmt.Printf("Touch the target at the %v (%.0f%% across, %.0f%% down)\n", target.description, target.position.X*100, target.position.Y*100)
+	SAMPLE_LOOP:
+		for {
+			select {
+			case sample := <-samples:
+				// Ignore the same touch being released again
+				if time.Since(sampled) < TARGET_DEBOUNCE {
+					continue
+				}
+				sampled = time.Now()
+				fmt.Printf("  Sample %v => Target %v\n", sample, position)
+				sample_points = append(sample_points, sample)
+				target_points = append(target_points, position)
+				break SAMPLE_LOOP
+			case <-done:
+				DestroySurface(graphics, surface)
+				return input.IdentityCalibration, errors.New("Calibration interrupted")
+			}
+		}
+		if err := DestroySurface(graphics, surface); err != nil {
+			return input.IdentityCalibration, err
+		}
+	}
+	return input.NewCalibration(sample_points, target_points)
+}
+
+func Main(app *gopi.AppInstance, done chan<- struct{}) error {
+	defer func() {
+		done <- gopi.DONE
+	}()
+
+	folder, _ := app.AppFlags.GetString("input.calibration")
+	if folder == "" {
+		return errors.New("Missing -input.calibration flag")
+	}
+
+	device, err := GetDevice(app)
+	if err != nil {
+		return err
+	}
+	abs_device, ok := device.(input.AbsDevice)
+	if ok == false {
+		return fmt.Errorf("Device %v does not report absolute positions", device.Name())
+	}
+	calibration_device, ok := device.(input.CalibrationDevice)
+	if ok == false {
+		return fmt.Errorf("Device %v cannot be calibrated", device.Name())
+	}
+	x, y, err := GetAxes(abs_device)
+	if err != nil {
+		return err
+	}
+
+	// Collect samples as reported by the device
+	mode, size := abs_device.PositionMode()
+	if err := abs_device.SetPositionMode(input.POSITION_RAW, gopi.ZeroSize); err != nil {
+		return err
+	}
+	calibration_device.SetCalibration(input.IdentityCalibration)
+
+	// Interrupt calibration on CTRL+C
+	interrupt := make(chan struct{})
+	go func() {
+		app.WaitForSignal()
+		close(interrupt)
+	}()
+
+	// Draw the background when there is a display
+	background, err := DrawBackground(app.Graphics)
+	if err != nil {
+		return err
+	}
+	defer DestroySurface(app.Graphics, background)
+
+	fmt.Printf("Calibrating %v, press CTRL+C to end\n", device.Name())
+	release_event, release_key = GetReleaseEvent(abs_device)
+	start <- gopi.DONE
+	calibration, err := Calibrate(app.Graphics, x, y, interrupt)
+	if err != nil {
+		return err
+	}
+
+	// Write the calibration file and apply it to the device
+	path := input.CalibrationPath(folder, device.Name())
+	if err := input.WriteCalibration(path, calibration); err != nil {
+		return err
+	}
+	calibration_device.SetCalibration(calibration)
+	if err := abs_device.SetPositionMode(mode, size); err != nil {
+		return err
+	}
+	fmt.Printf("Calibration %v written to %v\n", calibration, path)
+
+	return nil
+}
+
+func main() {
+	config := gopi.NewAppConfig(modules...)
+	config.AppFlags.FlagString("name", "", "Name or alias of touchscreen to calibrate")
+	os.Exit(gopi.CommandLineTool(config, Main, EventLoop))
+}
@@ -0,0 +1,9 @@
+// +build !rpi
+
+package main
+
+var (
+	// Modules for the calibration tool, which prints the
+	// targets when there is no display
+	modules = []string{"input"}
+)
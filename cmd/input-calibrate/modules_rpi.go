@@ -0,0 +1,15 @@
+// +build rpi
+
+package main
+
+import (
+	// Modules
+	_ "github.com/djthorpe/gopi/sys/graphics/rpi"
+	_ "github.com/djthorpe/gopi/sys/hw/rpi"
+)
+
+var (
+	// Modules for the calibration tool, which draws the
+	// targets on the display
+	modules = []string{"input", "graphics"}
+)
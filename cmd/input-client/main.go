@@ -19,6 +19,7 @@ import (
 	// Frameworks
 	gopi "github.com/djthorpe/gopi"
 	input "github.com/djthorpe/gopi-input/rpc/grpc/input"
+	sysinput "github.com/djthorpe/gopi-input/sys/input"
 
 	// Modules
 	grpc "github.com/djthorpe/gopi-rpc/sys/grpc"
@@ -33,11 +34,31 @@ var (
 ///////////////////////////////////////////////////////////////////////////////
 
 func stringForDevice(evt gopi.InputEvent) string {
-	device_type := strings.ToLower(strings.TrimPrefix(fmt.Sprint(evt.DeviceType()), "INPUT_TYPE_"))
+	device_type := strings.ToLower(strings.Replace(sysinput.DeviceTypeString(evt.DeviceType()), "INPUT_TYPE_", "", -1))
 	return fmt.Sprintf("%s", device_type)
 }
 
 func stringForEvent(evt gopi.InputEvent) string {
+	switch evt.EventType() {
+	case sysinput.INPUT_EVENT_SCROLL:
+		return "SCROLL"
+	case sysinput.INPUT_EVENT_AXIS:
+		return "AXIS"
+	case sysinput.INPUT_EVENT_HAT:
+		return "HAT"
+	case sysinput.INPUT_EVENT_EFFECT:
+		return "EFFECT"
+	case sysinput.INPUT_EVENT_SWITCH:
+		return "SWITCH"
+	case sysinput.INPUT_EVENT_STYLUS:
+		return "STYLUS"
+	case sysinput.INPUT_EVENT_PROXIMITYIN:
+		return "PROXIMITYIN"
+	case sysinput.INPUT_EVENT_PROXIMITYOUT:
+		return "PROXIMITYOUT"
+	case sysinput.INPUT_EVENT_BATTERY:
+		return "BATTERY"
+	}
 	return strings.TrimPrefix(fmt.Sprint(evt.EventType()), "INPUT_EVENT_")
 }
 
@@ -46,13 +67,40 @@ func stringForKeyPosition(evt gopi.InputEvent) string {
 		return fmt.Sprintf("{%v,%v} => {%v,%v}", evt.Relative().X, evt.Relative().Y, evt.Position().X, evt.Position().Y)
 	} else if evt.EventType() == gopi.INPUT_EVENT_ABSPOSITION {
 		return fmt.Sprint(evt.Position())
+	} else if evt.EventType() == gopi.INPUT_EVENT_TOUCHPOSITION {
+		return fmt.Sprintf("%v [%v]", evt.Position(), evt.Slot())
+	} else if scroll_event, ok := evt.(sysinput.ScrollEvent); ok && evt.EventType() == sysinput.INPUT_EVENT_SCROLL {
+		return fmt.Sprintf("{%v,%v}", scroll_event.Scroll().X, scroll_event.Scroll().Y)
+	} else if axis_event, ok := evt.(sysinput.AxisEvent); ok && evt.EventType() == sysinput.INPUT_EVENT_AXIS {
+		return fmt.Sprintf("%v %.3f", strings.TrimPrefix(fmt.Sprint(axis_event.Axis()), "ABS_"), axis_event.Value())
+	} else if hat_event, ok := evt.(sysinput.HatEvent); ok && evt.EventType() == sysinput.INPUT_EVENT_HAT {
+		return fmt.Sprintf("%v [%v]", strings.ToLower(strings.Replace(fmt.Sprint(hat_event.Direction()), "HAT_", "", -1)), hat_event.Hat())
+	} else if effect_event, ok := evt.(sysinput.EffectEvent); ok && evt.EventType() == sysinput.INPUT_EVENT_EFFECT {
+		return fmt.Sprintf("%v [%v]", strings.ToLower(strings.TrimPrefix(fmt.Sprint(effect_event.EffectStatus()), "EFFECT_STATUS_")), effect_event.Effect())
+	} else if switch_event, ok := evt.(sysinput.SwitchEvent); ok && evt.EventType() == sysinput.INPUT_EVENT_SWITCH {
+		if switch_event.SwitchState() {
+			return fmt.Sprintf("%v on", strings.TrimPrefix(fmt.Sprint(switch_event.Switch()), "SW_"))
+		} else {
+			return fmt.Sprintf("%v off", strings.TrimPrefix(fmt.Sprint(switch_event.Switch()), "SW_"))
+		}
+	} else if battery_event, ok := evt.(sysinput.BatteryEvent); ok && evt.EventType() == sysinput.INPUT_EVENT_BATTERY {
+		battery := battery_event.Battery()
+		status := strings.ToLower(strings.Replace(strings.TrimPrefix(fmt.Sprint(battery.Status), "BATTERY_"), "_", " ", -1))
+		if battery.Level >= 0 {
+			status = fmt.Sprintf("%.0f%% %v", battery.Level*100, status)
+		}
+		return status
+	} else if stylus_event, ok := evt.(sysinput.StylusEvent); ok && evt.EventType() == sysinput.INPUT_EVENT_STYLUS {
+		return fmt.Sprintf("%v pressure=%.3f tilt={%v,%v}", stylus_event.Position(), stylus_event.Pressure(), stylus_event.Tilt().X, stylus_event.Tilt().Y)
+	} else if stylus_event, ok := evt.(sysinput.StylusEvent); ok && (evt.EventType() == sysinput.INPUT_EVENT_PROXIMITYIN || evt.EventType() == sysinput.INPUT_EVENT_PROXIMITYOUT) {
+		return fmt.Sprintf("%v %v", strings.ToLower(strings.TrimPrefix(fmt.Sprint(stylus_event.Tool()), "STYLUS_TOOL_")), stylus_event.Position())
 	} else {
 		return strings.TrimPrefix(fmt.Sprint(evt.KeyCode()), "KEYCODE_")
 	}
 }
 
 func stringForDeviceState(evt gopi.InputEvent) string {
-	if evt.DeviceType() != gopi.INPUT_TYPE_KEYBOARD {
+	if evt.DeviceType()&gopi.INPUT_TYPE_KEYBOARD == 0 {
 		return "N/A"
 	} else {
 		key_state := fmt.Sprint(evt.KeyState())
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	// Frameworks
+	gopi "github.com/djthorpe/gopi"
+
+	// Modules
+	input "github.com/djthorpe/gopi-input/sys/input"
+	_ "github.com/djthorpe/gopi/sys/logger"
+)
+
+var (
+	start = make(chan struct{})
+
+	// Rules, the devices which are remapped and the virtual device which
+	// remapped events are emitted from, which are set before the start
+	// flag is sent
+	remap   *input.Remap
+	devices []gopi.InputDevice
+	virtual input.VirtualInputDevice
+)
+
+///////////////////////////////////////////////////////////////////////////////
+
+func ReloadRules(app *gopi.AppInstance) error {
+	path, _ := app.AppFlags.GetString("rules")
+	if rules, err := input.ReadRemapRules(path); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	} else {
+		remap.SetRules(rules)
+		app.Logger.Info("Loaded %v rules from %v", len(rules), path)
+	}
+
+	// Recreate the virtual device when keys are mapped to keys which it
+	// can't emit
+	if virtual != nil {
+		caps := virtual.Capabilities()
+		for _, key_code := range remap.KeyCodes() {
+			if caps.HasKey(key_code) == false {
+				app.Logger.Info("Recreating virtual device for %v", key_code)
+				return OpenVirtualDevice(app)
+			}
+		}
+	}
+	return nil
+}
+
+// OpenVirtualDevice creates the virtual device with the capabilities of the
+// devices and the keys which are mapped to, and closes any existing virtual
+// device once the new one is created
+func OpenVirtualDevice(app *gopi.AppInstance) error {
+	device_type := gopi.INPUT_TYPE_NONE
+	for _, device := range devices {
+		device_type |= device.Type()
+	}
+	virtual_name, _ := app.AppFlags.GetString("virtual")
+	if driver, err := gopi.Open(input.VirtualDevice{
+		Name:         virtual_name,
+		Type:         device_type,
+		Bus:          gopi.INPUT_BUS_VIRTUAL,
+		Capabilities: input.MergeCapabilities(devices, remap.KeyCodes()),
+	}, app.Logger); err != nil {
+		return err
+	} else if virtual == nil {
+		virtual = driver.(input.VirtualInputDevice)
+		return nil
+	} else {
+		previous := virtual
+		virtual = driver.(input.VirtualInputDevice)
+		return previous.Close()
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+func EventLoop(app *gopi.AppInstance, done <-chan struct{}) error {
+	started := false
+
+	// Subscribe to events, and reload rules on SIGHUP
+	evt_input := app.Input.Subscribe()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+FOR_LOOP:
+	for {
+		select {
+		case <-start:
+			app.Logger.Info("Start")
+			started = true
+		case <-done:
+			app.Logger.Info("Done")
+			break FOR_LOOP
+		case <-hup:
+			if started {
+				if err := ReloadRules(app); err != nil {
+					app.Logger.Error("ReloadRules: %v", err)
+				}
+			}
+		case event := <-evt_input:
+			if evt, ok := event.(gopi.InputEvent); ok && started {
+				if evt = remap.Apply(evt); evt == nil {
+					continue
+				} else if err := virtual.Emit(evt); err == gopi.ErrNotImplemented {
+					app.Logger.Warn("Not emitted: %v", evt)
+				} else if err != nil {
+					app.Logger.Error("Emit: %v", err)
+				}
+			}
+		}
+	}
+
+	// Unsubscribe from events
+	signal.Stop(hup)
+	app.Input.Unsubscribe(evt_input)
+
+	// Return success
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// GetFilterParameters returns the filter for the devices to remap. Virtual
+// devices are excluded unless the bus is virtual, so that the virtual device
+// which remapped events are emitted from is not remapped when opened with
+// -input.autoopen
+func GetFilterParameters(app *gopi.AppInstance) (string, gopi.InputDeviceType, gopi.InputDeviceBus, error) {
+	device_name, _ := app.AppFlags.GetString("name")
+	device_type, _ := app.AppFlags.GetString("type")
+	device_bus, _ := app.AppFlags.GetString("bus")
+
+	if device_name, device_type, device_bus, err := input.ParseFilter(device_name, device_type, device_bus); err != nil {
+		return "", 0, 0, err
+	} else if device_bus == gopi.INPUT_BUS_VIRTUAL {
+		return device_name, device_type, device_bus, nil
+	} else if device_name == "" {
+		return "!bus=virtual", device_type, device_bus, nil
+	} else {
+		return device_name + input.MATCHER_SEPARATOR + "!bus=virtual", device_type, device_bus, nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+func Main(app *gopi.AppInstance, done chan<- struct{}) error {
+	// Read the rules and open the devices, which are grabbed unless
+	// -input.exclusive=false
+	remap = input.NewRemap(nil)
+	if path, _ := app.AppFlags.GetString("rules"); path == "" {
+		done <- gopi.DONE
+		return errors.New("Missing -rules flag")
+	} else if err := ReloadRules(app); err != nil {
+		done <- gopi.DONE
+		return err
+	} else if device_name, device_type, device_bus, err := GetFilterParameters(app); err != nil {
+		done <- gopi.DONE
+		return err
+	} else if devices, err = app.Input.OpenDevicesByName(device_name, device_type, device_bus); err != nil {
+		done <- gopi.DONE
+		return err
+	} else if len(devices) == 0 {
+		done <- gopi.DONE
+		return errors.New("No devices opened")
+	}
+
+	// Create the virtual device with the capabilities of the devices
+	for _, device := range devices {
+		app.Logger.Info("Remapping %v", device.Name())
+	}
+	if err := OpenVirtualDevice(app); err != nil {
+		done <- gopi.DONE
+		return err
+	}
+
+	// Send start flag
+	start <- gopi.DONE
+
+	// Wait for CTRL+C
+	fmt.Println("Remapping events, send SIGHUP to reload rules or press CTRL+C to end")
+	app.WaitForSignal()
+	fmt.Println("Terminating")
+
+	done <- gopi.DONE
+	return virtual.Close()
+}
+
+func main() {
+	config := gopi.NewAppConfig("input")
+	config.AppFlags.FlagString("rules", "", "File containing remapping rules")
+	config.AppFlags.FlagString("virtual", "gopi remapped input", "Name of the virtual device")
+	config.AppFlags.FlagString("type", "", fmt.Sprintf("Filter by type of device (%v)", strings.Join(input.DeviceTypeNames(), ",")))
+	config.AppFlags.FlagString("bus", "", fmt.Sprintf("Filter by one or more device busses (%v)", strings.Join(input.DeviceBusNames(), ",")))
+	config.AppFlags.FlagString("name", "", "Filter by device name, alias or matcher (name=, phys=, uniq=, alias=, bus=, has=, controls=, <vendor>:<product>, ! to negate, ; to combine)")
+	os.Exit(gopi.CommandLineTool(config, Main, EventLoop))
+}
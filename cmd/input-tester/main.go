@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 
@@ -12,51 +13,49 @@ import (
 	tablewriter "github.com/olekukonko/tablewriter"
 
 	// Modules
-	_ "github.com/djthorpe/gopi-input/sys/input"
+	input "github.com/djthorpe/gopi-input/sys/input"
 	_ "github.com/djthorpe/gopi/sys/logger"
 )
 
 var (
-	start     = make(chan struct{})
-	map_type  = make(map[string]gopi.InputDeviceType)
-	map_bus   = make(map[string]gopi.InputDeviceBus)
-	keys_type = make([]string, 0)
-	keys_bus  = make([]string, 0)
+	start = make(chan struct{})
 )
 
 ///////////////////////////////////////////////////////////////////////////////
 
-func init() {
-	// Device types
-	for t := gopi.INPUT_TYPE_NONE; t < gopi.INPUT_TYPE_ANY; t++ {
-		s := fmt.Sprint(t)
-		if strings.HasPrefix(s, "INPUT_TYPE_") {
-			k := strings.ToLower(strings.TrimPrefix(s, "INPUT_TYPE_"))
-			map_type[k] = t
-			keys_type = append(keys_type, k)
-		}
-	}
-	// Device bus
-	for b := gopi.INPUT_BUS_NONE; b < gopi.INPUT_BUS_ANY; b++ {
-		s := fmt.Sprint(b)
-		if strings.HasPrefix(s, "INPUT_BUS_") {
-			k := strings.ToLower(strings.TrimPrefix(s, "INPUT_BUS_"))
-			map_bus[k] = b
-			keys_bus = append(keys_bus, k)
-		}
-	}
+func stringForTime(evt gopi.InputEvent) string {
+	return input.WallClock(evt.Timestamp()).Format("15:04:05.000")
 }
 
-///////////////////////////////////////////////////////////////////////////////
-
 func stringForDevice(evt gopi.InputEvent) string {
 	device_name := evt.Source().(gopi.InputDevice).Name()
-	device_type := strings.ToLower(strings.TrimPrefix(fmt.Sprint(evt.DeviceType()), "INPUT_TYPE_"))
+	device_type := strings.ToLower(strings.Replace(input.DeviceTypeString(evt.DeviceType()), "INPUT_TYPE_", "", -1))
 	return fmt.Sprintf("%s [%s]", device_name, device_type)
 }
 
 func stringForEvent(evt gopi.InputEvent) string {
-	return strings.TrimPrefix(fmt.Sprint(evt.EventType()), "INPUT_EVENT_")
+	switch evt.EventType() {
+	case input.INPUT_EVENT_SCROLL:
+		return "SCROLL"
+	case input.INPUT_EVENT_AXIS:
+		return "AXIS"
+	case input.INPUT_EVENT_HAT:
+		return "HAT"
+	case input.INPUT_EVENT_EFFECT:
+		return "EFFECT"
+	case input.INPUT_EVENT_SWITCH:
+		return "SWITCH"
+	case input.INPUT_EVENT_STYLUS:
+		return "STYLUS"
+	case input.INPUT_EVENT_PROXIMITYIN:
+		return "PROXIMITYIN"
+	case input.INPUT_EVENT_PROXIMITYOUT:
+		return "PROXIMITYOUT"
+	case input.INPUT_EVENT_BATTERY:
+		return "BATTERY"
+	default:
+		return strings.TrimPrefix(fmt.Sprint(evt.EventType()), "INPUT_EVENT_")
+	}
 }
 
 func stringForKeyPosition(evt gopi.InputEvent) string {
@@ -64,14 +63,47 @@ func stringForKeyPosition(evt gopi.InputEvent) string {
 		return fmt.Sprintf("{%v,%v} => {%v,%v}", evt.Relative().X, evt.Relative().Y, evt.Position().X, evt.Position().Y)
 	} else if evt.EventType() == gopi.INPUT_EVENT_ABSPOSITION {
 		return fmt.Sprint(evt.Position())
+	} else if evt.EventType() == gopi.INPUT_EVENT_TOUCHPOSITION {
+		return fmt.Sprintf("%v [%v]", evt.Position(), evt.Slot())
+	} else if scroll_event, ok := evt.(input.ScrollEvent); ok && evt.EventType() == input.INPUT_EVENT_SCROLL {
+		return fmt.Sprintf("{%v,%v}", scroll_event.Scroll().X, scroll_event.Scroll().Y)
+	} else if axis_event, ok := evt.(input.AxisEvent); ok && evt.EventType() == input.INPUT_EVENT_AXIS {
+		return fmt.Sprintf("%v %.3f", strings.TrimPrefix(fmt.Sprint(axis_event.Axis()), "ABS_"), axis_event.Value())
+	} else if hat_event, ok := evt.(input.HatEvent); ok && evt.EventType() == input.INPUT_EVENT_HAT {
+		return fmt.Sprintf("%v [%v]", strings.ToLower(strings.Replace(fmt.Sprint(hat_event.Direction()), "HAT_", "", -1)), hat_event.Hat())
+	} else if effect_event, ok := evt.(input.EffectEvent); ok && evt.EventType() == input.INPUT_EVENT_EFFECT {
+		return fmt.Sprintf("%v [%v]", strings.ToLower(strings.TrimPrefix(fmt.Sprint(effect_event.EffectStatus()), "EFFECT_STATUS_")), effect_event.Effect())
+	} else if switch_event, ok := evt.(input.SwitchEvent); ok && evt.EventType() == input.INPUT_EVENT_SWITCH {
+		if switch_event.SwitchState() {
+			return fmt.Sprintf("%v on", strings.TrimPrefix(fmt.Sprint(switch_event.Switch()), "SW_"))
+		} else {
+			return fmt.Sprintf("%v off", strings.TrimPrefix(fmt.Sprint(switch_event.Switch()), "SW_"))
+		}
+	} else if stylus_event, ok := evt.(input.StylusEvent); ok && evt.EventType() == input.INPUT_EVENT_STYLUS {
+		return fmt.Sprintf("%v pressure=%.3f tilt={%v,%v}", stylus_event.Position(), stylus_event.Pressure(), stylus_event.Tilt().X, stylus_event.Tilt().Y)
+	} else if stylus_event, ok := evt.(input.StylusEvent); ok && (evt.EventType() == input.INPUT_EVENT_PROXIMITYIN || evt.EventType() == input.INPUT_EVENT_PROXIMITYOUT) {
+		return fmt.Sprintf("%v %v", strings.ToLower(strings.TrimPrefix(fmt.Sprint(stylus_event.Tool()), "STYLUS_TOOL_")), stylus_event.Position())
+	} else if battery_event, ok := evt.(input.BatteryEvent); ok && evt.EventType() == input.INPUT_EVENT_BATTERY {
+		return stringForBattery(battery_event.Battery())
 	} else {
 		return strings.TrimPrefix(fmt.Sprint(evt.KeyCode()), "KEYCODE_")
 	}
 }
 
+func stringForBattery(battery input.Battery) string {
+	status := strings.ToLower(strings.Replace(strings.TrimPrefix(fmt.Sprint(battery.Status), "BATTERY_"), "_", " ", -1))
+	if battery.Level >= 0 {
+		status = fmt.Sprintf("%.0f%% %v", battery.Level*100, status)
+	}
+	if battery.Low {
+		status = status + " (low)"
+	}
+	return status
+}
+
 func stringForDeviceState(evt gopi.InputEvent) string {
 	device := evt.Source().(gopi.InputDevice)
-	if device.Type() != gopi.INPUT_TYPE_KEYBOARD {
+	if device.Type()&gopi.INPUT_TYPE_KEYBOARD == 0 {
 		return "N/A"
 	} else {
 		key_state := fmt.Sprint(device.KeyState())
@@ -84,23 +116,68 @@ func stringForDeviceState(evt gopi.InputEvent) string {
 func PrintDevicesTable(devices []gopi.InputDevice) {
 	// Table
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Type", "Name", "Bus"})
+	table.SetHeader([]string{"ID", "Type", "Name", "Bus", "Battery"})
 	for _, d := range devices {
+		device_id := ""
+		if identity, ok := d.(input.IdentityDevice); ok {
+			device_id = fmt.Sprintf("%08X", identity.DeviceID())
+		}
+		battery := ""
+		if device, ok := d.(input.BatteryDevice); ok && device.Battery().Status != input.BATTERY_NONE {
+			battery = stringForBattery(device.Battery())
+		}
 		table.Append([]string{
-			fmt.Sprint(d.Type()),
+			device_id,
+			input.DeviceTypeString(d.Type()),
 			d.Name(),
 			fmt.Sprint(d.Bus()),
+			battery,
 		})
 	}
 	table.Render()
 }
 
+func PrintCapabilities(devices []gopi.InputDevice) {
+	for _, d := range devices {
+		caps, ok := d.(input.CapabilitiesDevice)
+		if ok == false {
+			continue
+		}
+		c := caps.Capabilities()
+		abs := make([]string, 0, len(c.AbsAxes))
+		for axis, info := range c.AbsAxes {
+			abs = append(abs, fmt.Sprintf("%v [%v,%v]", axis, info.Minimum, info.Maximum))
+		}
+		sort.Strings(abs)
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{d.Name(), ""})
+		table.SetAutoWrapText(false)
+		table.Append([]string{"Keys", fmt.Sprint(len(c.Keys))})
+		table.Append([]string{"Relative axes", fmt.Sprint(c.RelAxes)})
+		table.Append([]string{"Absolute axes", strings.Join(abs, "\n")})
+		table.Append([]string{"Switches", fmt.Sprint(c.Switches)})
+		table.Append([]string{"LEDs", fmt.Sprint(c.LEDs)})
+		table.Append([]string{"Effects", fmt.Sprint(c.Effects, c.Waveforms)})
+		table.Append([]string{"Properties", fmt.Sprint(c.Properties)})
+		table.Render()
+	}
+}
+
 func PrintInputEvent(evt gopi.InputEvent, once *sync.Once) {
 	once.Do(func() {
-		fmt.Printf("%-25s %-25s %-15s %-15s\n", "DEVICE", "KEY/POSITION", "EVENT", "STATE")
-		fmt.Printf("%-25s %-25s %-15s %-15s\n", strings.Repeat("-", 25), strings.Repeat("-", 25), strings.Repeat("-", 15), strings.Repeat("-", 15))
+		fmt.Printf("%-12s %-25s %-25s %-15s %-15s\n", "TIME", "DEVICE", "KEY/POSITION", "EVENT", "STATE")
+		fmt.Printf("%-12s %-25s %-25s %-15s %-15s\n", strings.Repeat("-", 12), strings.Repeat("-", 25), strings.Repeat("-", 25), strings.Repeat("-", 15), strings.Repeat("-", 15))
 	})
-	fmt.Printf("%-25s %-25s %-15s %-15s\n", stringForDevice(evt), stringForKeyPosition(evt), stringForEvent(evt), stringForDeviceState(evt))
+	fmt.Printf("%-12s %-25s %-25s %-15s %-15s\n", stringForTime(evt), stringForDevice(evt), stringForKeyPosition(evt), stringForEvent(evt), stringForDeviceState(evt))
+}
+
+func PrintDeviceEvent(evt input.DeviceEvent) {
+	event_type := strings.TrimPrefix(fmt.Sprint(evt.Type()), "DEVICE_EVENT_")
+	if device := evt.Device(); device != nil {
+		fmt.Printf("%v: %v (%v)\n", event_type, evt.Path(), device.Name())
+	} else {
+		fmt.Printf("%v: %v\n", event_type, evt.Path())
+	}
 }
 
 func EventLoop(app *gopi.AppInstance, done <-chan struct{}) error {
@@ -118,8 +195,11 @@ FOR_LOOP:
 			app.Logger.Info("Done")
 			break FOR_LOOP
 		case event := <-evt_input:
-			if event != nil {
+			switch event.(type) {
+			case gopi.InputEvent:
 				PrintInputEvent(event.(gopi.InputEvent), &once)
+			case input.DeviceEvent:
+				PrintDeviceEvent(event.(input.DeviceEvent))
 			}
 		}
 	}
@@ -133,48 +213,11 @@ FOR_LOOP:
 
 ///////////////////////////////////////////////////////////////////////////////
 
-func GetFilterType(app *gopi.AppInstance) (gopi.InputDeviceType, error) {
-	if device_type, exists := app.AppFlags.GetString("type"); exists == false {
-		return gopi.INPUT_TYPE_ANY, nil
-	} else {
-		filter := gopi.INPUT_TYPE_NONE
-		for _, k := range strings.Split(device_type, ",") {
-			if v, exists := map_type[k]; exists == false {
-				return 0, fmt.Errorf("Invalid type: %v", k)
-			} else {
-				filter |= v
-			}
-		}
-		return filter, nil
-	}
-}
-
-func GetFilterBus(app *gopi.AppInstance) (gopi.InputDeviceBus, error) {
-	if device_bus, exists := app.AppFlags.GetString("bus"); exists == false {
-		return gopi.INPUT_BUS_ANY, nil
-	} else {
-		filter := gopi.INPUT_BUS_NONE
-		for _, k := range strings.Split(device_bus, ",") {
-			if v, exists := map_bus[k]; exists == false {
-				return 0, fmt.Errorf("Invalid bus: %v", k)
-			} else {
-				filter |= v
-			}
-		}
-		return filter, nil
-	}
-}
-
 func GetFilterParameters(app *gopi.AppInstance) (string, gopi.InputDeviceType, gopi.InputDeviceBus, error) {
 	device_name, _ := app.AppFlags.GetString("name")
-
-	if device_type, err := GetFilterType(app); err != nil {
-		return "", 0, 0, err
-	} else if device_bus, err := GetFilterBus(app); err != nil {
-		return "", 0, 0, err
-	} else {
-		return device_name, device_type, device_bus, nil
-	}
+	device_type, _ := app.AppFlags.GetString("type")
+	device_bus, _ := app.AppFlags.GetString("bus")
+	return input.ParseFilter(device_name, device_type, device_bus)
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -192,6 +235,9 @@ func Main(app *gopi.AppInstance, done chan<- struct{}) error {
 		return errors.New("No devices opened")
 	} else {
 		PrintDevicesTable(devices)
+		if caps, _ := app.AppFlags.GetBool("caps"); caps {
+			PrintCapabilities(devices)
+		}
 	}
 
 	if watch, _ := app.AppFlags.GetBool("watch"); watch {
@@ -211,8 +257,9 @@ func Main(app *gopi.AppInstance, done chan<- struct{}) error {
 func main() {
 	config := gopi.NewAppConfig("input")
 	config.AppFlags.FlagBool("watch", false, "Watch for device events")
-	config.AppFlags.FlagString("type", "", fmt.Sprintf("Filter by type of device (%v)", strings.Join(keys_type, ",")))
-	config.AppFlags.FlagString("bus", "", fmt.Sprintf("Filter by one or more device busses (%v)", strings.Join(keys_bus, ",")))
-	config.AppFlags.FlagString("name", "", fmt.Sprintf("Filter by device name or alias"))
+	config.AppFlags.FlagBool("caps", false, "Print device capabilities")
+	config.AppFlags.FlagString("type", "", fmt.Sprintf("Filter by type of device (%v)", strings.Join(input.DeviceTypeNames(), ",")))
+	config.AppFlags.FlagString("bus", "", fmt.Sprintf("Filter by one or more device busses (%v)", strings.Join(input.DeviceBusNames(), ",")))
+	config.AppFlags.FlagString("name", "", "Filter by device name, alias or matcher (name=, phys=, uniq=, alias=, bus=, has=, controls=, <vendor>:<product>, ! to negate, ; to combine)")
 	os.Exit(gopi.CommandLineTool(config, Main, EventLoop))
 }
@@ -9,6 +9,8 @@
 package input
 
 import (
+	"time"
+
 	// Frameworks
 	gopi "github.com/djthorpe/gopi"
 	input "github.com/djthorpe/gopi-input/sys/input"
@@ -37,12 +39,63 @@ func toProtobufInputEvent(evt gopi.InputEvent) *pb.InputEvent {
 		Relative:   toProtobufPoint(evt.Relative()),
 		Slot:       uint32(evt.Slot()),
 	}
+	if ts, err := ptype.TimestampProto(input.WallClock(evt.Timestamp())); err == nil {
+		input_event.Time = ts
+	}
+	if identity_event, ok := evt.(input.IdentityEvent); ok {
+		input_event.Device = identity_event.DeviceID()
+	}
+	if scroll_event, ok := evt.(input.ScrollEvent); ok && evt.EventType() == input.INPUT_EVENT_SCROLL {
+		input_event.Scroll = toProtobufPoint(scroll_event.Scroll())
+	}
+	if stylus_event, ok := evt.(input.StylusEvent); ok && evt.DeviceType()&input.INPUT_TYPE_TABLET != 0 {
+		input_event.Stylus = toProtobufStylus(stylus_event)
+	}
+	switch evt.EventType() {
+	case input.INPUT_EVENT_AXIS:
+		if axis_event, ok := evt.(input.AxisEvent); ok {
+			input_event.Axis = &pb.Axis{Axis: uint32(axis_event.Axis()), Value: axis_event.Value()}
+		}
+	case input.INPUT_EVENT_HAT:
+		if hat_event, ok := evt.(input.HatEvent); ok {
+			input_event.Hat = &pb.Hat{Hat: uint32(hat_event.Hat()), Direction: uint32(hat_event.Direction())}
+		}
+	case input.INPUT_EVENT_EFFECT:
+		if effect_event, ok := evt.(input.EffectEvent); ok {
+			input_event.Effect = &pb.Effect{Effect: int32(effect_event.Effect()), Status: pb.EffectStatus(effect_event.EffectStatus())}
+		}
+	case input.INPUT_EVENT_SWITCH:
+		if switch_event, ok := evt.(input.SwitchEvent); ok {
+			input_event.Switch = &pb.Switch{Code: uint32(switch_event.Switch()), State: switch_event.SwitchState()}
+		}
+	case input.INPUT_EVENT_BATTERY:
+		if battery_event, ok := evt.(input.BatteryEvent); ok {
+			input_event.Battery = toProtobufBattery(battery_event.Battery())
+		}
+	}
 	return input_event
 }
 
 func fromProtobufInputEvent(source gopi.InputDevice, evt *pb.InputEvent) gopi.InputEvent {
 	ts, _ := ptype.Duration(evt.Ts)
 	// TODO: Set device_type and key_state from protobuf
+	if gopi.InputEventType(evt.EventType) == input.INPUT_EVENT_SCROLL {
+		return input.NewScrollEvent(source, ts, fromProtobufPoint(evt.Position), fromProtobufPoint(evt.Scroll))
+	}
+	switch gopi.InputEventType(evt.EventType) {
+	case input.INPUT_EVENT_STYLUS, input.INPUT_EVENT_PROXIMITYIN, input.INPUT_EVENT_PROXIMITYOUT:
+		return fromProtobufStylusEvent(source, ts, evt)
+	case input.INPUT_EVENT_AXIS:
+		return input.NewAxisEvent(source, ts, input.AbsAxis(evt.Axis.GetAxis()), evt.Axis.GetValue())
+	case input.INPUT_EVENT_HAT:
+		return input.NewHatEvent(source, ts, uint(evt.Hat.GetHat()), input.HatDirection(evt.Hat.GetDirection()))
+	case input.INPUT_EVENT_EFFECT:
+		return input.NewEffectEvent(source, ts, input.EffectID(evt.Effect.GetEffect()), input.EffectStatus(evt.Effect.GetStatus()))
+	case input.INPUT_EVENT_SWITCH:
+		return input.NewSwitchEvent(source, ts, input.SwitchCode(evt.Switch.GetCode()), evt.Switch.GetState())
+	case input.INPUT_EVENT_BATTERY:
+		return input.NewBatteryEvent(source, ts, fromProtobufBattery(evt.Battery))
+	}
 	return input.NewInputEvent(
 		source, ts, gopi.InputEventType(evt.EventType),
 		gopi.KeyCode(evt.KeyCode), uint32(evt.ScanCode),
@@ -50,6 +103,48 @@ func fromProtobufInputEvent(source gopi.InputDevice, evt *pb.InputEvent) gopi.In
 	)
 }
 
+func fromProtobufStylusEvent(source gopi.InputDevice, ts time.Duration, evt *pb.InputEvent) gopi.InputEvent {
+	stylus := evt.Stylus
+	if stylus == nil {
+		stylus = &pb.Stylus{}
+	}
+	return input.NewStylusEvent(
+		source, ts, gopi.InputEventType(evt.EventType), input.StylusTool(stylus.Tool),
+		fromProtobufPoint(evt.Position), stylus.Pressure, fromProtobufPoint(stylus.Tilt),
+		stylus.Distance, input.StylusButton(stylus.Buttons),
+	)
+}
+
+func toProtobufStylus(evt input.StylusEvent) *pb.Stylus {
+	return &pb.Stylus{
+		Tool:     pb.StylusTool(evt.Tool()),
+		Pressure: evt.Pressure(),
+		Tilt:     toProtobufPoint(evt.Tilt()),
+		Distance: evt.Distance(),
+		Buttons:  uint32(evt.Buttons()),
+	}
+}
+
+func toProtobufBattery(battery input.Battery) *pb.Battery {
+	return &pb.Battery{
+		Status: pb.BatteryStatus(battery.Status),
+		Level:  battery.Level,
+		Low:    battery.Low,
+	}
+}
+
+func fromProtobufBattery(battery *pb.Battery) input.Battery {
+	if battery == nil {
+		return input.Battery{}
+	} else {
+		return input.Battery{
+			Status: input.BatteryStatus(battery.Status),
+			Level:  battery.Level,
+			Low:    battery.Low,
+		}
+	}
+}
+
 func toProtobufPoint(pt gopi.Point) *pb.Point {
 	return &pb.Point{
 		X: pt.X,
@@ -69,7 +164,14 @@ func fromProtobufPoint(pt *pb.Point) gopi.Point {
 }
 
 func toProtobufInputDevice(device gopi.InputDevice) *pb.InputDevice {
-	// TODO: Set device here
-	input_device := &pb.InputDevice{}
+	input_device := &pb.InputDevice{
+		Name:       device.Name(),
+		DeviceType: pb.InputDeviceType(device.Type()),
+		DeviceBus:  pb.InputDeviceBus(device.Bus()),
+		Position:   toProtobufPoint(device.Position()),
+	}
+	if identity, ok := device.(input.IdentityDevice); ok {
+		input_device.DeviceId = identity.DeviceID()
+	}
 	return input_device
 }
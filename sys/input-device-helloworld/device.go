@@ -20,7 +20,6 @@ import (
 )
 
 var (
-	ts       = time.Now()
 	keycodes = []gopi.KeyCode{
 		gopi.KEYCODE_H,
 		gopi.KEYCODE_E,
@@ -163,6 +162,6 @@ FOR_LOOP:
 }
 
 func (this *device) emitEvent(event_type gopi.InputEventType, keycode gopi.KeyCode) {
-	this.Emit(input.NewInputEvent(this, time.Now().Sub(ts),
+	this.Emit(input.NewInputEvent(this, input.Timestamp(),
 		event_type, keycode, 0, 0, this.Position(), gopi.ZeroPoint))
 }
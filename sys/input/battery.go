@@ -0,0 +1,113 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"fmt"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACES
+
+// BatteryDevice is implemented by input devices which can report the
+// battery of a wireless keyboard, mouse or gamepad
+type BatteryDevice interface {
+	gopi.InputDevice
+
+	// Return the battery level and charging state, where the status
+	// is BATTERY_NONE if the device has no battery
+	Battery() Battery
+}
+
+// BatteryEvent is an input event for INPUT_EVENT_BATTERY, which reports
+// a change in the battery level or charging state, or that the battery
+// has become low. Battery returns zero for other event types
+type BatteryEvent interface {
+	gopi.InputEvent
+
+	// The battery level and charging state
+	Battery() Battery
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Battery is the level and charging state of a battery
+type Battery struct {
+	Status BatteryStatus
+
+	// Level between 0.0 and 1.0, or less than zero if the
+	// device doesn't report the level
+	Level float32
+
+	// Whether the battery is low
+	Low bool
+}
+
+// BatteryStatus is the charging state of a battery
+type BatteryStatus uint
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Change in battery level or charging state, in addition
+	// to the gopi.InputEventType values
+	INPUT_EVENT_BATTERY gopi.InputEventType = 0x0011
+
+	// Level at or below which a battery is low, when the device
+	// doesn't report that the battery is low
+	BATTERY_LOW_LEVEL = 0.1
+)
+
+// Battery charging states
+const (
+	BATTERY_NONE         BatteryStatus = iota // No battery
+	BATTERY_UNKNOWN                           // Charging state is not known
+	BATTERY_CHARGING                          // Battery is charging
+	BATTERY_DISCHARGING                       // Battery is discharging
+	BATTERY_NOT_CHARGING                      // Battery is connected to power but not charging
+	BATTERY_FULL                              // Battery is fully charged
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (b Battery) String() string {
+	if b.Status == BATTERY_NONE {
+		return "<input.Battery>{ status=BATTERY_NONE }"
+	} else if b.Level < 0 {
+		return fmt.Sprintf("<input.Battery>{ status=%v low=%v }", b.Status, b.Low)
+	} else {
+		return fmt.Sprintf("<input.Battery>{ status=%v level=%.0f%% low=%v }", b.Status, b.Level*100, b.Low)
+	}
+}
+
+func (s BatteryStatus) String() string {
+	switch s {
+	case BATTERY_NONE:
+		return "BATTERY_NONE"
+	case BATTERY_UNKNOWN:
+		return "BATTERY_UNKNOWN"
+	case BATTERY_CHARGING:
+		return "BATTERY_CHARGING"
+	case BATTERY_DISCHARGING:
+		return "BATTERY_DISCHARGING"
+	case BATTERY_NOT_CHARGING:
+		return "BATTERY_NOT_CHARGING"
+	case BATTERY_FULL:
+		return "BATTERY_FULL"
+	default:
+		return "[?? Invalid BatteryStatus value]"
+	}
+}
@@ -0,0 +1,198 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Calibration is an affine transform which corrects the absolute
+// positions reported by a device, including rotation, flipping and
+// offset. The position (x,y) is transformed to:
+//
+//	x' = A*x + B*y + C
+//	y' = D*x + E*y + F
+//
+// which is the top two rows of a 3x3 matrix whose bottom row is (0 0 1)
+type Calibration struct {
+	A, B, C float32
+	D, E, F float32
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// File extension for calibration files
+	CALIBRATION_FILE_EXT = ".calibration"
+
+	// Divisor for coefficients in calibration files, as used by tslib
+	CALIBRATION_FILE_SCALE = 65536
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// The calibration which does not change positions
+	IdentityCalibration = Calibration{A: 1, E: 1}
+
+	ErrCalibrationPoints = errors.New("At least three non-collinear points are required for calibration")
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CALIBRATION
+
+// NewCalibration returns the calibration which best transforms each
+// sample position to each target position, using a least squares fit.
+// At least three samples are required which are not in a straight line
+func NewCalibration(samples, targets []gopi.Point) (Calibration, error) {
+	if len(samples) != len(targets) || len(samples) < 3 {
+		return IdentityCalibration, ErrCalibrationPoints
+	}
+
+	// Sum the normal equations: M is the sum of [x y 1]'[x y 1]
+	// and vx, vy are the sums of [x y 1]' multiplied by the target
+	var m [3][3]float64
+	var vx, vy [3]float64
+	for i, sample := range samples {
+		row := [3]float64{float64(sample.X), float64(sample.Y), 1}
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				m[j][k] += row[j] * row[k]
+			}
+			vx[j] += row[j] * float64(targets[i].X)
+			vy[j] += row[j] * float64(targets[i].Y)
+		}
+	}
+
+	// Solve for each row of the matrix
+	if abc, err := calibrationSolve(m, vx); err != nil {
+		return IdentityCalibration, err
+	} else if def, err := calibrationSolve(m, vy); err != nil {
+		return IdentityCalibration, err
+	} else {
+		return Calibration{
+			A: float32(abc[0]), B: float32(abc[1]), C: float32(abc[2]),
+			D: float32(def[0]), E: float32(def[1]), F: float32(def[2]),
+		}, nil
+	}
+}
+
+// Transform returns a calibrated position
+func (this Calibration) Transform(pt gopi.Point) gopi.Point {
+	return gopi.Point{
+		X: this.A*pt.X + this.B*pt.Y + this.C,
+		Y: this.D*pt.X + this.E*pt.Y + this.F,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CALIBRATION FILES
+
+// CalibrationPath returns the path to the calibration file for
+// a device within a folder, which is based on the device name
+func CalibrationPath(folder, name string) string {
+	file := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimSpace(name))
+	return filepath.Join(folder, file+CALIBRATION_FILE_EXT)
+}
+
+// ReadCalibration reads a calibration file, which contains the seven
+// integer values "A B C D E F S" as used by the tslib pointercal file,
+// where each coefficient is divided by S
+func ReadCalibration(path string) (Calibration, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return IdentityCalibration, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 7 {
+		return IdentityCalibration, fmt.Errorf("Invalid calibration file: %v", path)
+	}
+	values := make([]float32, 7)
+	for i := range values {
+		if value, err := strconv.ParseInt(fields[i], 10, 64); err != nil {
+			return IdentityCalibration, fmt.Errorf("Invalid calibration file: %v", path)
+		} else {
+			values[i] = float32(value)
+		}
+	}
+	if values[6] == 0 {
+		return IdentityCalibration, fmt.Errorf("Invalid calibration file: %v", path)
+	}
+	return Calibration{
+		A: values[0] / values[6], B: values[1] / values[6], C: values[2] / values[6],
+		D: values[3] / values[6], E: values[4] / values[6], F: values[5] / values[6],
+	}, nil
+}
+
+// WriteCalibration writes a calibration file in the same format
+// as read by ReadCalibration
+func WriteCalibration(path string, calibration Calibration) error {
+	values := []float32{calibration.A, calibration.B, calibration.C, calibration.D, calibration.E, calibration.F}
+	fields := make([]string, 0, 7)
+	for _, value := range values {
+		fields = append(fields, fmt.Sprint(int64(math.Round(float64(value)*CALIBRATION_FILE_SCALE))))
+	}
+	fields = append(fields, fmt.Sprint(CALIBRATION_FILE_SCALE))
+	return ioutil.WriteFile(path, []byte(strings.Join(fields, " ")+"\n"), 0644)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this Calibration) String() string {
+	return fmt.Sprintf("<input.Calibration>{ x'=%v*x%+v*y%+v y'=%v*x%+v*y%+v }", this.A, this.B, this.C, this.D, this.E, this.F)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// calibrationSolve solves the equations m.x = v using Cramer's rule
+func calibrationSolve(m [3][3]float64, v [3]float64) ([3]float64, error) {
+	det := calibrationDeterminant(m)
+	if math.Abs(det) < 1e-9 {
+		return [3]float64{}, ErrCalibrationPoints
+	}
+	var x [3]float64
+	for i := 0; i < 3; i++ {
+		mi := m
+		for j := 0; j < 3; j++ {
+			mi[j][i] = v[j]
+		}
+		x[i] = calibrationDeterminant(mi) / det
+	}
+	return x, nil
+}
+
+func calibrationDeterminant(m [3][3]float64) float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
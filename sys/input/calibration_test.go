@@ -0,0 +1,89 @@
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+func testCalibrationEqual(a, b Calibration) bool {
+	const epsilon = 1e-3
+	values_a := []float32{a.A, a.B, a.C, a.D, a.E, a.F}
+	values_b := []float32{b.A, b.B, b.C, b.D, b.E, b.F}
+	for i := range values_a {
+		if math.Abs(float64(values_a[i]-values_b[i])) > epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCalibration_000(t *testing.T) {
+	// Rotated by 90 degrees, flipped and offset
+	expected := Calibration{A: 0, B: -1, C: 4095, D: 1, E: 0, F: -50}
+	samples := []gopi.Point{{X: 100, Y: 100}, {X: 4000, Y: 100}, {X: 4000, Y: 4000}, {X: 100, Y: 4000}, {X: 2048, Y: 2048}}
+	targets := make([]gopi.Point, len(samples))
+	for i, sample := range samples {
+		targets[i] = expected.Transform(sample)
+	}
+	if calibration, err := NewCalibration(samples, targets); err != nil {
+		t.Fatal(err)
+	} else if testCalibrationEqual(calibration, expected) == false {
+		t.Errorf("Expected %v, got %v", expected, calibration)
+	}
+}
+
+func TestCalibration_001(t *testing.T) {
+	// Collinear points cannot be used for calibration
+	samples := []gopi.Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 2}}
+	if _, err := NewCalibration(samples, samples); err != ErrCalibrationPoints {
+		t.Errorf("Expected ErrCalibrationPoints, got %v", err)
+	}
+	if _, err := NewCalibration(samples[0:2], samples[0:2]); err != ErrCalibrationPoints {
+		t.Errorf("Expected ErrCalibrationPoints, got %v", err)
+	}
+}
+
+func TestCalibration_002(t *testing.T) {
+	folder, err := ioutil.TempDir("", "calibration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+
+	path := CalibrationPath(folder, "FT5406 memory based driver")
+	if filepath.Base(path) != "FT5406_memory_based_driver.calibration" {
+		t.Errorf("Unexpected calibration path: %v", path)
+	}
+	calibration := Calibration{A: -1, B: 0, C: 800, D: 0, E: 1.5, F: 0.25}
+	if err := WriteCalibration(path, calibration); err != nil {
+		t.Fatal(err)
+	} else if other, err := ReadCalibration(path); err != nil {
+		t.Fatal(err)
+	} else if testCalibrationEqual(calibration, other) == false {
+		t.Errorf("Expected %v, got %v", calibration, other)
+	}
+
+	// tslib pointercal file with resolution
+	if err := ioutil.WriteFile(path, []byte("0 -65536 52428800 65536 0 0 65536 800 480\n"), 0644); err != nil {
+		t.Fatal(err)
+	} else if other, err := ReadCalibration(path); err != nil {
+		t.Fatal(err)
+	} else if pt := other.Transform(gopi.Point{X: 10, Y: 20}); pt != (gopi.Point{X: 780, Y: 10}) {
+		t.Errorf("Unexpected transform: %v", pt)
+	}
+}
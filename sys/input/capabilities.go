@@ -0,0 +1,240 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"fmt"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACES
+
+// CapabilitiesDevice is implemented by input devices which can report
+// the codes and properties they support
+type CapabilitiesDevice interface {
+	gopi.InputDevice
+
+	// Return the codes and properties supported by the device
+	Capabilities() Capabilities
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Capabilities describes the key codes, axes, switches, LEDs, force
+// feedback effects and properties supported by an input device. Only
+// the effects and waveforms which can be uploaded are reported
+type Capabilities struct {
+	Keys       []gopi.KeyCode
+	RelAxes    []RelAxis
+	AbsAxes    map[AbsAxis]AbsInfo
+	Switches   []SwitchCode
+	LEDs       []LEDCode
+	Effects    []EffectType
+	Waveforms  []EffectWaveform
+	Properties []InputProperty
+}
+
+// RelAxis is a relative axis code
+type RelAxis uint16
+
+// LEDCode identifies an LED
+type LEDCode uint16
+
+// InputProperty describes how a device should be interpreted
+type InputProperty uint16
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Relative axes
+const (
+	REL_X             RelAxis = 0x0000
+	REL_Y             RelAxis = 0x0001
+	REL_Z             RelAxis = 0x0002
+	REL_RX            RelAxis = 0x0003
+	REL_RY            RelAxis = 0x0004
+	REL_RZ            RelAxis = 0x0005
+	REL_HWHEEL        RelAxis = 0x0006
+	REL_DIAL          RelAxis = 0x0007
+	REL_WHEEL         RelAxis = 0x0008
+	REL_MISC          RelAxis = 0x0009
+	REL_WHEEL_HI_RES  RelAxis = 0x000B
+	REL_HWHEEL_HI_RES RelAxis = 0x000C
+	REL_MAX           RelAxis = 0x000F
+)
+
+// LEDs
+const (
+	LED_NUML     LEDCode = 0x0000
+	LED_CAPSL    LEDCode = 0x0001
+	LED_SCROLLL  LEDCode = 0x0002
+	LED_COMPOSE  LEDCode = 0x0003
+	LED_KANA     LEDCode = 0x0004
+	LED_SLEEP    LEDCode = 0x0005
+	LED_SUSPEND  LEDCode = 0x0006
+	LED_MUTE     LEDCode = 0x0007
+	LED_MISC     LEDCode = 0x0008
+	LED_MAIL     LEDCode = 0x0009
+	LED_CHARGING LEDCode = 0x000A
+	LED_MAX      LEDCode = 0x000F
+)
+
+// Properties
+const (
+	INPUT_PROP_POINTER        InputProperty = 0x0000 // Needs a pointer
+	INPUT_PROP_DIRECT         InputProperty = 0x0001 // Direct input device, such as a touchscreen
+	INPUT_PROP_BUTTONPAD      InputProperty = 0x0002 // Has button under pad
+	INPUT_PROP_SEMI_MT        InputProperty = 0x0003 // Touch rectangle only
+	INPUT_PROP_TOPBUTTONPAD   InputProperty = 0x0004 // Softbuttons at top of pad
+	INPUT_PROP_POINTING_STICK InputProperty = 0x0005 // Is a pointing stick
+	INPUT_PROP_ACCELEROMETER  InputProperty = 0x0006 // Has accelerometer
+	INPUT_PROP_MAX            InputProperty = 0x001F
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// METHODS
+
+// HasKey returns true if a key or button is supported
+func (c Capabilities) HasKey(key gopi.KeyCode) bool {
+	for _, k := range c.Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRelAxis returns true if a relative axis is supported
+func (c Capabilities) HasRelAxis(axis RelAxis) bool {
+	for _, a := range c.RelAxes {
+		if a == axis {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAbsAxis returns true if an absolute axis is supported
+func (c Capabilities) HasAbsAxis(axis AbsAxis) bool {
+	_, exists := c.AbsAxes[axis]
+	return exists
+}
+
+// HasSwitch returns true if a switch is supported
+func (c Capabilities) HasSwitch(switch_code SwitchCode) bool {
+	for _, s := range c.Switches {
+		if s == switch_code {
+			return true
+		}
+	}
+	return false
+}
+
+// HasProperty returns true if the device has a property
+func (c Capabilities) HasProperty(property InputProperty) bool {
+	for _, p := range c.Properties {
+		if p == property {
+			return true
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (c Capabilities) String() string {
+	return fmt.Sprintf("<input.Capabilities>{ keys=%v rel=%v abs=%v switches=%v leds=%v effects=%v waveforms=%v properties=%v }", len(c.Keys), c.RelAxes, len(c.AbsAxes), c.Switches, c.LEDs, c.Effects, c.Waveforms, c.Properties)
+}
+
+func (a RelAxis) String() string {
+	switch a {
+	case REL_X:
+		return "REL_X"
+	case REL_Y:
+		return "REL_Y"
+	case REL_Z:
+		return "REL_Z"
+	case REL_RX:
+		return "REL_RX"
+	case REL_RY:
+		return "REL_RY"
+	case REL_RZ:
+		return "REL_RZ"
+	case REL_HWHEEL:
+		return "REL_HWHEEL"
+	case REL_DIAL:
+		return "REL_DIAL"
+	case REL_WHEEL:
+		return "REL_WHEEL"
+	case REL_MISC:
+		return "REL_MISC"
+	case REL_WHEEL_HI_RES:
+		return "REL_WHEEL_HI_RES"
+	case REL_HWHEEL_HI_RES:
+		return "REL_HWHEEL_HI_RES"
+	default:
+		return "[?? Invalid RelAxis value]"
+	}
+}
+
+func (l LEDCode) String() string {
+	switch l {
+	case LED_NUML:
+		return "LED_NUML"
+	case LED_CAPSL:
+		return "LED_CAPSL"
+	case LED_SCROLLL:
+		return "LED_SCROLLL"
+	case LED_COMPOSE:
+		return "LED_COMPOSE"
+	case LED_KANA:
+		return "LED_KANA"
+	case LED_SLEEP:
+		return "LED_SLEEP"
+	case LED_SUSPEND:
+		return "LED_SUSPEND"
+	case LED_MUTE:
+		return "LED_MUTE"
+	case LED_MISC:
+		return "LED_MISC"
+	case LED_MAIL:
+		return "LED_MAIL"
+	case LED_CHARGING:
+		return "LED_CHARGING"
+	default:
+		return "[?? Invalid LEDCode value]"
+	}
+}
+
+func (p InputProperty) String() string {
+	switch p {
+	case INPUT_PROP_POINTER:
+		return "INPUT_PROP_POINTER"
+	case INPUT_PROP_DIRECT:
+		return "INPUT_PROP_DIRECT"
+	case INPUT_PROP_BUTTONPAD:
+		return "INPUT_PROP_BUTTONPAD"
+	case INPUT_PROP_SEMI_MT:
+		return "INPUT_PROP_SEMI_MT"
+	case INPUT_PROP_TOPBUTTONPAD:
+		return "INPUT_PROP_TOPBUTTONPAD"
+	case INPUT_PROP_POINTING_STICK:
+		return "INPUT_PROP_POINTING_STICK"
+	case INPUT_PROP_ACCELEROMETER:
+		return "INPUT_PROP_ACCELEROMETER"
+	default:
+		return "[?? Invalid InputProperty value]"
+	}
+}
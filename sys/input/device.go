@@ -0,0 +1,384 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACES
+
+// IdentityDevice is implemented by input devices which can report
+// their physical path, unique identifier, alias, vendor and product
+// IDs and a device ID
+type IdentityDevice interface {
+	gopi.InputDevice
+
+	// Return the device ID, which is the same each time the device
+	// is opened and differs between identical devices
+	DeviceID() uint32
+
+	// Return the physical path and unique identifier of the device,
+	// which are empty if not known
+	Phys() string
+	Uniq() string
+
+	// Return the alias for the device from the device rules, or empty
+	Alias() string
+
+	// Return the vendor and product IDs of the device
+	Vendor() uint16
+	Product() uint16
+}
+
+// IdentityManager is implemented by input managers which can
+// return an opened device from a device ID
+type IdentityManager interface {
+	gopi.InputManager
+
+	// Return the opened device with a device ID, or nil
+	DeviceByID(device_id uint32) gopi.InputDevice
+}
+
+// KeyDevice is implemented by input devices which can report
+// which keys are currently pressed
+type KeyDevice interface {
+	gopi.InputDevice
+
+	// Return the keys and buttons which are currently pressed
+	PressedKeys() []gopi.KeyCode
+}
+
+// RepeatDevice is implemented by input devices which can
+// repeat keys which are held down, such as keyboards
+type RepeatDevice interface {
+	gopi.InputDevice
+
+	// Return the key repeat mode, delay and period
+	KeyRepeat() KeyRepeat
+
+	// Set the key repeat mode, delay and period
+	SetKeyRepeat(repeat KeyRepeat) error
+}
+
+// AbsDevice is implemented by input devices which report
+// absolute axes, such as touchscreens and joysticks
+type AbsDevice interface {
+	gopi.InputDevice
+
+	// Return the range and resolution of each supported axis
+	AbsInfo() map[AbsAxis]AbsInfo
+
+	// Return the scaling applied to absolute positions
+	PositionMode() (PositionMode, gopi.Size)
+
+	// Set the scaling applied to absolute positions
+	SetPositionMode(mode PositionMode, size gopi.Size) error
+}
+
+// CalibrationDevice is implemented by input devices which correct
+// absolute positions with a calibration matrix, such as touchscreens
+type CalibrationDevice interface {
+	gopi.InputDevice
+
+	// Return the calibration applied to absolute positions
+	Calibration() Calibration
+
+	// Set the calibration applied to absolute positions
+	SetCalibration(calibration Calibration)
+}
+
+// JoystickDevice is implemented by input devices which report
+// joystick or gamepad axes and hats
+type JoystickDevice interface {
+	gopi.InputDevice
+
+	// Return the current value of an axis, between -1.0 and 1.0 for
+	// sticks and between 0.0 and 1.0 for triggers and throttles
+	AxisValue(axis AbsAxis) float32
+
+	// Return the current direction of a hat
+	HatDirection(hat uint) HatDirection
+
+	// Set the dead zone for an axis, as a proportion of the range of
+	// the axis between 0.0 and 1.0. Values within the dead zone
+	// are reported as zero
+	SetDeadZone(axis AbsAxis, dead_zone float32) error
+
+	// Set the fuzz for an axis, in device units. Changes which are
+	// no greater than the fuzz are ignored
+	SetFuzz(axis AbsAxis, fuzz int32) error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// AbsAxis is an absolute axis code
+type AbsAxis uint16
+
+// AbsInfo describes the current value, range and resolution of
+// an absolute axis. Resolution is in units per millimetre
+type AbsInfo struct {
+	Value      int32
+	Minimum    int32
+	Maximum    int32
+	Fuzz       int32
+	Flat       int32
+	Resolution int32
+}
+
+// PositionMode determines how absolute positions are scaled
+type PositionMode uint
+
+// KeyRepeat determines how keys which are held down are repeated. The
+// delay is the time before the first repeat, and period is the time
+// between subsequent repeats
+type KeyRepeat struct {
+	Mode   RepeatMode
+	Delay  time.Duration
+	Period time.Duration
+}
+
+// RepeatMode determines whether keys are repeated by the kernel,
+// in software or not at all
+type RepeatMode uint
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Touchpads and graphics tablets, in addition to the
+	// gopi.InputDeviceType values
+	INPUT_TYPE_TOUCHPAD gopi.InputDeviceType = 0x40
+	INPUT_TYPE_TABLET   gopi.InputDeviceType = 0x80
+)
+
+// Absolute axes
+const (
+	ABS_X              AbsAxis = 0x0000
+	ABS_Y              AbsAxis = 0x0001
+	ABS_Z              AbsAxis = 0x0002
+	ABS_RX             AbsAxis = 0x0003
+	ABS_RY             AbsAxis = 0x0004
+	ABS_RZ             AbsAxis = 0x0005
+	ABS_THROTTLE       AbsAxis = 0x0006
+	ABS_RUDDER         AbsAxis = 0x0007
+	ABS_WHEEL          AbsAxis = 0x0008
+	ABS_GAS            AbsAxis = 0x0009
+	ABS_BRAKE          AbsAxis = 0x000A
+	ABS_HAT0X          AbsAxis = 0x0010
+	ABS_HAT0Y          AbsAxis = 0x0011
+	ABS_HAT1X          AbsAxis = 0x0012
+	ABS_HAT1Y          AbsAxis = 0x0013
+	ABS_HAT2X          AbsAxis = 0x0014
+	ABS_HAT2Y          AbsAxis = 0x0015
+	ABS_HAT3X          AbsAxis = 0x0016
+	ABS_HAT3Y          AbsAxis = 0x0017
+	ABS_PRESSURE       AbsAxis = 0x0018
+	ABS_DISTANCE       AbsAxis = 0x0019
+	ABS_TILT_X         AbsAxis = 0x001A
+	ABS_TILT_Y         AbsAxis = 0x001B
+	ABS_TOOL_WIDTH     AbsAxis = 0x001C
+	ABS_VOLUME         AbsAxis = 0x0020
+	ABS_MISC           AbsAxis = 0x0028
+	ABS_MT_SLOT        AbsAxis = 0x002F
+	ABS_MT_TOUCH_MAJOR AbsAxis = 0x0030
+	ABS_MT_TOUCH_MINOR AbsAxis = 0x0031
+	ABS_MT_WIDTH_MAJOR AbsAxis = 0x0032
+	ABS_MT_WIDTH_MINOR AbsAxis = 0x0033
+	ABS_MT_ORIENTATION AbsAxis = 0x0034
+	ABS_MT_POSITION_X  AbsAxis = 0x0035
+	ABS_MT_POSITION_Y  AbsAxis = 0x0036
+	ABS_MT_TOOL_TYPE   AbsAxis = 0x0037
+	ABS_MT_BLOB_ID     AbsAxis = 0x0038
+	ABS_MT_TRACKING_ID AbsAxis = 0x0039
+	ABS_MT_PRESSURE    AbsAxis = 0x003A
+	ABS_MT_DISTANCE    AbsAxis = 0x003B
+	ABS_MT_TOOL_X      AbsAxis = 0x003C
+	ABS_MT_TOOL_Y      AbsAxis = 0x003D
+	ABS_MAX            AbsAxis = 0x003F
+)
+
+// Scaling of absolute positions
+const (
+	POSITION_RAW        PositionMode = iota // Positions as reported by the device
+	POSITION_NORMALISED                     // Positions between 0.0 and 1.0
+	POSITION_SCALED                         // Positions scaled to a screen size
+)
+
+// Key repeat modes
+const (
+	REPEAT_DEFAULT  RepeatMode = iota // Keep the repeat settings of the device
+	REPEAT_KERNEL                     // Keys are repeated by the kernel
+	REPEAT_SOFTWARE                   // Keys are repeated by the input device
+	REPEAT_NONE                       // Keys are not repeated
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+// DeviceTypeString returns the flags of a device type, including the
+// device types in this package, separated by "|"
+func DeviceTypeString(device_type gopi.InputDeviceType) string {
+	if device_type == gopi.INPUT_TYPE_NONE || device_type == gopi.INPUT_TYPE_ANY {
+		return fmt.Sprint(device_type)
+	}
+	flags := make([]string, 0)
+	for flag := gopi.InputDeviceType(1); flag != 0; flag <<= 1 {
+		if device_type&flag == 0 {
+			continue
+		}
+		switch flag {
+		case INPUT_TYPE_SWITCH:
+			flags = append(flags, "INPUT_TYPE_SWITCH")
+		case INPUT_TYPE_TOUCHPAD:
+			flags = append(flags, "INPUT_TYPE_TOUCHPAD")
+		case INPUT_TYPE_TABLET:
+			flags = append(flags, "INPUT_TYPE_TABLET")
+		default:
+			flags = append(flags, fmt.Sprint(flag))
+		}
+	}
+	return strings.Join(flags, "|")
+}
+
+func (r KeyRepeat) String() string {
+	switch r.Mode {
+	case REPEAT_KERNEL, REPEAT_SOFTWARE:
+		return fmt.Sprintf("<input.KeyRepeat>{ mode=%v delay=%v period=%v }", r.Mode, r.Delay, r.Period)
+	default:
+		return fmt.Sprintf("<input.KeyRepeat>{ mode=%v }", r.Mode)
+	}
+}
+
+func (m RepeatMode) String() string {
+	switch m {
+	case REPEAT_DEFAULT:
+		return "REPEAT_DEFAULT"
+	case REPEAT_KERNEL:
+		return "REPEAT_KERNEL"
+	case REPEAT_SOFTWARE:
+		return "REPEAT_SOFTWARE"
+	case REPEAT_NONE:
+		return "REPEAT_NONE"
+	default:
+		return "[?? Invalid RepeatMode value]"
+	}
+}
+
+func (m PositionMode) String() string {
+	switch m {
+	case POSITION_RAW:
+		return "POSITION_RAW"
+	case POSITION_NORMALISED:
+		return "POSITION_NORMALISED"
+	case POSITION_SCALED:
+		return "POSITION_SCALED"
+	default:
+		return "[?? Invalid PositionMode value]"
+	}
+}
+
+func (a AbsAxis) String() string {
+	switch a {
+	case ABS_X:
+		return "ABS_X"
+	case ABS_Y:
+		return "ABS_Y"
+	case ABS_Z:
+		return "ABS_Z"
+	case ABS_RX:
+		return "ABS_RX"
+	case ABS_RY:
+		return "ABS_RY"
+	case ABS_RZ:
+		return "ABS_RZ"
+	case ABS_THROTTLE:
+		return "ABS_THROTTLE"
+	case ABS_RUDDER:
+		return "ABS_RUDDER"
+	case ABS_WHEEL:
+		return "ABS_WHEEL"
+	case ABS_GAS:
+		return "ABS_GAS"
+	case ABS_BRAKE:
+		return "ABS_BRAKE"
+	case ABS_HAT0X:
+		return "ABS_HAT0X"
+	case ABS_HAT0Y:
+		return "ABS_HAT0Y"
+	case ABS_HAT1X:
+		return "ABS_HAT1X"
+	case ABS_HAT1Y:
+		return "ABS_HAT1Y"
+	case ABS_HAT2X:
+		return "ABS_HAT2X"
+	case ABS_HAT2Y:
+		return "ABS_HAT2Y"
+	case ABS_HAT3X:
+		return "ABS_HAT3X"
+	case ABS_HAT3Y:
+		return "ABS_HAT3Y"
+	case ABS_PRESSURE:
+		return "ABS_PRESSURE"
+	case ABS_DISTANCE:
+		return "ABS_DISTANCE"
+	case ABS_TILT_X:
+		return "ABS_TILT_X"
+	case ABS_TILT_Y:
+		return "ABS_TILT_Y"
+	case ABS_TOOL_WIDTH:
+		return "ABS_TOOL_WIDTH"
+	case ABS_VOLUME:
+		return "ABS_VOLUME"
+	case ABS_MISC:
+		return "ABS_MISC"
+	case ABS_MT_SLOT:
+		return "ABS_MT_SLOT"
+	case ABS_MT_TOUCH_MAJOR:
+		return "ABS_MT_TOUCH_MAJOR"
+	case ABS_MT_TOUCH_MINOR:
+		return "ABS_MT_TOUCH_MINOR"
+	case ABS_MT_WIDTH_MAJOR:
+		return "ABS_MT_WIDTH_MAJOR"
+	case ABS_MT_WIDTH_MINOR:
+		return "ABS_MT_WIDTH_MINOR"
+	case ABS_MT_ORIENTATION:
+		return "ABS_MT_ORIENTATION"
+	case ABS_MT_POSITION_X:
+		return "ABS_MT_POSITION_X"
+	case ABS_MT_POSITION_Y:
+		return "ABS_MT_POSITION_Y"
+	case ABS_MT_TOOL_TYPE:
+		return "ABS_MT_TOOL_TYPE"
+	case ABS_MT_BLOB_ID:
+		return "ABS_MT_BLOB_ID"
+	case ABS_MT_TRACKING_ID:
+		return "ABS_MT_TRACKING_ID"
+	case ABS_MT_PRESSURE:
+		return "ABS_MT_PRESSURE"
+	case ABS_MT_DISTANCE:
+		return "ABS_MT_DISTANCE"
+	case ABS_MT_TOOL_X:
+		return "ABS_MT_TOOL_X"
+	case ABS_MT_TOOL_Y:
+		return "ABS_MT_TOOL_Y"
+	case ABS_MAX:
+		return "ABS_MAX"
+	default:
+		return "[?? Invalid AbsAxis value]"
+	}
+}
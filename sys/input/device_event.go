@@ -0,0 +1,107 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"fmt"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// DeviceEventType is the type of device event (added, removed)
+type DeviceEventType uint
+
+// DeviceEvent is emitted by the input manager when a device is
+// plugged in or removed whilst the software is running
+type DeviceEvent interface {
+	gopi.Event
+
+	// Type of event
+	Type() DeviceEventType
+
+	// Path to the device node
+	Path() string
+
+	// The device which has been opened or closed, or nil
+	Device() gopi.InputDevice
+}
+
+// Device event
+type device_event struct {
+	source gopi.Driver
+	event  DeviceEventType
+	path   string
+	device gopi.InputDevice
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DEVICE_EVENT_NONE DeviceEventType = iota
+	DEVICE_EVENT_ADDED
+	DEVICE_EVENT_REMOVED
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// DeviceEvent INTERFACE
+
+func NewDeviceEvent(source gopi.Driver, event_type DeviceEventType, path string, device gopi.InputDevice) DeviceEvent {
+	return &device_event{
+		source: source,
+		event:  event_type,
+		path:   path,
+		device: device,
+	}
+}
+
+func (this *device_event) Name() string {
+	return "DeviceEvent"
+}
+
+func (this *device_event) Source() gopi.Driver {
+	return this.source
+}
+
+func (this *device_event) Type() DeviceEventType {
+	return this.event
+}
+
+func (this *device_event) Path() string {
+	return this.path
+}
+
+func (this *device_event) Device() gopi.InputDevice {
+	return this.device
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *device_event) String() string {
+	return fmt.Sprintf("<sys.input.DeviceEvent>{ type=%v path=%v device=%v }", this.event, this.path, this.device)
+}
+
+func (t DeviceEventType) String() string {
+	switch t {
+	case DEVICE_EVENT_NONE:
+		return "DEVICE_EVENT_NONE"
+	case DEVICE_EVENT_ADDED:
+		return "DEVICE_EVENT_ADDED"
+	case DEVICE_EVENT_REMOVED:
+		return "DEVICE_EVENT_REMOVED"
+	default:
+		return "[?? Invalid DeviceEventType value]"
+	}
+}
@@ -35,6 +35,28 @@ type InputDevice struct {
 
 	// Whether to try and get exclusivity
 	Exclusive bool
+
+	// Scaling of absolute positions, and the screen size
+	// when positions are scaled
+	PositionMode PositionMode
+	Size         gopi.Size
+
+	// Folder containing calibration files, or empty if
+	// positions are not calibrated
+	CalibrationPath string
+
+	// Dead zone for joystick axes between 0.0 and 1.0, or zero
+	// to use the dead zone reported by the device
+	DeadZone float32
+
+	// Key repeat, or REPEAT_DEFAULT to keep the device settings
+	Repeat KeyRepeat
+
+	// Touchpad settings, or TOUCHPAD_RAW to report touches
+	Touchpad Touchpad
+
+	// Rules which set the alias and policy for the device
+	Rules []DeviceRule
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -59,46 +81,102 @@ type device struct {
 	// Unique Identifier
 	uniq string
 
+	// Alias from the device rules
+	alias string
+
 	// The type of device, or NONE if not known
 	device_type gopi.InputDeviceType
 
 	// The bus which the device is attached to, or NONE if not known
 	bus gopi.InputDeviceBus
 
+	// Whether the device is a consumer or system control device
+	controls ControlType
+
 	// Product and version
 	product uint16
 	vendor  uint16
 	version uint16
 
-	// The Device ID - combo of vendor/product
+	// The Device ID, which is stable and unique for each device
 	device_id uint32
 
-	// Capabilities
+	// Capabilities, and the codes and properties supported
 	capabilities []evType
-
-	// Positions for mice, joystick and touchscreens
-	position      gopi.Point
-	rel_position  gopi.Point
-	last_position gopi.Point
-
-	// Key presses and state
-	key_code   evKeyCode
-	key_action evKeyAction
-	key_state  gopi.KeyState
-	scan_code  uint32
-
-	// Multi-touch support
-	slot  uint32
-	slots []slot
+	caps         Capabilities
+
+	// Absolute axis information, and scaling of absolute positions
+	abs_info      map[evKeyCode]evAbsInfo
+	position_mode PositionMode
+	size          gopi.Size
+	calibration   Calibration
+
+	// Joystick axes and hats
+	axes map[evKeyCode]*evAxis
+	hats [EV_JOYSTICK_HATS]HatDirection
+
+	// Supported force feedback effects, or nil if force
+	// feedback is not supported
+	ff_bits     []byte
+	max_effects uint
+
+	// Key repeat settings, the settings to restore when the
+	// device is closed and the key repeated in software
+	repeat         KeyRepeat
+	repeat_restore KeyRepeat
+	soft_repeat    evRepeat
+
+	// Touchpad settings and state
+	touchpad evTouchpad
+
+	// Stylus state for tablets
+	stylus evStylus
+
+	// Switches which are on, as a bitmap of switch codes
+	switches uint32
+
+	// Battery of a wireless device
+	power evBattery
+
+	// Whether the device reports high resolution scroll
+	scroll_hi_res bool
+
+	// Positions for mice, joystick and touchscreens, and the
+	// absolute position before calibration and scaling
+	position     gopi.Point
+	raw_position gopi.Point
+
+	// Key state, pressed keys and scan code for the next key event
+	key_state gopi.KeyState
+	keys      evKeyBitmap
+	scan_code uint32
+
+	// Whether event timestamps are on the realtime clock, because
+	// the clock for the device could not be set
+	clock_realtime bool
+
+	// Raw events received since the last SYN_REPORT, and whether
+	// events have been dropped since then
+	frame   []evEvent
+	dropped bool
+
+	// Multi-touch support. For protocol A devices, contacts are
+	// assigned to slots with synthetic tracking IDs
+	slot          uint32
+	slots         []slot
+	mt_protocol_a bool
+	tracking_id   int32
 
 	// Publisher
 	event.Publisher
 }
 
-// Represents multi-touch slot information
+// Represents multi-touch slot information, where id is
+// the tracking ID of the contact when the slot is active
 type slot struct {
-	id       int16
+	id       int32
 	position gopi.Point
+	raw      gopi.Point
 	active   bool
 }
 
@@ -107,7 +185,7 @@ type slot struct {
 
 // Create new InputDevice object or return error
 func (config InputDevice) Open(log gopi.Logger) (gopi.Driver, error) {
-	log.Debug("<sys.input.InputDevice.Open>{ path=%v exclusive=%v }", config.Path, config.Exclusive)
+	log.Debug("<sys.input.InputDevice.Open>{ path=%v exclusive=%v position_mode=%v size=%v calibration_path=%v dead_zone=%v repeat=%v touchpad=%v }", config.Path, config.Exclusive, config.PositionMode, config.Size, config.CalibrationPath, config.DeadZone, config.Repeat, config.Touchpad)
 
 	// Check incoming configuration parameters
 	if config.FilePoll == nil {
@@ -116,12 +194,22 @@ func (config InputDevice) Open(log gopi.Logger) (gopi.Driver, error) {
 	if config.Path == "" {
 		return nil, gopi.ErrBadParameter
 	}
+	if config.PositionMode == POSITION_SCALED && (config.Size.W <= 0 || config.Size.H <= 0) {
+		return nil, gopi.ErrBadParameter
+	}
+	if config.DeadZone < 0 || config.DeadZone >= 1 {
+		return nil, gopi.ErrBadParameter
+	}
 
 	this := new(device)
 	this.log = log
 	this.path = config.Path
 	this.exclusive = config.Exclusive
 	this.filepoll = config.FilePoll
+	this.position_mode = config.PositionMode
+	this.size = config.Size
+	this.abs_info = make(map[evKeyCode]evAbsInfo)
+	this.calibration = IdentityCalibration
 
 	// Open the event stream for reading and writing
 	if handle, err := os.OpenFile(config.Path, os.O_RDWR, 0); err != nil {
@@ -138,6 +226,17 @@ func (config InputDevice) Open(log gopi.Logger) (gopi.Driver, error) {
 		this.name = name
 	}
 
+	// Read calibration for the device, if it exists
+	if config.CalibrationPath != "" {
+		path := CalibrationPath(config.CalibrationPath, this.name)
+		if calibration, err := ReadCalibration(path); err == nil {
+			this.calibration = calibration
+		} else if os.IsNotExist(err) == false {
+			this.handle.Close()
+			return nil, err
+		}
+	}
+
 	// Get phys & uniq of device. Ignore errors here,
 	// since it seems this isn't reported by touchscreen
 	this.phys, _ = evGetPhys(this.handle)
@@ -152,7 +251,6 @@ func (config InputDevice) Open(log gopi.Logger) (gopi.Driver, error) {
 		this.vendor = vendor
 		this.product = product
 		this.version = version
-		this.device_id = uint32(vendor)<<16 | uint32(product)
 	}
 
 	// Get capabilities
@@ -163,37 +261,111 @@ func (config InputDevice) Open(log gopi.Logger) (gopi.Driver, error) {
 		this.capabilities = capabilities
 	}
 
-	// Determine device type. We don't know if joysticks are
-	// currently supported, however, so will need to find a
-	// joystick tester later
-	switch {
-	case evSupportsEventType(this.capabilities, EV_KEY, EV_LED, EV_REP):
-		this.device_type = gopi.INPUT_TYPE_KEYBOARD
-	case evSupportsEventType(this.capabilities, EV_KEY, EV_REL):
-		this.device_type = gopi.INPUT_TYPE_MOUSE
-	case evSupportsEventType(this.capabilities, EV_KEY, EV_ABS, EV_MSC):
-		this.device_type = gopi.INPUT_TYPE_JOYSTICK
-	case evSupportsEventType(this.capabilities, EV_KEY, EV_ABS):
-		this.device_type = gopi.INPUT_TYPE_TOUCHSCREEN
+	// Get supported codes and properties, and determine the device type
+	if bits, props, err := this.evGetSupportedBits(); err != nil {
+		this.handle.Close()
+		return nil, err
+	} else {
+		this.caps = evDecodeCapabilities(bits, props)
+		this.ff_bits = bits[EV_FF]
+		sysfs := evGetSysfsPath(this.path)
+		this.device_type = evClassify(this.capabilities, bits, props, sysfs)
+		this.controls = evClassifyControls(this.device_type, bits[EV_KEY])
+		this.device_id = evDeviceID(this.name, this.vendor, this.product, this.uniq, this.phys, sysfs)
+	}
+
+	// Set multi-touch slot array to track slots, and determine if
+	// the device uses protocol A (anonymous contacts) for multi-touch
+	this.slot = 0
+	this.slots = make([]slot, INPUT_MAX_MULTITOUCH_SLOTS)
+	if evSupportsEventType(this.capabilities, EV_REL) {
+		if rel, err := evGetSupportedCodes(this.handle, EV_REL); err != nil {
+			this.handle.Close()
+			return nil, err
+		} else {
+			this.scroll_hi_res = evBitIsSet(rel, EV_CODE_WHEEL_HI_RES) || evBitIsSet(rel, EV_CODE_HWHEEL_HI_RES)
+		}
+	}
+	if evSupportsEventType(this.capabilities, EV_ABS) {
+		if err := this.evGetAbsInfo(); err != nil {
+			this.handle.Close()
+			return nil, err
+		}
+		_, has_mt_x := this.abs_info[EV_CODE_SLOT_X]
+		_, has_mt_slot := this.abs_info[EV_CODE_SLOT]
+		this.mt_protocol_a = has_mt_x && has_mt_slot == false
+	}
+
+	// Apply the alias and policy from the device rules, once the
+	// absolute axes are known so that rules can match on them
+	if err := this.evApplyRule(DeviceRuleFor(config.Rules, this)); err != nil {
+		this.handle.Close()
+		return nil, err
+	}
+	if this.device_type&gopi.INPUT_TYPE_JOYSTICK != 0 {
+		this.evInitJoystick(config.DeadZone)
+	}
+	if this.device_type&INPUT_TYPE_TOUCHPAD != 0 {
+		if err := this.SetTouchpad(config.Touchpad); err != nil {
+			this.handle.Close()
+			return nil, err
+		}
+	}
+	if evSupportsEventType(this.capabilities, EV_REP) {
+		if err := this.evGetKeyRepeat(); err != nil {
+			this.handle.Close()
+			return nil, err
+		}
+		if config.Repeat.Mode != REPEAT_DEFAULT {
+			if err := this.SetKeyRepeat(config.Repeat); err != nil {
+				this.evCloseOnError()
+				return nil, err
+			}
+		}
+	}
+	if this.ff_bits != nil {
+		if max_effects, err := evGetMaxEffects(this.handle); err != nil {
+			this.evCloseOnError()
+			return nil, err
+		} else {
+			this.max_effects = max_effects
+		}
+	}
+
+	// Synchronise the pressed keys, lock states and switches with the device
+	if err := this.evSyncKeyState(); err != nil {
+		this.evCloseOnError()
+		return nil, err
+	}
+	if err := this.evSyncSwitchState(); err != nil {
+		this.evCloseOnError()
+		return nil, err
+	}
+
+	// Timestamp events with the monotonic clock, which older
+	// kernels don't support
+	if err := evSetClockID(this.handle, CLOCK_MONOTONIC); err != nil {
+		this.log.Warn("<sys.input.InputDevice.Open> Unable to set clock: %v", err)
+		this.clock_realtime = true
 	}
 
 	// Start watching
 	if err := this.filepoll.Watch(this.handle, linux.FILEPOLL_MODE_READ, this.evReceive); err != nil {
-		this.handle.Close()
+		this.evCloseOnError()
 		return nil, err
 	}
 
 	// Obtain exclusive use of device
 	if this.exclusive {
 		if err := evSetGrabState(this.handle, true); err != nil {
-			this.handle.Close()
+			this.evCloseOnError()
 			return nil, err
 		}
 	}
 
-	// Set multi-touch slot array to track slots
-	this.slot = 0
-	this.slots = make([]slot, INPUT_MAX_MULTITOUCH_SLOTS)
+	// Read the battery of wireless devices, which is linked from
+	// the sysfs tree of the device
+	this.evOpenBattery(evGetSysfsPath(this.path))
 
 	// Success
 	return this, nil
@@ -216,6 +388,16 @@ func (this *device) Close() error {
 		this.log.Warn("Unwatch: %v", err)
 	}
 
+	// Stop polling the battery
+	this.evCloseBattery()
+
+	// Stop repeating keys and restore key repeat settings
+	if evSupportsEventType(this.capabilities, EV_REP) {
+		if err := this.evRestoreKeyRepeat(); err != nil {
+			this.log.Warn("<sys.input.InputDevice.Close> Error: %v", err)
+		}
+	}
+
 	// Close file handle
 	if err := this.handle.Close(); err != nil {
 		return err
@@ -237,10 +419,25 @@ func (this *device) Close() error {
 ////////////////////////////////////////////////////////////////////////////////
 // MATCH DEVICE
 
-// Return true if the device matches an alias, type and bus
+// Return true if the device matches an alias, type and bus. The alias
+// is a name, uniq or phys value, an alias from the device rules or a
+// matcher, as parsed by ParseMatcher
 func (this *device) Matches(alias string, flags gopi.InputDeviceType, bus gopi.InputDeviceBus) bool {
 	this.log.Debug2("<sys.input.InputDevice.Matches>{ alias=%v flags=%v bus=%v }", alias, flags, bus)
 
+	// check alias against name, uniq, phys or the alias from the
+	// device rules, or the terms of a matcher. If empty then return true
+	if matcher, err := ParseMatcher(alias); err != nil {
+		this.log.Warn("Matches: %v", err)
+		return false
+	} else {
+		return this.matches(matcher, flags, bus)
+	}
+}
+
+// matches returns true if the device matches a parsed matcher,
+// device type and bus
+func (this *device) matches(matcher *Matcher, flags gopi.InputDeviceType, bus gopi.InputDeviceBus) bool {
 	// Check the device type. We use NONE or ANY to match any device
 	// type. The input argument can be OR'd in order to match more than one
 	// device type.
@@ -263,21 +460,8 @@ func (this *device) Matches(alias string, flags gopi.InputDeviceType, bus gopi.I
 			return false
 		}
 	}
-	// check alias against name, uniq or phys
-	// if empty then return true
-	if alias == "" {
-		return true
-	}
-	if alias == this.uniq {
-		return true
-	}
-	if alias == this.phys {
-		return true
-	}
-	if alias == this.name {
-		return true
-	}
-	return false
+	// Check the terms of the matcher
+	return matcher.Matches(this)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -293,11 +477,46 @@ func (this *device) Type() gopi.InputDeviceType {
 	return this.device_type
 }
 
+// Return whether the device is a consumer or system control device
+func (this *device) Controls() ControlType {
+	return this.controls
+}
+
 // Return the bus we think the device is connected to
 func (this *device) Bus() gopi.InputDeviceBus {
 	return this.bus
 }
 
+// Return the physical path of the device
+func (this *device) Phys() string {
+	return this.phys
+}
+
+// Return the unique identifier of the device
+func (this *device) Uniq() string {
+	return this.uniq
+}
+
+// Return the alias for the device from the device rules
+func (this *device) Alias() string {
+	return this.alias
+}
+
+// Return the vendor ID of the device
+func (this *device) Vendor() uint16 {
+	return this.vendor
+}
+
+// Return the product ID of the device
+func (this *device) Product() uint16 {
+	return this.product
+}
+
+// Return the device ID
+func (this *device) DeviceID() uint32 {
+	return this.device_id
+}
+
 // Return absolute cursor position
 func (this *device) Position() gopi.Point {
 	return this.position
@@ -342,16 +561,165 @@ func (this *device) SetKeyState(flags gopi.KeyState, state bool) error {
 		if state {
 			this.key_state |= v
 		} else {
-			this.key_state ^= v
+			this.key_state &^= v
 		}
 	}
 	// Success
 	return nil
 }
 
+// PressedKeys returns the keys and buttons which are currently pressed
+func (this *device) PressedKeys() []gopi.KeyCode {
+	keys := make([]gopi.KeyCode, 0)
+	for code := evKeyCode(0); code <= EV_KEY_MAX; code++ {
+		if this.keys.isSet(code) {
+			keys = append(keys, gopi.KeyCode(code))
+		}
+	}
+	return keys
+}
+
+// AbsInfo returns the range and resolution of each supported absolute axis
+func (this *device) AbsInfo() map[AbsAxis]AbsInfo {
+	abs_info := make(map[AbsAxis]AbsInfo, len(this.abs_info))
+	for code, info := range this.abs_info {
+		abs_info[AbsAxis(code)] = AbsInfo(info)
+	}
+	return abs_info
+}
+
+// PositionMode returns the scaling of absolute positions
+func (this *device) PositionMode() (PositionMode, gopi.Size) {
+	return this.position_mode, this.size
+}
+
+// SetPositionMode sets the scaling of absolute positions. The size
+// is required when positions are scaled to a screen size
+func (this *device) SetPositionMode(mode PositionMode, size gopi.Size) error {
+	switch mode {
+	case POSITION_RAW, POSITION_NORMALISED:
+		this.position_mode = mode
+	case POSITION_SCALED:
+		if size.W <= 0 || size.H <= 0 {
+			return gopi.ErrBadParameter
+		}
+		this.position_mode = mode
+		this.size = size
+	default:
+		return gopi.ErrBadParameter
+	}
+	return nil
+}
+
+// Calibration returns the calibration applied to absolute positions
+func (this *device) Calibration() Calibration {
+	return this.calibration
+}
+
+// SetCalibration sets the calibration applied to absolute positions
+func (this *device) SetCalibration(calibration Calibration) {
+	this.calibration = calibration
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evCloseOnError restores the key repeat settings and closes the
+// device when it fails to open after changing the key repeat
+func (this *device) evCloseOnError() {
+	if evSupportsEventType(this.capabilities, EV_REP) {
+		if err := this.evRestoreKeyRepeat(); err != nil {
+			this.log.Warn("<sys.input.InputDevice.Open> Error: %v", err)
+		}
+	}
+	this.handle.Close()
+}
+
+// evGetAbsInfo reads the information for every supported absolute axis
+func (this *device) evGetAbsInfo() error {
+	abs, err := evGetSupportedCodes(this.handle, EV_ABS)
+	if err != nil {
+		return err
+	}
+	for code := evKeyCode(0); code <= EV_ABS_MAX; code++ {
+		if evBitIsSet(abs, code) == false {
+			continue
+		}
+		if info, err := evGetAbsInfo(this.handle, code); err != nil {
+			return err
+		} else {
+			this.abs_info[code] = info
+		}
+	}
+	return nil
+}
+
+// evApplyRule sets the alias, exclusivity, calibration and type of the
+// device from the device rules, or returns ErrIgnoredDevice if the device
+// should not be opened
+func (this *device) evApplyRule(rule DeviceRule) error {
+	if rule.Ignore {
+		return ErrIgnoredDevice
+	}
+	this.alias = rule.Alias
+	switch rule.Grab {
+	case GRAB_EXCLUSIVE:
+		this.exclusive = true
+	case GRAB_SHARED:
+		this.exclusive = false
+	}
+	if rule.Calibration != "" {
+		if calibration, err := ReadCalibration(rule.Calibration); err != nil {
+			return err
+		} else {
+			this.calibration = calibration
+		}
+	}
+	if rule.Type != gopi.INPUT_TYPE_NONE {
+		// Controls depend on the type of device, so are determined
+		// again from the supported keys
+		var keys evKeyBitmap
+		for _, key_code := range this.caps.Keys {
+			keys.set(evKeyCode(key_code), true)
+		}
+		this.device_type = rule.Type
+		this.controls = evClassifyControls(this.device_type, keys[:])
+	}
+	return nil
+}
+
+// evSyncKeyState reads the pressed keys and LED states from the device
+// and sets the modifier and lock key states from them
+func (this *device) evSyncKeyState() error {
+	if evSupportsEventType(this.capabilities, EV_KEY) {
+		if keys, err := evGetKeyState(this.handle); err != nil {
+			return err
+		} else {
+			this.evSetPressedKeys(keys)
+		}
+	}
+	if evSupportsEventType(this.capabilities, EV_LED) {
+		if leds, err := evGetLEDState(this.handle); err != nil {
+			return err
+		} else {
+			this.evSetLockState(leds)
+		}
+	}
+	return nil
+}
+
+// evSetPressedKeys sets the pressed keys from a bitmap of keys, and
+// the modifier key states from the pressed keys
+func (this *device) evSetPressedKeys(keys []byte) {
+	copy(this.keys[:], keys)
+	for _, key_code := range this.PressedKeys() {
+		this.key_state |= evModifierKeyState(key_code)
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
 func (this *device) String() string {
-	return fmt.Sprintf("<sys.input.InputDevice>{ name=\"%s\" phys=\"%v\" uniq=\"%v\" type=%v bus=%v position=%v product=0x%04X vendor=0x%04X version=0x%04X capabilities=%v key_state=%v exclusive=%v fd=%v path=%v }", this.name, this.phys, this.uniq, this.device_type, this.bus, this.position, this.product, this.vendor, this.version, this.capabilities, this.key_state, this.exclusive, this.handle.Fd(), this.path)
+	return fmt.Sprintf("<sys.input.InputDevice>{ id=0x%08X name=\"%s\" alias=\"%v\" phys=\"%v\" uniq=\"%v\" type=%v bus=%v position=%v product=0x%04X vendor=0x%04X version=0x%04X capabilities=%v key_state=%v exclusive=%v fd=%v path=%v }", this.device_id, this.name, this.alias, this.phys, this.uniq, DeviceTypeString(this.device_type), this.bus, this.position, this.product, this.vendor, this.version, this.capabilities, this.key_state, this.exclusive, this.handle.Fd(), this.path)
 }
@@ -0,0 +1,182 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"fmt"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACES
+
+// FeedbackDevice is implemented by input devices which support force
+// feedback, such as gamepads with rumble motors and haptic touch panels
+type FeedbackDevice interface {
+	gopi.InputDevice
+
+	// Return the number of effects which can be uploaded at once
+	MaxEffects() uint
+
+	// Return true if an effect type is supported by the device
+	SupportsEffect(effect_type EffectType) bool
+
+	// Upload an effect and return the identifier for the effect
+	UploadEffect(effect Effect) (EffectID, error)
+
+	// Update an uploaded effect
+	UpdateEffect(id EffectID, effect Effect) error
+
+	// Remove an uploaded effect
+	EraseEffect(id EffectID) error
+
+	// Play an uploaded effect a number of times
+	PlayEffect(id EffectID, count uint) error
+
+	// Stop an effect from playing
+	StopEffect(id EffectID) error
+
+	// Set the strength of all effects, between 0.0 and 1.0
+	SetGain(gain float32) error
+
+	// Set the strength of autocentering, between 0.0 (off) and 1.0
+	SetAutocenter(autocenter float32) error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// EffectID identifies an effect uploaded to a device
+type EffectID int16
+
+// EffectType is the type of force feedback effect
+type EffectType uint16
+
+// EffectWaveform is the waveform of a periodic effect
+type EffectWaveform uint16
+
+// EffectStatus is the status of an effect
+type EffectStatus uint
+
+// Effect describes a force feedback effect. Which fields are used depends
+// on the type of effect: Strong and Weak for rumble effects, Level for
+// constant effects and Waveform, Period, Magnitude, Offset and Phase for
+// periodic effects. Length of zero plays the effect until it is stopped
+type Effect struct {
+	Type      EffectType
+	Direction uint16 // 0x0000 is down, 0x4000 is left, 0x8000 is up and 0xC000 is right
+	Length    time.Duration
+	Delay     time.Duration
+
+	// Rumble effects
+	Strong uint16 // Magnitude of the heavy motor
+	Weak   uint16 // Magnitude of the light motor
+
+	// Constant effects
+	Level int16
+
+	// Periodic effects
+	Waveform  EffectWaveform
+	Period    time.Duration
+	Magnitude int16
+	Offset    int16
+	Phase     uint16
+
+	// Envelope for constant and periodic effects
+	AttackLength time.Duration
+	AttackLevel  uint16
+	FadeLength   time.Duration
+	FadeLevel    uint16
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Effect types
+const (
+	EFFECT_RUMBLE   EffectType = 0x0050
+	EFFECT_PERIODIC EffectType = 0x0051
+	EFFECT_CONSTANT EffectType = 0x0052
+)
+
+// Periodic effect waveforms
+const (
+	EFFECT_WAVEFORM_SQUARE   EffectWaveform = 0x0058
+	EFFECT_WAVEFORM_TRIANGLE EffectWaveform = 0x0059
+	EFFECT_WAVEFORM_SINE     EffectWaveform = 0x005A
+	EFFECT_WAVEFORM_SAW_UP   EffectWaveform = 0x005B
+	EFFECT_WAVEFORM_SAW_DOWN EffectWaveform = 0x005C
+)
+
+// Effect status
+const (
+	EFFECT_STATUS_STOPPED EffectStatus = iota
+	EFFECT_STATUS_PLAYING
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (e Effect) String() string {
+	switch e.Type {
+	case EFFECT_RUMBLE:
+		return fmt.Sprintf("<input.Effect>{ type=%v strong=%v weak=%v length=%v delay=%v }", e.Type, e.Strong, e.Weak, e.Length, e.Delay)
+	case EFFECT_CONSTANT:
+		return fmt.Sprintf("<input.Effect>{ type=%v level=%v direction=0x%04X length=%v delay=%v }", e.Type, e.Level, e.Direction, e.Length, e.Delay)
+	case EFFECT_PERIODIC:
+		return fmt.Sprintf("<input.Effect>{ type=%v waveform=%v period=%v magnitude=%v offset=%v phase=%v direction=0x%04X length=%v delay=%v }", e.Type, e.Waveform, e.Period, e.Magnitude, e.Offset, e.Phase, e.Direction, e.Length, e.Delay)
+	default:
+		return fmt.Sprintf("<input.Effect>{ type=%v }", e.Type)
+	}
+}
+
+func (t EffectType) String() string {
+	switch t {
+	case EFFECT_RUMBLE:
+		return "EFFECT_RUMBLE"
+	case EFFECT_PERIODIC:
+		return "EFFECT_PERIODIC"
+	case EFFECT_CONSTANT:
+		return "EFFECT_CONSTANT"
+	default:
+		return "[?? Invalid EffectType value]"
+	}
+}
+
+func (w EffectWaveform) String() string {
+	switch w {
+	case EFFECT_WAVEFORM_SQUARE:
+		return "EFFECT_WAVEFORM_SQUARE"
+	case EFFECT_WAVEFORM_TRIANGLE:
+		return "EFFECT_WAVEFORM_TRIANGLE"
+	case EFFECT_WAVEFORM_SINE:
+		return "EFFECT_WAVEFORM_SINE"
+	case EFFECT_WAVEFORM_SAW_UP:
+		return "EFFECT_WAVEFORM_SAW_UP"
+	case EFFECT_WAVEFORM_SAW_DOWN:
+		return "EFFECT_WAVEFORM_SAW_DOWN"
+	default:
+		return "[?? Invalid EffectWaveform value]"
+	}
+}
+
+func (s EffectStatus) String() string {
+	switch s {
+	case EFFECT_STATUS_STOPPED:
+		return "EFFECT_STATUS_STOPPED"
+	case EFFECT_STATUS_PLAYING:
+		return "EFFECT_STATUS_PLAYING"
+	default:
+		return "[?? Invalid EffectStatus value]"
+	}
+}
@@ -0,0 +1,236 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Represents the power supply of a wireless device, which is polled
+// for changes in the battery level and charging state until done is
+// closed
+type evBattery struct {
+	sync.Mutex
+	sync.WaitGroup
+	path    string
+	battery Battery
+	done    chan struct{}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Interval between reading the battery of a device
+	EV_BATTERY_POLL_INTERVAL = 30 * time.Second
+
+	// Number of parents of the input device in the sysfs tree which
+	// are searched for a power supply
+	EV_BATTERY_SEARCH_DEPTH = 4
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// BatteryDevice INTERFACE
+
+// Battery returns the battery level and charging state of the device,
+// which has status BATTERY_NONE when the device has no battery
+func (this *device) Battery() Battery {
+	this.power.Lock()
+	defer this.power.Unlock()
+	if this.power.path == "" {
+		return Battery{Status: BATTERY_NONE, Level: -1}
+	}
+	return this.power.battery
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evOpenBattery finds the power supply for the device from the sysfs
+// path of the device, reads the battery and starts polling the battery
+// for changes. Devices without a power supply are not polled
+func (this *device) evOpenBattery(sysfs string) {
+	if path := evFindBattery(sysfs); path == "" {
+		return
+	} else if battery, err := evReadBattery(path); err != nil {
+		this.log.Warn("<sys.input.InputDevice.Open> Battery: %v", err)
+	} else {
+		this.power.path = path
+		this.power.battery = battery
+		this.power.done = make(chan struct{})
+		this.power.Add(1)
+		go this.evPollBattery(this.power.done)
+	}
+}
+
+// evCloseBattery stops polling the battery, and waits for polling
+// to end so that no further events are emitted
+func (this *device) evCloseBattery() {
+	this.power.Lock()
+	if this.power.done != nil {
+		close(this.power.done)
+		this.power.done = nil
+	}
+	this.power.Unlock()
+	this.power.Wait()
+}
+
+// evPollBattery reads the battery and emits an event when the level or
+// charging state changes, until the done channel is closed
+func (this *device) evPollBattery(done <-chan struct{}) {
+	defer this.power.Done()
+	ticker := time.NewTicker(EV_BATTERY_POLL_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if evt := this.evUpdateBattery(); evt != nil {
+				this.Emit(evt)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// evUpdateBattery reads the battery and returns an INPUT_EVENT_BATTERY
+// event when the level, charging state or low battery state has
+// changed, or nil otherwise
+func (this *device) evUpdateBattery() *input_event {
+	this.power.Lock()
+	defer this.power.Unlock()
+	if this.power.path == "" {
+		return nil
+	} else if battery, err := evReadBattery(this.power.path); err != nil {
+		this.log.Debug("evUpdateBattery: %v", err)
+		return nil
+	} else if battery == this.power.battery {
+		return nil
+	} else {
+		// The event doesn't include the key state, which is written when
+		// frames are decoded rather than on the polling goroutine
+		this.power.battery = battery
+		return &input_event{
+			source:    this,
+			device:    this.device_type,
+			device_id: this.device_id,
+			event:     INPUT_EVENT_BATTERY,
+			timestamp: Timestamp(),
+			battery:   battery,
+		}
+	}
+}
+
+// evFindBattery returns the path of the power supply for an input
+// device, from the sysfs path of the device. The power supply of a
+// wireless device is linked from its parent (for example, the HID
+// device) rather than the input device itself. Power supplies which
+// aren't linked from the parent are only used when their scope is
+// Device, since system batteries (for example, ACPI batteries) don't
+// have a scope. Returns an empty string if the device has no battery
+func evFindBattery(sysfs string) string {
+	if sysfs == "" {
+		return ""
+	}
+	path := filepath.Clean(sysfs)
+	parent := evParentDevice(path)
+	for depth := 0; depth <= EV_BATTERY_SEARCH_DEPTH; depth++ {
+		if supplies, err := filepath.Glob(filepath.Join(path, "power_supply", "*")); err == nil {
+			for _, supply := range supplies {
+				if supply_type := evReadSysfsValue(supply, "type"); supply_type != "" && strings.EqualFold(supply_type, "Battery") == false {
+					continue
+				} else if scope := evReadSysfsValue(supply, "scope"); strings.EqualFold(scope, "Device") {
+					return supply
+				} else if scope == "" && path == parent {
+					return supply
+				}
+			}
+		}
+		if parent := filepath.Dir(path); parent == path {
+			break
+		} else {
+			path = parent
+		}
+	}
+	return ""
+}
+
+// evParentDevice returns the sysfs path of the device which an input
+// device belongs to, skipping the input class folder
+func evParentDevice(sysfs string) string {
+	parent := filepath.Dir(sysfs)
+	if filepath.Base(parent) == "input" {
+		parent = filepath.Dir(parent)
+	}
+	return parent
+}
+
+// evReadBattery reads the level and charging state from the
+// power supply of a device
+func evReadBattery(path string) (Battery, error) {
+	battery := Battery{Status: BATTERY_UNKNOWN, Level: -1}
+
+	// The present attribute is zero when the device is disconnected
+	status, err := ioutil.ReadFile(filepath.Join(path, "status"))
+	if err != nil {
+		return battery, err
+	} else if evReadSysfsValue(path, "present") == "0" {
+		return Battery{Status: BATTERY_NONE, Level: -1}, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(status))) {
+	case "charging":
+		battery.Status = BATTERY_CHARGING
+	case "discharging":
+		battery.Status = BATTERY_DISCHARGING
+	case "not charging":
+		battery.Status = BATTERY_NOT_CHARGING
+	case "full":
+		battery.Status = BATTERY_FULL
+	}
+
+	// The level is read from the capacity as a percentage. Some
+	// devices only report the capacity level
+	if capacity, err := strconv.ParseUint(evReadSysfsValue(path, "capacity"), 10, 32); err == nil && capacity <= 100 {
+		battery.Level = float32(capacity) / 100
+		battery.Low = battery.Level <= BATTERY_LOW_LEVEL
+	}
+	switch strings.ToLower(evReadSysfsValue(path, "capacity_level")) {
+	case "critical", "low":
+		battery.Low = true
+	case "normal", "high", "full":
+		battery.Low = false
+	}
+
+	// A battery which is charging is not low
+	if battery.Status == BATTERY_CHARGING || battery.Status == BATTERY_FULL {
+		battery.Low = false
+	}
+	return battery, nil
+}
+
+// evReadSysfsValue returns the value of a sysfs attribute with
+// whitespace trimmed, or an empty string if it can't be read
+func evReadSysfsValue(path, name string) string {
+	if value, err := ioutil.ReadFile(filepath.Join(path, name)); err != nil {
+		return ""
+	} else {
+		return strings.TrimSpace(string(value))
+	}
+}
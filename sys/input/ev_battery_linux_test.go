@@ -0,0 +1,153 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST BATTERY
+
+const (
+	testSysfsKeyboard = "testdata/sysfs/devices/platform/i8042/serio0/input/input3"
+	testSysfsWireless = "testdata/sysfs/devices/virtual/misc/uhid/0005_046D_B342.0004/input/input12"
+	testSysfsButton   = "testdata/sysfs/devices/LNXSYSTM:00/LNXPWRBN:00/input/input1"
+)
+
+func TestBattery_000(t *testing.T) {
+	// The power supply is linked from the parent of the input device
+	path := evFindBattery(testSysfsWireless)
+	if filepath.Base(path) != "hid-e417d8aabbcc-battery" {
+		t.Fatalf("Unexpected power supply: %v", path)
+	}
+	if battery, err := evReadBattery(path); err != nil {
+		t.Error(err)
+	} else if battery.Status != BATTERY_DISCHARGING || battery.Level != 0.55 || battery.Low {
+		t.Errorf("Unexpected battery: %v", battery)
+	}
+
+	// Wired devices have no battery
+	if path := evFindBattery(testSysfsKeyboard); path != "" {
+		t.Errorf("Unexpected power supply: %v", path)
+	}
+	keyboard := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	keyboard.evOpenBattery(testSysfsKeyboard)
+	if battery := keyboard.Battery(); battery.Status != BATTERY_NONE {
+		t.Errorf("Unexpected battery: %v", battery)
+	}
+
+	// The system battery, which has no scope, is not the battery of
+	// a device it isn't linked from
+	if path := evFindBattery(testSysfsButton); path != "" {
+		t.Errorf("Unexpected power supply: %v", path)
+	}
+}
+
+func TestBattery_001(t *testing.T) {
+	folder, err := ioutil.TempDir("", "power_supply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	write := func(name, value string) {
+		if err := ioutil.WriteFile(filepath.Join(folder, name), []byte(value+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("status", "Discharging")
+	write("capacity", "20")
+
+	mouse := testDevice(t, gopi.INPUT_TYPE_MOUSE)
+	mouse.power.path = folder
+	if evt := mouse.evUpdateBattery(); evt == nil || evt.EventType() != INPUT_EVENT_BATTERY || evt.Battery().Level != 0.2 {
+		t.Errorf("Unexpected event: %v", evt)
+	}
+
+	// No event is emitted when the battery doesn't change
+	if evt := mouse.evUpdateBattery(); evt != nil {
+		t.Errorf("Unexpected event: %v", evt)
+	}
+
+	// The battery is low at or below the low level, or when the
+	// device reports a low capacity level
+	write("capacity", "10")
+	if evt := mouse.evUpdateBattery(); evt == nil || evt.Battery().Low == false {
+		t.Errorf("Expected low battery event, got %v", evt)
+	}
+	write("capacity", "")
+	write("capacity_level", "Critical")
+	if evt := mouse.evUpdateBattery(); evt == nil || evt.Battery().Low == false || evt.Battery().Level >= 0 {
+		t.Errorf("Expected low battery event, got %v", evt)
+	}
+
+	// A charging battery is not low
+	write("status", "Charging")
+	if evt := mouse.evUpdateBattery(); evt == nil || evt.Battery().Status != BATTERY_CHARGING || evt.Battery().Low {
+		t.Errorf("Expected charging event, got %v", evt)
+	} else if mouse.Battery() != evt.Battery() {
+		t.Errorf("Expected %v, got %v", evt.Battery(), mouse.Battery())
+	}
+}
+
+func TestBattery_002(t *testing.T) {
+	// Polling has ended when the battery is closed
+	mouse := testDevice(t, gopi.INPUT_TYPE_MOUSE)
+	mouse.evOpenBattery(testSysfsWireless)
+	if mouse.power.done == nil {
+		t.Fatal("Expected battery to be polled")
+	}
+	mouse.evCloseBattery()
+	if mouse.power.done != nil {
+		t.Error("Expected battery polling to end")
+	}
+	mouse.evCloseBattery()
+}
+
+func TestBattery_003(t *testing.T) {
+	// The battery is polled while frames are decoded, which
+	// is checked with go test -race
+	folder, err := ioutil.TempDir("", "power_supply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	if err := ioutil.WriteFile(filepath.Join(folder, "status"), []byte("Discharging\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	keyboard := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	keyboard.power.path = folder
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for capacity := 1; capacity <= 50; capacity++ {
+			if err := ioutil.WriteFile(filepath.Join(folder, "capacity"), []byte(fmt.Sprintln(capacity)), 0644); err != nil {
+				t.Error(err)
+				return
+			} else if evt := keyboard.evUpdateBattery(); evt == nil || evt.Battery().Level != float32(capacity)/100 {
+				t.Errorf("Unexpected event: %v", evt)
+			}
+		}
+	}()
+	for i := uint32(0); i < 50; i++ {
+		testFrame(keyboard, i, []evEvent{testKey(evKeyCode(gopi.KEYCODE_LEFTSHIFT), i%2)})
+	}
+	<-done
+}
@@ -0,0 +1,109 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CapabilitiesDevice INTERFACE
+
+// Capabilities returns the codes and properties supported by the device,
+// with the current absolute axis information
+func (this *device) Capabilities() Capabilities {
+	return Capabilities{
+		Keys:       append([]gopi.KeyCode{}, this.caps.Keys...),
+		RelAxes:    append([]RelAxis{}, this.caps.RelAxes...),
+		AbsAxes:    this.AbsInfo(),
+		Switches:   append([]SwitchCode{}, this.caps.Switches...),
+		LEDs:       append([]LEDCode{}, this.caps.LEDs...),
+		Effects:    append([]EffectType{}, this.caps.Effects...),
+		Waveforms:  append([]EffectWaveform{}, this.caps.Waveforms...),
+		Properties: append([]InputProperty{}, this.caps.Properties...),
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evGetSupportedBits returns the bitmaps of supported codes for each
+// event type, and the bitmap of device properties. Properties are not
+// reported by older kernels, so errors reading them are ignored
+func (this *device) evGetSupportedBits() (map[evType][]byte, []byte, error) {
+	bits := make(map[evType][]byte, len(this.capabilities))
+	for _, ev := range []evType{EV_KEY, EV_REL, EV_ABS, EV_SW, EV_LED, EV_FF} {
+		if evSupportsEventType(this.capabilities, ev) == false {
+			continue
+		}
+		if codes, err := evGetSupportedCodes(this.handle, ev); err != nil {
+			return nil, nil, err
+		} else {
+			bits[ev] = codes
+		}
+	}
+	props, _ := evGetProperties(this.handle)
+	return bits, props, nil
+}
+
+// evDecodeCapabilities returns capabilities from the bitmaps of
+// supported codes for each event type and the bitmap of properties
+func evDecodeCapabilities(bits map[evType][]byte, props []byte) Capabilities {
+	caps := Capabilities{
+		Keys:       make([]gopi.KeyCode, 0),
+		RelAxes:    make([]RelAxis, 0),
+		Switches:   make([]SwitchCode, 0),
+		LEDs:       make([]LEDCode, 0),
+		Effects:    make([]EffectType, 0),
+		Waveforms:  make([]EffectWaveform, 0),
+		Properties: make([]InputProperty, 0),
+	}
+	for _, code := range evBitmapCodes(bits[EV_KEY], EV_KEY_MAX) {
+		caps.Keys = append(caps.Keys, gopi.KeyCode(code))
+	}
+	for _, code := range evBitmapCodes(bits[EV_REL], EV_REL_MAX) {
+		caps.RelAxes = append(caps.RelAxes, RelAxis(code))
+	}
+	for _, code := range evBitmapCodes(bits[EV_SW], evKeyCode(SW_MAX)) {
+		caps.Switches = append(caps.Switches, SwitchCode(code))
+	}
+	for _, code := range evBitmapCodes(bits[EV_LED], evKeyCode(LED_MAX)) {
+		caps.LEDs = append(caps.LEDs, LEDCode(code))
+	}
+	for _, effect_type := range []EffectType{EFFECT_RUMBLE, EFFECT_PERIODIC, EFFECT_CONSTANT} {
+		if evBitIsSet(bits[EV_FF], evKeyCode(effect_type)) {
+			caps.Effects = append(caps.Effects, effect_type)
+		}
+	}
+	for waveform := EFFECT_WAVEFORM_SQUARE; waveform <= EFFECT_WAVEFORM_SAW_DOWN; waveform++ {
+		if evBitIsSet(bits[EV_FF], evKeyCode(waveform)) {
+			caps.Waveforms = append(caps.Waveforms, waveform)
+		}
+	}
+	for _, code := range evBitmapCodes(props, evKeyCode(INPUT_PROP_MAX)) {
+		caps.Properties = append(caps.Properties, InputProperty(code))
+	}
+	return caps
+}
+
+// evBitmapCodes returns the codes which are set in a bitmap,
+// up to and including a maximum code
+func evBitmapCodes(bits []byte, max evKeyCode) []evKeyCode {
+	codes := make([]evKeyCode, 0)
+	for code := evKeyCode(0); code <= max; code++ {
+		if evBitIsSet(bits, code) {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
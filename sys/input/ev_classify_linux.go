@@ -0,0 +1,208 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"path/filepath"
+	"strings"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Key and button codes used to classify devices
+const (
+	EV_CODE_BTN_MOUSE         evKeyCode = 0x0110 // First mouse button
+	EV_CODE_BTN_JOYSTICK      evKeyCode = 0x0120 // First joystick button
+	EV_CODE_BTN_TOOL_PEN      evKeyCode = 0x0140 // First digitizer button
+	EV_CODE_BTN_TOOL_FINGER   evKeyCode = 0x0145
+	EV_CODE_BTN_TOUCH         evKeyCode = 0x014A
+	EV_CODE_BTN_STYLUS        evKeyCode = 0x014B
+	EV_CODE_BTN_DPAD_UP       evKeyCode = 0x0220
+	EV_CODE_BTN_DPAD_RIGHT    evKeyCode = 0x0223
+	EV_CODE_BTN_TRIGGER_HAPPY evKeyCode = 0x02C0 // First extra joystick button
+	EV_CODE_BTN_TRIGGER_LAST  evKeyCode = 0x02E7 // Last extra joystick button
+)
+
+// Absolute axes used to classify devices
+const (
+	EV_CODE_RX       evKeyCode = 0x0003 // First joystick-only axis
+	EV_CODE_PRESSURE evKeyCode = 0x0018 // First axis after the joystick axes
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evClassify returns the type of a device from the supported event types,
+// the bitmaps of supported codes for each event type, the properties and
+// the sysfs path of the device. It follows the classification used by
+// udev (input_id), and a device may have more than one type
+func evClassify(types []evType, bits map[evType][]byte, props []byte, sysfs string) gopi.InputDeviceType {
+	device_type := gopi.INPUT_TYPE_NONE
+
+	// Remote controls registered through rc-core report keys,
+	// but are not keyboards
+	if evIsRemote(sysfs) {
+		return gopi.INPUT_TYPE_REMOTE
+	}
+	if evSupportsEventType(types, EV_ABS) || evSupportsEventType(types, EV_REL) || evSupportsEventType(types, EV_KEY) {
+		device_type |= evClassifyPointer(bits[EV_KEY], bits[EV_REL], bits[EV_ABS], props)
+	}
+	if evSupportsEventType(types, EV_KEY) && evIsKeyboard(bits[EV_KEY]) {
+		device_type |= gopi.INPUT_TYPE_KEYBOARD
+	}
+	if evSupportsEventType(types, EV_SW) {
+		device_type |= INPUT_TYPE_SWITCH
+	}
+
+	// Consumer and system control devices report media, volume
+	// and power keys, and are keyboards without the full set of keys
+	if device_type&^INPUT_TYPE_SWITCH == gopi.INPUT_TYPE_NONE && evSupportsEventType(types, EV_KEY) {
+		if evClassifyControls(gopi.INPUT_TYPE_KEYBOARD, bits[EV_KEY]) != CONTROL_NONE {
+			device_type |= gopi.INPUT_TYPE_KEYBOARD
+		}
+	}
+	return device_type
+}
+
+// evClassifyControls returns whether a device is a consumer control or
+// system control device, from the type of device and the bitmap of
+// supported keys. Full keyboards, pointing devices and remotes are not
+// control devices, even when they have media keys
+func evClassifyControls(device_type gopi.InputDeviceType, key []byte) ControlType {
+	if device_type&^INPUT_TYPE_SWITCH != gopi.INPUT_TYPE_KEYBOARD || evIsKeyboard(key) {
+		return CONTROL_NONE
+	}
+	controls := CONTROL_NONE
+	for key_code, media_key := range media_keys {
+		if evBitIsSet(key, evKeyCode(key_code)) {
+			controls |= media_key.Control()
+		}
+	}
+	return controls
+}
+
+// evClassifyPointer returns the type of pointing device, which is a
+// mouse, touchpad, touchscreen, tablet or joystick, or NONE if the device
+// is not a pointing device. Accelerometers are not pointing devices
+func evClassifyPointer(key, rel, abs, props []byte) gopi.InputDeviceType {
+	has_keys := evAnyBitIsSet(key, 0, EV_KEY_MAX)
+	has_abs := evBitIsSet(abs, EV_CODE_X) && evBitIsSet(abs, EV_CODE_Y)
+	has_rel := evBitIsSet(rel, EV_CODE_X) && evBitIsSet(rel, EV_CODE_Y)
+	has_mt := evBitIsSet(abs, EV_CODE_SLOT_X) && evBitIsSet(abs, EV_CODE_SLOT_Y)
+	is_direct := evBitIsSet(props, evKeyCode(INPUT_PROP_DIRECT))
+
+	// Accelerometers
+	if evBitIsSet(props, evKeyCode(INPUT_PROP_ACCELEROMETER)) {
+		return gopi.INPUT_TYPE_NONE
+	}
+	if has_keys == false && has_abs && evBitIsSet(abs, EV_CODE_Z) {
+		return gopi.INPUT_TYPE_NONE
+	}
+
+	// Devices which claim every absolute axis don't have multi-touch
+	if has_mt && evBitIsSet(abs, EV_CODE_SLOT) && evBitIsSet(abs, EV_CODE_SLOT-1) {
+		has_mt = false
+	}
+
+	// Buttons
+	has_pen := evBitIsSet(key, EV_CODE_BTN_TOOL_PEN) || evBitIsSet(key, EV_CODE_BTN_STYLUS)
+	has_finger := evBitIsSet(key, EV_CODE_BTN_TOOL_FINGER) && evBitIsSet(key, EV_CODE_BTN_TOOL_PEN) == false
+	has_touch := evBitIsSet(key, EV_CODE_BTN_TOUCH)
+	has_mouse_button := evAnyBitIsSet(key, EV_CODE_BTN_MOUSE, EV_CODE_BTN_JOYSTICK-1)
+	has_joystick := evAnyBitIsSet(key, EV_CODE_BTN_JOYSTICK, EV_CODE_BTN_TOOL_PEN-1) ||
+		evAnyBitIsSet(key, EV_CODE_BTN_TRIGGER_HAPPY, EV_CODE_BTN_TRIGGER_LAST) ||
+		evAnyBitIsSet(key, EV_CODE_BTN_DPAD_UP, EV_CODE_BTN_DPAD_RIGHT) ||
+		evAnyBitIsSet(abs, EV_CODE_RX, EV_CODE_PRESSURE-1)
+
+	// Determine the type of pointing device
+	device_type := gopi.INPUT_TYPE_NONE
+	switch {
+	case has_abs && has_pen:
+		device_type = INPUT_TYPE_TABLET
+	case has_abs && has_finger && is_direct == false:
+		device_type = INPUT_TYPE_TOUCHPAD
+	case has_abs && has_mouse_button:
+		// Virtual machine mice have absolute axes but no touch
+		device_type = gopi.INPUT_TYPE_MOUSE
+	case has_abs && (has_touch || is_direct):
+		device_type = gopi.INPUT_TYPE_TOUCHSCREEN
+	case has_joystick:
+		device_type = gopi.INPUT_TYPE_JOYSTICK
+	}
+	if has_mt {
+		switch {
+		case has_pen:
+			device_type = INPUT_TYPE_TABLET
+		case has_finger && is_direct == false:
+			device_type = INPUT_TYPE_TOUCHPAD
+		case has_touch || is_direct:
+			device_type = gopi.INPUT_TYPE_TOUCHSCREEN
+		}
+	}
+
+	// Mice have buttons and relative axes, and pointing sticks are mice
+	if device_type&(INPUT_TYPE_TABLET|INPUT_TYPE_TOUCHPAD|gopi.INPUT_TYPE_JOYSTICK) == 0 && has_mouse_button && (has_rel || has_abs == false) {
+		device_type |= gopi.INPUT_TYPE_MOUSE
+	}
+	if evBitIsSet(props, evKeyCode(INPUT_PROP_POINTING_STICK)) {
+		device_type |= gopi.INPUT_TYPE_MOUSE
+	}
+	return device_type
+}
+
+// evIsKeyboard returns true if the device has the keys from ESC
+// through the numbers to D, which a full keyboard has
+func evIsKeyboard(key []byte) bool {
+	return evAllBitsAreSet(key, 1, 31)
+}
+
+// evIsRemote returns true if the sysfs path of a device
+// is an rc-core remote control receiver
+func evIsRemote(sysfs string) bool {
+	return strings.Contains(sysfs, "/rc/rc")
+}
+
+// evGetSysfsPath returns the sysfs path for an event device,
+// or an empty string if the path could not be determined
+func evGetSysfsPath(path string) string {
+	if sysfs, err := filepath.EvalSymlinks(filepath.Join("/sys/class/input", filepath.Base(path), "device")); err != nil {
+		return ""
+	} else {
+		return sysfs
+	}
+}
+
+// evAnyBitIsSet returns true if any bit between first and last
+// (inclusive) is set in a bitmap
+func evAnyBitIsSet(bits []byte, first, last evKeyCode) bool {
+	for code := first; code <= last; code++ {
+		if evBitIsSet(bits, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// evAllBitsAreSet returns true if every bit between first and last
+// (inclusive) is set in a bitmap
+func evAllBitsAreSet(bits []byte, first, last evKeyCode) bool {
+	for code := first; code <= last; code++ {
+		if evBitIsSet(bits, code) == false {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,230 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST FIXTURES
+
+// testFixture is a device read from testdata/devices, which is
+// in the format of /proc/bus/input/devices
+type testFixture struct {
+	name            string
+	phys, uniq      string
+	sysfs           string
+	bus             gopi.InputDeviceBus
+	vendor, product uint16
+	types []evType
+	bits  map[evType][]byte
+	props []byte
+}
+
+var (
+	testBitmapTypes = map[string]evType{
+		"KEY": EV_KEY,
+		"REL": EV_REL,
+		"ABS": EV_ABS,
+		"MSC": EV_MSC,
+		"SW":  EV_SW,
+		"LED": EV_LED,
+		"FF":  EV_FF,
+	}
+)
+
+func testReadFixtures(t *testing.T) map[string]*testFixture {
+	file, err := os.Open("testdata/devices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	fixtures := make(map[string]*testFixture)
+	var fixture *testFixture
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "I: "):
+			fixture = &testFixture{bits: make(map[evType][]byte)}
+			for _, field := range strings.Fields(strings.TrimPrefix(line, "I: ")) {
+				kv := strings.SplitN(field, "=", 2)
+				if value, err := strconv.ParseUint(kv[1], 16, 16); err != nil {
+					t.Fatal(err)
+				} else if kv[0] == "Bus" {
+					fixture.bus = gopi.InputDeviceBus(value)
+				} else if kv[0] == "Vendor" {
+					fixture.vendor = uint16(value)
+				} else if kv[0] == "Product" {
+					fixture.product = uint16(value)
+				}
+			}
+		case strings.HasPrefix(line, "N: Name="):
+			fixture.name = strings.Trim(strings.TrimPrefix(line, "N: Name="), "\"")
+			fixtures[fixture.name] = fixture
+		case strings.HasPrefix(line, "P: Phys="):
+			fixture.phys = strings.TrimPrefix(line, "P: Phys=")
+		case strings.HasPrefix(line, "U: Uniq="):
+			fixture.uniq = strings.TrimPrefix(line, "U: Uniq=")
+		case strings.HasPrefix(line, "S: Sysfs="):
+			fixture.sysfs = "/sys" + strings.TrimPrefix(line, "S: Sysfs=")
+		case strings.HasPrefix(line, "B: "):
+			kv := strings.SplitN(strings.TrimPrefix(line, "B: "), "=", 2)
+			bits := testParseBitmap(t, kv[1])
+			switch kv[0] {
+			case "PROP":
+				fixture.props = bits
+			case "EV":
+				for _, code := range evBitmapCodes(bits, evKeyCode(EV_MAX)) {
+					fixture.types = append(fixture.types, evType(code))
+				}
+			default:
+				if ev, exists := testBitmapTypes[kv[0]]; exists == false {
+					t.Fatalf("Unknown bitmap: %v", line)
+				} else {
+					fixture.bits[ev] = bits
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return fixtures
+}
+
+// testFixtureDevice returns a device with the identity and capabilities
+// of a fixture as when it is opened, where the absolute axes have a
+// range of 0 to 1000 and touchpads have the default settings
+func testFixtureDevice(t *testing.T, fixture *testFixture) *device {
+	this := testDevice(t, evClassify(fixture.types, fixture.bits, fixture.props, fixture.sysfs))
+	this.name, this.phys, this.uniq = fixture.name, fixture.phys, fixture.uniq
+	this.bus, this.vendor, this.product = fixture.bus, fixture.vendor, fixture.product
+	this.capabilities = fixture.types
+	this.caps = evDecodeCapabilities(fixture.bits, fixture.props)
+	this.controls = evClassifyControls(this.device_type, fixture.bits[EV_KEY])
+	this.device_id = evDeviceID(this.name, this.vendor, this.product, this.uniq, this.phys, fixture.sysfs)
+	this.abs_info = make(map[evKeyCode]evAbsInfo)
+	for _, code := range evBitmapCodes(fixture.bits[EV_ABS], EV_ABS_MAX) {
+		this.abs_info[code] = evAbsInfo{Maximum: 1000}
+	}
+	if this.device_type&INPUT_TYPE_TOUCHPAD != 0 {
+		if err := this.SetTouchpad(DefaultTouchpad); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return this
+}
+
+// testParseBitmap parses a bitmap of 64-bit words in hexadecimal,
+// with the most significant word first
+func testParseBitmap(t *testing.T, value string) []byte {
+	words := strings.Fields(value)
+	bits := make([]byte, 0, len(words)*8)
+	for i := len(words) - 1; i >= 0; i-- {
+		word, err := strconv.ParseUint(words[i], 16, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 8; j++ {
+			bits = append(bits, byte(word>>(uint(j)*8)))
+		}
+	}
+	return bits
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST CLASSIFY
+
+func TestClassify_000(t *testing.T) {
+	tests := map[string]gopi.InputDeviceType{
+		"AT Translated Set 2 keyboard":           gopi.INPUT_TYPE_KEYBOARD,
+		"Logitech USB Optical Mouse":             gopi.INPUT_TYPE_MOUSE,
+		"Razer Razer DeathAdder V2":              gopi.INPUT_TYPE_MOUSE,
+		"Logitech K400 Plus":                     gopi.INPUT_TYPE_KEYBOARD | gopi.INPUT_TYPE_MOUSE,
+		"SynPS/2 Synaptics TouchPad":             INPUT_TYPE_TOUCHPAD,
+		"FT5406 memory based driver":             gopi.INPUT_TYPE_TOUCHSCREEN,
+		"Wacom Intuos S Pen":                     INPUT_TYPE_TABLET,
+		"Microsoft X-Box 360 pad":                gopi.INPUT_TYPE_JOYSTICK,
+		"gpio_ir_recv":                           gopi.INPUT_TYPE_REMOTE,
+		"Lid Switch":                             INPUT_TYPE_SWITCH,
+		"ST LIS3LV02DL Accelerometer":            gopi.INPUT_TYPE_NONE,
+		"Logitech USB Receiver Consumer Control": gopi.INPUT_TYPE_KEYBOARD,
+		"Logitech USB Receiver System Control":   gopi.INPUT_TYPE_KEYBOARD,
+	}
+	fixtures := testReadFixtures(t)
+	if len(fixtures) != len(tests) {
+		t.Errorf("Expected %v fixtures, got %v", len(tests), len(fixtures))
+	}
+	for name, expected := range tests {
+		if fixture, exists := fixtures[name]; exists == false {
+			t.Errorf("Missing fixture: %v", name)
+		} else if device_type := evClassify(fixture.types, fixture.bits, fixture.props, fixture.sysfs); device_type != expected {
+			t.Errorf("%v: Expected %v, got %v", name, DeviceTypeString(expected), DeviceTypeString(device_type))
+		}
+	}
+}
+
+func TestClassify_001(t *testing.T) {
+	// Without the rc-core sysfs path, a remote with a full
+	// set of keys is a keyboard
+	fixture := testReadFixtures(t)["gpio_ir_recv"]
+	if device_type := evClassify(fixture.types, fixture.bits, fixture.props, ""); device_type != gopi.INPUT_TYPE_KEYBOARD {
+		t.Errorf("Expected INPUT_TYPE_KEYBOARD, got %v", DeviceTypeString(device_type))
+	}
+}
+
+func TestClassify_002(t *testing.T) {
+	// Consumer and system control devices are recognised, but keyboards
+	// and remotes with media keys are not control devices. The consumer
+	// control device of the receiver also has a power key
+	tests := map[string]ControlType{
+		"Logitech USB Receiver Consumer Control": CONTROL_CONSUMER | CONTROL_SYSTEM,
+		"Logitech USB Receiver System Control":   CONTROL_SYSTEM,
+		"AT Translated Set 2 keyboard":           CONTROL_NONE,
+		"Logitech K400 Plus":                     CONTROL_NONE,
+		"gpio_ir_recv":                           CONTROL_NONE,
+	}
+	fixtures := testReadFixtures(t)
+	for name, expected := range tests {
+		fixture := fixtures[name]
+		device_type := evClassify(fixture.types, fixture.bits, fixture.props, fixture.sysfs)
+		if controls := evClassifyControls(device_type, fixture.bits[EV_KEY]); controls != expected {
+			t.Errorf("%v: Expected %v, got %v", name, expected, controls)
+		}
+	}
+}
+
+func TestDeviceTypeString_000(t *testing.T) {
+	tests := map[gopi.InputDeviceType]string{
+		gopi.INPUT_TYPE_NONE:                             "INPUT_TYPE_NONE",
+		gopi.INPUT_TYPE_KEYBOARD:                         "INPUT_TYPE_KEYBOARD",
+		gopi.INPUT_TYPE_KEYBOARD | gopi.INPUT_TYPE_MOUSE: "INPUT_TYPE_KEYBOARD|INPUT_TYPE_MOUSE",
+		INPUT_TYPE_TOUCHPAD:                              "INPUT_TYPE_TOUCHPAD",
+		gopi.INPUT_TYPE_TOUCHSCREEN | INPUT_TYPE_TABLET:  "INPUT_TYPE_TOUCHSCREEN|INPUT_TYPE_TABLET",
+		gopi.INPUT_TYPE_KEYBOARD | INPUT_TYPE_SWITCH:     "INPUT_TYPE_KEYBOARD|INPUT_TYPE_SWITCH",
+	}
+	for device_type, expected := range tests {
+		if value := DeviceTypeString(device_type); value != expected {
+			t.Errorf("Expected %v, got %v", expected, value)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"encoding/binary"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	EV_CODE_FF_GAIN       evKeyCode = 0x0060 // Set the strength of all effects
+	EV_CODE_FF_AUTOCENTER evKeyCode = 0x0061 // Set the strength of autocentering
+)
+
+const (
+	EV_VALUE_FF_STATUS_STOPPED uint32 = 0x00000000
+	EV_VALUE_FF_STATUS_PLAYING uint32 = 0x00000001
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// FeedbackDevice INTERFACE
+
+// MaxEffects returns the number of effects which can be uploaded at
+// once, or zero if the device does not support force feedback
+func (this *device) MaxEffects() uint {
+	return this.max_effects
+}
+
+// SupportsEffect returns true if an effect type is supported
+func (this *device) SupportsEffect(effect_type EffectType) bool {
+	return this.ff_bits != nil && evBitIsSet(this.ff_bits, evKeyCode(effect_type))
+}
+
+// UploadEffect uploads an effect to the device and returns the identifier
+// which is used to play, stop, update and erase the effect
+func (this *device) UploadEffect(effect Effect) (EffectID, error) {
+	this.log.Debug2("<sys.input.InputDevice.UploadEffect>{ effect=%v }", effect)
+	if this.SupportsEffect(effect.Type) == false {
+		return -1, gopi.ErrNotImplemented
+	}
+	if id, err := evUploadEffect(this.handle, -1, effect); err != nil {
+		return -1, err
+	} else {
+		return EffectID(id), nil
+	}
+}
+
+// UpdateEffect changes an uploaded effect, which can be playing
+func (this *device) UpdateEffect(id EffectID, effect Effect) error {
+	this.log.Debug2("<sys.input.InputDevice.UpdateEffect>{ id=%v effect=%v }", id, effect)
+	if this.SupportsEffect(effect.Type) == false {
+		return gopi.ErrNotImplemented
+	}
+	if id < 0 {
+		return gopi.ErrBadParameter
+	}
+	_, err := evUploadEffect(this.handle, int16(id), effect)
+	return err
+}
+
+// EraseEffect removes an uploaded effect from the device
+func (this *device) EraseEffect(id EffectID) error {
+	this.log.Debug2("<sys.input.InputDevice.EraseEffect>{ id=%v }", id)
+	if this.ff_bits == nil {
+		return gopi.ErrNotImplemented
+	}
+	if id < 0 {
+		return gopi.ErrBadParameter
+	}
+	return evEraseEffect(this.handle, int16(id))
+}
+
+// PlayEffect plays an uploaded effect a number of times
+func (this *device) PlayEffect(id EffectID, count uint) error {
+	if this.ff_bits == nil {
+		return gopi.ErrNotImplemented
+	}
+	if id < 0 || count == 0 {
+		return gopi.ErrBadParameter
+	}
+	return this.evWriteEvent(EV_FF, evKeyCode(id), uint32(count))
+}
+
+// StopEffect stops an effect from playing
+func (this *device) StopEffect(id EffectID) error {
+	if this.ff_bits == nil {
+		return gopi.ErrNotImplemented
+	}
+	if id < 0 {
+		return gopi.ErrBadParameter
+	}
+	return this.evWriteEvent(EV_FF, evKeyCode(id), 0)
+}
+
+// SetGain sets the strength of all effects, between 0.0 and 1.0
+func (this *device) SetGain(gain float32) error {
+	if this.ff_bits == nil || evBitIsSet(this.ff_bits, EV_CODE_FF_GAIN) == false {
+		return gopi.ErrNotImplemented
+	}
+	if gain < 0 || gain > 1 {
+		return gopi.ErrBadParameter
+	}
+	return this.evWriteEvent(EV_FF, EV_CODE_FF_GAIN, uint32(gain*0xFFFF))
+}
+
+// SetAutocenter sets the strength of autocentering, between 0.0 and 1.0
+func (this *device) SetAutocenter(autocenter float32) error {
+	if this.ff_bits == nil || evBitIsSet(this.ff_bits, EV_CODE_FF_AUTOCENTER) == false {
+		return gopi.ErrNotImplemented
+	}
+	if autocenter < 0 || autocenter > 1 {
+		return gopi.ErrBadParameter
+	}
+	return this.evWriteEvent(EV_FF, EV_CODE_FF_AUTOCENTER, uint32(autocenter*0xFFFF))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evDecodeEffectStatus returns an event when an effect starts or
+// stops playing. The code of the raw event is the effect identifier
+func (this *device) evDecodeEffectStatus(raw_event *evEvent) []gopi.InputEvent {
+	evt := this.evNewEvent(INPUT_EVENT_EFFECT)
+	evt.timestamp = this.evTimestamp(raw_event)
+	evt.position = this.position
+	evt.effect = EffectID(raw_event.Code)
+	switch raw_event.Value {
+	case EV_VALUE_FF_STATUS_STOPPED:
+		evt.status = EFFECT_STATUS_STOPPED
+	case EV_VALUE_FF_STATUS_PLAYING:
+		evt.status = EFFECT_STATUS_PLAYING
+	default:
+		this.log.Warn("evDecodeEffectStatus: Ignoring effect %v with value %v", raw_event.Code, raw_event.Value)
+		return nil
+	}
+	return []gopi.InputEvent{evt}
+}
+
+// evWriteEvent writes an event to the device
+func (this *device) evWriteEvent(ev evType, code evKeyCode, value uint32) error {
+	return binary.Write(this.handle, binary.LittleEndian, &evEvent{Type: ev, Code: code, Value: value})
+}
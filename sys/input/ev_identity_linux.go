@@ -0,0 +1,77 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Name of a HID device in sysfs, which is <bus>:<vendor>:<product>.<number>
+	ev_sysfs_hid = regexp.MustCompile("^[0-9A-F]{4}:[0-9A-F]{4}:[0-9A-F]{4}\\.[0-9A-F]+$")
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evDeviceID returns a device ID from the name, vendor and product of a
+// device and where it is attached. The unique identifier (usually a
+// serial number) is used when it is reported, so the ID doesn't change
+// when the device is plugged into a different port. Otherwise the physical
+// path is used, or the sysfs path of the parent device, so identical
+// devices in different ports have different IDs
+func evDeviceID(name string, vendor, product uint16, uniq, phys, sysfs string) uint32 {
+	location := "uniq:" + uniq
+	if uniq == "" && phys != "" {
+		location = "phys:" + phys
+	} else if uniq == "" {
+		location = "sysfs:" + evSysfsParent(sysfs)
+	}
+	hash := fnv.New32a()
+	fmt.Fprintf(hash, "%04X:%04X\n%s\n%s", vendor, product, name, location)
+	return hash.Sum32()
+}
+
+// evSysfsParent returns the sysfs path of the device which an input
+// device belongs to, since the numbers of input and HID devices change
+// each time the device is plugged in
+func evSysfsParent(sysfs string) string {
+	if strings.HasPrefix(filepath.Base(sysfs), "input") && filepath.Base(filepath.Dir(sysfs)) == "input" {
+		sysfs = filepath.Dir(filepath.Dir(sysfs))
+	}
+	if ev_sysfs_hid.MatchString(filepath.Base(sysfs)) {
+		sysfs = filepath.Dir(sysfs)
+	}
+	return sysfs
+}
+
+// evUniqueDeviceID changes the device ID when it's the same as the ID of
+// another device, which happens when identical devices report the same
+// unique identifier. The physical path, the sysfs path of the parent device
+// and then the device node are mixed into the ID until it's different
+func (this *device) evUniqueDeviceID(sysfs string, exists func(device_id uint32) bool) {
+	for _, location := range []string{"phys:" + this.phys, "sysfs:" + evSysfsParent(sysfs), "path:" + this.path} {
+		if exists(this.device_id) == false {
+			return
+		}
+		hash := fnv.New32a()
+		fmt.Fprintf(hash, "%08X\n%s", this.device_id, location)
+		this.device_id = hash.Sum32()
+	}
+}
@@ -0,0 +1,124 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"testing"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST DEVICE ID
+
+func TestDeviceID_000(t *testing.T) {
+	name := "Honeywell Barcode Scanner"
+	sysfs := "/sys/devices/platform/soc/3f980000.usb/usb1/1-1/1-1.2/1-1.2:1.0/0003:0C2E:0B61.0001/input/input3"
+
+	// Identical devices in different ports have different IDs
+	port1 := evDeviceID(name, 0x0C2E, 0x0B61, "", "usb-3f980000.usb-1.2/input0", sysfs)
+	port2 := evDeviceID(name, 0x0C2E, 0x0B61, "", "usb-3f980000.usb-1.3/input0", sysfs)
+	if port1 == port2 {
+		t.Error("Expected different device IDs for different ports")
+	}
+
+	// The same device has the same ID when plugged in again
+	if port1 != evDeviceID(name, 0x0C2E, 0x0B61, "", "usb-3f980000.usb-1.2/input0", sysfs) {
+		t.Error("Expected the same device ID")
+	}
+
+	// The unique identifier is used in preference to the port
+	serial1 := evDeviceID(name, 0x0C2E, 0x0B61, "17010B0123", "usb-3f980000.usb-1.2/input0", sysfs)
+	serial2 := evDeviceID(name, 0x0C2E, 0x0B61, "17010B0123", "usb-3f980000.usb-1.3/input0", sysfs)
+	if serial1 != serial2 || serial1 == port1 {
+		t.Error("Expected device ID from unique identifier")
+	}
+
+	// Without phys or uniq, the sysfs path of the parent device is used
+	replugged := "/sys/devices/platform/soc/3f980000.usb/usb1/1-1/1-1.2/1-1.2:1.0/0003:0C2E:0B61.0002/input/input7"
+	if evSysfsParent(replugged) != "/sys/devices/platform/soc/3f980000.usb/usb1/1-1/1-1.2/1-1.2:1.0" {
+		t.Errorf("Unexpected sysfs parent: %v", evSysfsParent(replugged))
+	}
+	if evDeviceID(name, 0x0C2E, 0x0B61, "", "", sysfs) != evDeviceID(name, 0x0C2E, 0x0B61, "", "", replugged) {
+		t.Error("Expected the same device ID when plugged in again")
+	}
+	if evSysfsParent("/sys/devices/virtual/misc/uinput") != "/sys/devices/virtual/misc/uinput" {
+		t.Error("Expected unchanged sysfs path")
+	}
+}
+
+func TestDeviceID_001(t *testing.T) {
+	keyboard := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	keyboard.device_id = evDeviceID("Keyboard", 0x04D9, 0x0006, "", "usb-3f980000.usb-1.4/input0", "")
+
+	// Events have the device ID of the device which emitted them
+	evt := NewInputEvent(keyboard, 0, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_A, 0x1E, 0, gopi.ZeroPoint, gopi.ZeroPoint)
+	if evt.(IdentityEvent).DeviceID() != keyboard.device_id {
+		t.Errorf("Expected device ID 0x%08X, got 0x%08X", keyboard.device_id, evt.(IdentityEvent).DeviceID())
+	}
+	events := testDecode(keyboard, []evEvent{
+		{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_A), Value: 1},
+		syn_report,
+	})
+	if len(events) != 1 || events[0].(IdentityEvent).DeviceID() != keyboard.device_id {
+		t.Errorf("Unexpected events: %v", events)
+	}
+
+	// The manager returns the device with the device ID
+	this := &manager{devices: []gopi.InputDevice{testDevice(t, gopi.INPUT_TYPE_MOUSE), keyboard}}
+	if this.DeviceByID(keyboard.device_id) != keyboard {
+		t.Error("Expected keyboard")
+	} else if this.DeviceByID(keyboard.device_id+1) != nil {
+		t.Error("Expected nil device")
+	}
+}
+
+func TestDeviceID_002(t *testing.T) {
+	// Identical receivers report the same unique identifier, so have
+	// the same device ID until it's made unique
+	fixture := testReadFixtures(t)["Logitech K400 Plus"]
+	first, second := testFixtureDevice(t, fixture), testFixtureDevice(t, fixture)
+	first.path, second.path = "/dev/input/event2", "/dev/input/event5"
+	second.phys = "usb-3f980000.usb-1.5/input2:1"
+	if first.uniq == "" || first.device_id != second.device_id {
+		t.Fatalf("Expected the same device ID: 0x%08X 0x%08X", first.device_id, second.device_id)
+	}
+	this := &manager{devices: []gopi.InputDevice{first}}
+	exists := func(device_id uint32) bool {
+		return this.deviceByID(device_id) != nil
+	}
+	device_id := second.device_id
+	second.evUniqueDeviceID(fixture.sysfs, exists)
+	if second.device_id == first.device_id {
+		t.Error("Expected unique device ID")
+	}
+
+	// The ID is the same each time the device is opened in the same port
+	third := testFixtureDevice(t, fixture)
+	third.path, third.phys = second.path, second.phys
+	if third.evUniqueDeviceID(fixture.sysfs, exists); third.device_id != second.device_id {
+		t.Errorf("Expected device ID 0x%08X, got 0x%08X", second.device_id, third.device_id)
+	}
+
+	// Devices in the same port are distinguished by the device node
+	third.device_id, third.phys = device_id, first.phys
+	if third.evUniqueDeviceID(fixture.sysfs, exists); third.device_id == first.device_id {
+		t.Error("Expected unique device ID")
+	}
+
+	// A device ID which is already unique is not changed
+	this.devices = nil
+	if first.evUniqueDeviceID(fixture.sysfs, exists); first.device_id != device_id {
+		t.Errorf("Expected device ID 0x%08X, got 0x%08X", device_id, first.device_id)
+	}
+}
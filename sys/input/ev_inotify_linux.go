@@ -0,0 +1,117 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type evWatchAction uint
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	EV_WATCH_NONE   evWatchAction = iota
+	EV_WATCH_CREATE               // Device node has been created
+	EV_WATCH_ATTRIB               // Device node permissions have changed
+	EV_WATCH_DELETE               // Device node has been removed
+)
+
+const (
+	// Prefix for event-driven device nodes
+	EV_WATCH_PREFIX = "event"
+
+	// Size of buffer for reading inotify events
+	EV_WATCH_BUFFER_SIZE = 4096
+
+	// Mode for checking a device node can be read and written
+	evAccessReadWrite = 0x04 | 0x02
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INOTIFY FUNCTIONS
+
+// evWatchDevices returns a handle which becomes readable when
+// device nodes are created or removed in a folder
+func evWatchDevices(folder string) (*os.File, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	if _, err := syscall.InotifyAddWatch(fd, folder, syscall.IN_CREATE|syscall.IN_ATTRIB|syscall.IN_DELETE); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+	return os.NewFile(uintptr(fd), folder), nil
+}
+
+// evReadWatchEvents reads pending inotify events from the handle and
+// calls a callback function for each event-driven device node with the
+// device path and the action
+func evReadWatchEvents(handle *os.File, callback func(string, evWatchAction)) error {
+	buf := make([]byte, EV_WATCH_BUFFER_SIZE)
+	n, err := handle.Read(buf)
+	if err != nil {
+		return err
+	}
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+		event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		name_start := offset + syscall.SizeofInotifyEvent
+		name_end := name_start + int(event.Len)
+		if name_end > n {
+			break
+		}
+		name := strings.TrimRight(string(buf[name_start:name_end]), "\x00")
+		offset = name_end
+
+		// Only consider event-driven devices
+		if strings.HasPrefix(name, EV_WATCH_PREFIX) == false {
+			continue
+		}
+		file := path.Join(handle.Name(), name)
+		switch {
+		case event.Mask&syscall.IN_CREATE != 0:
+			callback(file, EV_WATCH_CREATE)
+		case event.Mask&syscall.IN_ATTRIB != 0:
+			callback(file, EV_WATCH_ATTRIB)
+		case event.Mask&syscall.IN_DELETE != 0:
+			callback(file, EV_WATCH_DELETE)
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (a evWatchAction) String() string {
+	switch a {
+	case EV_WATCH_NONE:
+		return "EV_WATCH_NONE"
+	case EV_WATCH_CREATE:
+		return "EV_WATCH_CREATE"
+	case EV_WATCH_ATTRIB:
+		return "EV_WATCH_ATTRIB"
+	case EV_WATCH_DELETE:
+		return "EV_WATCH_DELETE"
+	default:
+		return "[?? Invalid evWatchAction value]"
+	}
+}
@@ -14,6 +14,7 @@ package input
 import (
 	"os"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
@@ -22,6 +23,7 @@ import (
 
 /*
  #include <linux/input.h>
+ #include <linux/uinput.h>
  static int _EVIOCGNAME(int len)        { return EVIOCGNAME(len); }
  static int _EVIOCGPHYS(int len)        { return EVIOCGPHYS(len); }
  static int _EVIOCGUNIQ(int len)        { return EVIOCGUNIQ(len); }
@@ -33,6 +35,7 @@ import (
  static int _EVIOCGBIT(int ev, int len) { return EVIOCGBIT(ev, len); }
  static int _EVIOCGABS(int abs)         { return EVIOCGABS(abs); }
  static int _EVIOCSABS(int abs)         { return EVIOCSABS(abs); }
+ static int _EVIOCGMTSLOTS(int len)     { return EVIOCGMTSLOTS(len); }
 */
 import "C"
 
@@ -44,17 +47,52 @@ const (
 	MAX_IOCTL_SIZE_BYTES = 256
 )
 
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Absolute axis information
+type evAbsInfo struct {
+	Value      int32
+	Minimum    int32
+	Maximum    int32
+	Fuzz       int32
+	Flat       int32
+	Resolution int32
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // GLOBAL VARIABLES
 
 var (
-	EVIOCGNAME = uintptr(C._EVIOCGNAME(MAX_IOCTL_SIZE_BYTES)) // get device name
-	EVIOCGPHYS = uintptr(C._EVIOCGPHYS(MAX_IOCTL_SIZE_BYTES)) // get physical location
-	EVIOCGUNIQ = uintptr(C._EVIOCGUNIQ(MAX_IOCTL_SIZE_BYTES)) // get unique identifier
-	EVIOCGPROP = uintptr(C._EVIOCGPROP(MAX_IOCTL_SIZE_BYTES)) // get device properties
-	EVIOCGID   = uintptr(C.EVIOCGID)                          // get device ID
-	EVIOCGLED  = uintptr(C._EVIOCGLED(MAX_IOCTL_SIZE_BYTES))  // get LED states
-	EVIOCGKEY  = uintptr(C._EVIOCGLED(MAX_IOCTL_SIZE_BYTES))  // get key states
+	EVIOCGNAME    = uintptr(C._EVIOCGNAME(MAX_IOCTL_SIZE_BYTES)) // get device name
+	EVIOCGPHYS    = uintptr(C._EVIOCGPHYS(MAX_IOCTL_SIZE_BYTES)) // get physical location
+	EVIOCGUNIQ    = uintptr(C._EVIOCGUNIQ(MAX_IOCTL_SIZE_BYTES)) // get unique identifier
+	EVIOCGPROP    = uintptr(C._EVIOCGPROP(MAX_IOCTL_SIZE_BYTES)) // get device properties
+	EVIOCGID      = uintptr(C.EVIOCGID)                          // get device ID
+	EVIOCGLED     = uintptr(C._EVIOCGLED(MAX_IOCTL_SIZE_BYTES))  // get LED states
+	EVIOCGKEY     = uintptr(C._EVIOCGKEY(MAX_IOCTL_SIZE_BYTES))  // get key states
+	EVIOCGSW      = uintptr(C._EVIOCGSW(MAX_IOCTL_SIZE_BYTES))   // get switch states
+	EVIOCSFF      = uintptr(C.EVIOCSFF)                          // upload force feedback effect
+	EVIOCRMFF     = uintptr(C.EVIOCRMFF)                         // erase force feedback effect
+	EVIOCGEFFECTS = uintptr(C.EVIOCGEFFECTS)                     // get number of simultaneous effects
+	EVIOCGREP     = uintptr(C.EVIOCGREP)                         // get key repeat delay and period
+	EVIOCSREP     = uintptr(C.EVIOCSREP)                         // set key repeat delay and period
+	EVIOCSCLOCKID = uintptr(C.EVIOCSCLOCKID)                     // set clock for event timestamps
+)
+
+var (
+	UI_DEV_CREATE  = uintptr(C.UI_DEV_CREATE)  // create a uinput device
+	UI_DEV_DESTROY = uintptr(C.UI_DEV_DESTROY) // destroy a uinput device
+	UI_DEV_SETUP   = uintptr(C.UI_DEV_SETUP)   // set uinput device name and ID
+	UI_ABS_SETUP   = uintptr(C.UI_ABS_SETUP)   // set uinput absolute axis information
+	UI_SET_EVBIT   = uintptr(C.UI_SET_EVBIT)   // enable uinput event type
+	UI_SET_KEYBIT  = uintptr(C.UI_SET_KEYBIT)  // enable uinput key or button
+	UI_SET_RELBIT  = uintptr(C.UI_SET_RELBIT)  // enable uinput relative axis
+	UI_SET_ABSBIT  = uintptr(C.UI_SET_ABSBIT)  // enable uinput absolute axis
+	UI_SET_MSCBIT  = uintptr(C.UI_SET_MSCBIT)  // enable uinput miscellaneous code
+	UI_SET_LEDBIT  = uintptr(C.UI_SET_LEDBIT)  // enable uinput LED
+	UI_SET_SWBIT   = uintptr(C.UI_SET_SWBIT)   // enable uinput switch
+	UI_SET_PROPBIT = uintptr(C.UI_SET_PROPBIT) // set uinput device property
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -122,14 +160,219 @@ func evGetSupportedEventTypes(handle *os.File) ([]evType, error) {
 	return capabilities, nil
 }
 
+// Get bitmap of supported codes for an event type
+func evGetSupportedCodes(handle *os.File, ev evType) ([]byte, error) {
+	evbits := new([MAX_IOCTL_SIZE_BYTES]byte)
+	err := evIoctl(handle.Fd(), uintptr(C._EVIOCGBIT(C.int(ev), C.int(MAX_IOCTL_SIZE_BYTES))), unsafe.Pointer(evbits))
+	if err != 0 {
+		return nil, err
+	}
+	return evbits[:], nil
+}
+
+// Get bitmap of device properties
+func evGetProperties(handle *os.File) ([]byte, error) {
+	evbits := new([MAX_IOCTL_SIZE_BYTES]byte)
+	err := evIoctl(handle.Fd(), uintptr(EVIOCGPROP), unsafe.Pointer(evbits))
+	if err != 0 {
+		return nil, err
+	}
+	return evbits[:], nil
+}
+
+// Get bitmap of keys which are currently pressed
+func evGetKeyState(handle *os.File) ([]byte, error) {
+	evbits := new([MAX_IOCTL_SIZE_BYTES]byte)
+	err := evIoctl(handle.Fd(), uintptr(EVIOCGKEY), unsafe.Pointer(evbits))
+	if err != 0 {
+		return nil, err
+	}
+	return evbits[:], nil
+}
+
+// Get bitmap of switches which are currently on
+func evGetSwitchState(handle *os.File) ([]byte, error) {
+	evbits := new([MAX_IOCTL_SIZE_BYTES]byte)
+	err := evIoctl(handle.Fd(), uintptr(EVIOCGSW), unsafe.Pointer(evbits))
+	if err != 0 {
+		return nil, err
+	}
+	return evbits[:], nil
+}
+
+// Get absolute axis information (value, minimum, maximum, etc)
+func evGetAbsInfo(handle *os.File, axis evKeyCode) (evAbsInfo, error) {
+	var info evAbsInfo
+	err := evIoctl(handle.Fd(), uintptr(C._EVIOCGABS(C.int(axis))), unsafe.Pointer(&info))
+	if err != 0 {
+		return info, err
+	}
+	return info, nil
+}
+
+// Get the values of a multi-touch code for a number of slots
+func evGetMTSlots(handle *os.File, code evKeyCode, slots int) ([]int32, error) {
+	data := make([]int32, slots+1)
+	data[0] = int32(code)
+	err := evIoctl(handle.Fd(), uintptr(C._EVIOCGMTSLOTS(C.int(len(data)*4))), unsafe.Pointer(&data[0]))
+	if err != 0 {
+		return nil, err
+	}
+	return data[1:], nil
+}
+
+// Get key repeat delay and period in milliseconds
+func evGetRepeat(handle *os.File) (uint32, uint32, error) {
+	rep := [2]C.uint{}
+	err := evIoctl(handle.Fd(), EVIOCGREP, unsafe.Pointer(&rep))
+	if err != 0 {
+		return 0, 0, err
+	}
+	return uint32(rep[0]), uint32(rep[1]), nil
+}
+
+// Set key repeat delay and period in milliseconds, where
+// zero values turn off key repeat in the kernel
+func evSetRepeat(handle *os.File, delay, period uint32) error {
+	rep := [2]C.uint{C.uint(delay), C.uint(period)}
+	err := evIoctl(handle.Fd(), EVIOCSREP, unsafe.Pointer(&rep))
+	if err != 0 {
+		return err
+	}
+	return nil
+}
+
+// Get the number of force feedback effects which can be uploaded
+func evGetMaxEffects(handle *os.File) (uint, error) {
+	var effects C.int
+	err := evIoctl(handle.Fd(), EVIOCGEFFECTS, unsafe.Pointer(&effects))
+	if err != 0 {
+		return 0, err
+	}
+	return uint(effects), nil
+}
+
+// Upload a force feedback effect, where id is -1 for a new effect or
+// the identifier of an existing effect to update. Returns the identifier
+func evUploadEffect(handle *os.File, id int16, effect Effect) (int16, error) {
+	var ff C.struct_ff_effect
+	ff._type = C.__u16(effect.Type)
+	ff.id = C.__s16(id)
+	ff.direction = C.__u16(effect.Direction)
+	ff.replay.length = C.__u16(evEffectMilliseconds(effect.Length))
+	ff.replay.delay = C.__u16(evEffectMilliseconds(effect.Delay))
+	envelope := C.struct_ff_envelope{
+		attack_length: C.__u16(evEffectMilliseconds(effect.AttackLength)),
+		attack_level:  C.__u16(effect.AttackLevel),
+		fade_length:   C.__u16(evEffectMilliseconds(effect.FadeLength)),
+		fade_level:    C.__u16(effect.FadeLevel),
+	}
+	switch effect.Type {
+	case EFFECT_RUMBLE:
+		rumble := (*C.struct_ff_rumble_effect)(unsafe.Pointer(&ff.u[0]))
+		rumble.strong_magnitude = C.__u16(effect.Strong)
+		rumble.weak_magnitude = C.__u16(effect.Weak)
+	case EFFECT_CONSTANT:
+		constant := (*C.struct_ff_constant_effect)(unsafe.Pointer(&ff.u[0]))
+		constant.level = C.__s16(effect.Level)
+		constant.envelope = envelope
+	case EFFECT_PERIODIC:
+		periodic := (*C.struct_ff_periodic_effect)(unsafe.Pointer(&ff.u[0]))
+		periodic.waveform = C.__u16(effect.Waveform)
+		periodic.period = C.__u16(evEffectMilliseconds(effect.Period))
+		periodic.magnitude = C.__s16(effect.Magnitude)
+		periodic.offset = C.__s16(effect.Offset)
+		periodic.phase = C.__u16(effect.Phase)
+		periodic.envelope = envelope
+	default:
+		return -1, syscall.EINVAL
+	}
+	err := evIoctl(handle.Fd(), EVIOCSFF, unsafe.Pointer(&ff))
+	if err != 0 {
+		return -1, err
+	}
+	return int16(ff.id), nil
+}
+
+// Erase a force feedback effect
+func evEraseEffect(handle *os.File, id int16) error {
+	if err := evIoctlValue(handle.Fd(), EVIOCRMFF, uintptr(id)); err != 0 {
+		return err
+	}
+	return nil
+}
+
 // Obtain and release exclusive device usage ("grab")
 func evSetGrabState(handle *os.File, state bool) error {
 	if state {
-		if err := evIoctl(handle.Fd(), C.EVIOCGRAB, unsafe.Pointer(uintptr(1))); err != 0 {
+		if err := evIoctlValue(handle.Fd(), C.EVIOCGRAB, 1); err != 0 {
+			return err
+		}
+	} else {
+		if err := evIoctlValue(handle.Fd(), C.EVIOCGRAB, 0); err != 0 {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set the clock used for event timestamps
+func evSetClockID(handle *os.File, clock_id int) error {
+	clock := C.int(clock_id)
+	if err := evIoctl(handle.Fd(), EVIOCSCLOCKID, unsafe.Pointer(&clock)); err != 0 {
+		return err
+	}
+	return nil
+}
+
+// Enable a code for a uinput device
+func evUinputSetBit(handle *os.File, name uintptr, code uint16) error {
+	if err := evIoctlValue(handle.Fd(), name, uintptr(code)); err != 0 {
+		return err
+	}
+	return nil
+}
+
+// Set the name, bus, vendor, product and version of a uinput device
+func evUinputSetup(handle *os.File, name string, bus, vendor, product, version uint16) error {
+	var setup C.struct_uinput_setup
+	setup.id.bustype = C.__u16(bus)
+	setup.id.vendor = C.__u16(vendor)
+	setup.id.product = C.__u16(product)
+	setup.id.version = C.__u16(version)
+	for i := 0; i < len(name) && i < C.UINPUT_MAX_NAME_SIZE-1; i++ {
+		setup.name[i] = C.char(name[i])
+	}
+	if err := evIoctl(handle.Fd(), UI_DEV_SETUP, unsafe.Pointer(&setup)); err != 0 {
+		return err
+	}
+	return nil
+}
+
+// Set the absolute axis information for a uinput device
+func evUinputAbsSetup(handle *os.File, axis evKeyCode, info evAbsInfo) error {
+	var setup C.struct_uinput_abs_setup
+	setup.code = C.__u16(axis)
+	setup.absinfo.value = C.__s32(info.Value)
+	setup.absinfo.minimum = C.__s32(info.Minimum)
+	setup.absinfo.maximum = C.__s32(info.Maximum)
+	setup.absinfo.fuzz = C.__s32(info.Fuzz)
+	setup.absinfo.flat = C.__s32(info.Flat)
+	setup.absinfo.resolution = C.__s32(info.Resolution)
+	if err := evIoctl(handle.Fd(), UI_ABS_SETUP, unsafe.Pointer(&setup)); err != 0 {
+		return err
+	}
+	return nil
+}
+
+// Create and destroy a uinput device
+func evUinputCreate(handle *os.File, state bool) error {
+	if state {
+		if err := evIoctlValue(handle.Fd(), UI_DEV_CREATE, 0); err != 0 {
 			return err
 		}
 	} else {
-		if err := evIoctl(handle.Fd(), C.EVIOCGRAB, unsafe.Pointer(uintptr(0))); err != 0 {
+		if err := evIoctlValue(handle.Fd(), UI_DEV_DESTROY, 0); err != 0 {
 			return err
 		}
 	}
@@ -141,3 +384,23 @@ func evIoctl(fd uintptr, name uintptr, data unsafe.Pointer) syscall.Errno {
 	_, _, err := syscall.RawSyscall(syscall.SYS_IOCTL, fd, name, uintptr(data))
 	return err
 }
+
+// Call ioctl with an integer value rather than a pointer
+func evIoctlValue(fd uintptr, name uintptr, value uintptr) syscall.Errno {
+	_, _, err := syscall.RawSyscall(syscall.SYS_IOCTL, fd, name, value)
+	return err
+}
+
+// Return a duration in milliseconds for an effect, which
+// is limited to 0xFFFF milliseconds
+func evEffectMilliseconds(value time.Duration) uint16 {
+	ms := value / time.Millisecond
+	switch {
+	case ms < 0:
+		return 0
+	case ms > 0xFFFF:
+		return 0xFFFF
+	default:
+		return uint16(ms)
+	}
+}
@@ -0,0 +1,285 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Represents the state of a joystick axis, where value is the
+// normalised value of the axis after the dead zone is applied
+type evAxis struct {
+	raw       int32
+	value     float32
+	minimum   int32
+	maximum   int32
+	dead_zone float32
+	fuzz      int32
+	trigger   bool
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	EV_CODE_Z        evKeyCode = 0x0002
+	EV_CODE_RZ       evKeyCode = 0x0005
+	EV_CODE_THROTTLE evKeyCode = 0x0006
+	EV_CODE_GAS      evKeyCode = 0x0009
+	EV_CODE_BRAKE    evKeyCode = 0x000A // Last joystick axis
+	EV_CODE_HAT0X    evKeyCode = 0x0010 // First hat axis
+	EV_CODE_HAT3Y    evKeyCode = 0x0017 // Last hat axis
+)
+
+const (
+	// Number of hats on a joystick
+	EV_JOYSTICK_HATS = 4
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// JOYSTICK
+
+// evInitJoystick sets up the axes and hats from the absolute axis
+// information. When dead_zone is zero, the flat value reported by
+// the device is used as the dead zone
+func (this *device) evInitJoystick(dead_zone float32) {
+	this.axes = make(map[evKeyCode]*evAxis)
+	for code, info := range this.abs_info {
+		switch {
+		case code <= EV_CODE_BRAKE:
+			axis := &evAxis{
+				raw:     info.Value,
+				minimum: info.Minimum,
+				maximum: info.Maximum,
+				fuzz:    info.Fuzz,
+				trigger: evIsTrigger(code) && info.Minimum >= 0,
+			}
+			if dead_zone > 0 {
+				axis.dead_zone = dead_zone
+			} else {
+				axis.dead_zone = evFlatDeadZone(info, axis.trigger)
+			}
+			axis.value = axis.normalise()
+			this.axes[code] = axis
+		case code >= EV_CODE_HAT0X && code <= EV_CODE_HAT3Y:
+			hat := (code - EV_CODE_HAT0X) >> 1
+			this.hats[hat] = evHatDirection(this.hats[hat], code, info.Value)
+		}
+	}
+}
+
+// evDecodeJoystick decodes joystick axes and hats. An axis event is
+// returned when the normalised value of an axis changes. Hat events are
+// returned once per hat in a frame, with the direction at the end of the
+// frame
+func (this *device) evDecodeJoystick(raw_event *evEvent, hat_events map[uint]*input_event) []*input_event {
+	value := int32(raw_event.Value)
+
+	// Hats
+	if raw_event.Code >= EV_CODE_HAT0X {
+		hat := uint(raw_event.Code-EV_CODE_HAT0X) >> 1
+		direction := evHatDirection(this.hats[hat], raw_event.Code, value)
+		if direction == this.hats[hat] {
+			return nil
+		}
+		this.hats[hat] = direction
+		if evt, exists := hat_events[hat]; exists {
+			evt.direction = direction
+			return nil
+		}
+		evt := this.evNewEvent(INPUT_EVENT_HAT)
+		evt.hat = hat
+		evt.direction = direction
+		hat_events[hat] = evt
+		return []*input_event{evt}
+	}
+
+	// Axes
+	axis, exists := this.axes[raw_event.Code]
+	if exists == false {
+		this.log.Warn("evDecodeJoystick: %v Ignoring code %v", raw_event.Type, raw_event.Code)
+		return nil
+	}
+
+	// Ignore changes within the fuzz, except at the ends of the axis
+	if delta := value - axis.raw; delta <= axis.fuzz && -delta <= axis.fuzz && value != axis.minimum && value != axis.maximum {
+		return nil
+	}
+	axis.raw = value
+	if normalised := axis.normalise(); normalised == axis.value {
+		return nil
+	} else {
+		axis.value = normalised
+	}
+	evt := this.evNewEvent(INPUT_EVENT_AXIS)
+	evt.axis = AbsAxis(raw_event.Code)
+	evt.value = axis.value
+	return []*input_event{evt}
+}
+
+// evResyncJoystick returns absolute axis events for any joystick
+// axes or hats which differ from the current state
+func (this *device) evResyncJoystick() []evEvent {
+	frame := make([]evEvent, 0)
+	for code, axis := range this.axes {
+		if info, err := evGetAbsInfo(this.handle, code); err != nil {
+			this.log.Warn("evResync: %v", err)
+		} else if info.Value != axis.raw {
+			frame = append(frame, evEvent{Type: EV_ABS, Code: code, Value: uint32(info.Value)})
+		}
+	}
+	for code := EV_CODE_HAT0X; code <= EV_CODE_HAT3Y; code++ {
+		if _, exists := this.abs_info[code]; exists == false {
+			continue
+		}
+		hat := (code - EV_CODE_HAT0X) >> 1
+		if info, err := evGetAbsInfo(this.handle, code); err != nil {
+			this.log.Warn("evResync: %v", err)
+		} else if evHatDirection(this.hats[hat], code, info.Value) != this.hats[hat] {
+			frame = append(frame, evEvent{Type: EV_ABS, Code: code, Value: uint32(info.Value)})
+		}
+	}
+	return frame
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// JoystickDevice INTERFACE
+
+// AxisValue returns the current value of an axis, or zero if the
+// axis is not supported
+func (this *device) AxisValue(axis AbsAxis) float32 {
+	if state, exists := this.axes[evKeyCode(axis)]; exists {
+		return state.value
+	} else {
+		return 0
+	}
+}
+
+// HatDirection returns the current direction of a hat
+func (this *device) HatDirection(hat uint) HatDirection {
+	if hat >= EV_JOYSTICK_HATS {
+		return HAT_CENTRE
+	} else {
+		return this.hats[hat]
+	}
+}
+
+// SetDeadZone sets the dead zone for an axis, as a proportion
+// of the range of the axis
+func (this *device) SetDeadZone(axis AbsAxis, dead_zone float32) error {
+	if state, exists := this.axes[evKeyCode(axis)]; exists == false {
+		return gopi.ErrBadParameter
+	} else if dead_zone < 0 || dead_zone >= 1 {
+		return gopi.ErrBadParameter
+	} else {
+		state.dead_zone = dead_zone
+		state.value = state.normalise()
+		return nil
+	}
+}
+
+// SetFuzz sets the fuzz for an axis, in device units
+func (this *device) SetFuzz(axis AbsAxis, fuzz int32) error {
+	if state, exists := this.axes[evKeyCode(axis)]; exists == false {
+		return gopi.ErrBadParameter
+	} else if fuzz < 0 {
+		return gopi.ErrBadParameter
+	} else {
+		state.fuzz = fuzz
+		return nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// normalise returns the value of the axis between -1.0 and 1.0, or
+// between 0.0 and 1.0 for triggers, with the dead zone applied. Values
+// outside the dead zone are rescaled so there is no step at its edge
+func (this *evAxis) normalise() float32 {
+	if this.maximum <= this.minimum {
+		return 0
+	}
+	value := float32(this.raw-this.minimum) / float32(this.maximum-this.minimum)
+	if this.trigger == false {
+		value = value*2 - 1
+	}
+	magnitude, sign := value, float32(1)
+	if magnitude < 0 {
+		magnitude, sign = -magnitude, -1
+	}
+	if magnitude <= this.dead_zone {
+		return 0
+	}
+	if magnitude > 1 {
+		magnitude = 1
+	}
+	return sign * (magnitude - this.dead_zone) / (1 - this.dead_zone)
+}
+
+// evIsJoystickCode returns true if the code is a joystick axis or hat
+func evIsJoystickCode(code evKeyCode) bool {
+	return code <= EV_CODE_BRAKE || (code >= EV_CODE_HAT0X && code <= EV_CODE_HAT3Y)
+}
+
+// evIsTrigger returns true for axes which are triggers or throttles
+// rather than sticks, when they have a range which starts at zero
+func evIsTrigger(code evKeyCode) bool {
+	switch code {
+	case EV_CODE_Z, EV_CODE_RZ, EV_CODE_THROTTLE, EV_CODE_GAS, EV_CODE_BRAKE:
+		return true
+	default:
+		return false
+	}
+}
+
+// evFlatDeadZone returns the dead zone from the flat value
+// reported by the device, as a proportion of the range
+func evFlatDeadZone(info evAbsInfo, trigger bool) float32 {
+	if info.Maximum <= info.Minimum || info.Flat <= 0 {
+		return 0
+	}
+	dead_zone := float32(info.Flat) / float32(info.Maximum-info.Minimum)
+	if trigger == false {
+		dead_zone = dead_zone * 2
+	}
+	if dead_zone >= 1 {
+		return 0
+	}
+	return dead_zone
+}
+
+// evHatDirection returns the direction of a hat after a hat axis changes
+func evHatDirection(direction HatDirection, code evKeyCode, value int32) HatDirection {
+	if (code-EV_CODE_HAT0X)&1 == 0 {
+		direction &^= HAT_LEFT | HAT_RIGHT
+		switch {
+		case value < 0:
+			direction |= HAT_LEFT
+		case value > 0:
+			direction |= HAT_RIGHT
+		}
+	} else {
+		direction &^= HAT_UP | HAT_DOWN
+		switch {
+		case value < 0:
+			direction |= HAT_UP
+		case value > 0:
+			direction |= HAT_DOWN
+		}
+	}
+	return direction
+}
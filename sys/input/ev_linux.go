@@ -17,6 +17,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"syscall"
 	"time"
 	"unsafe"
 
@@ -34,6 +35,9 @@ type evKeyCode uint16
 type evKeyAction uint32
 type evLEDState uint8
 
+// Bitmap of keys which are pressed
+type evKeyBitmap [(EV_KEY_MAX + 8) >> 3]byte
+
 type evEvent struct {
 	Second      uint32
 	Microsecond uint32
@@ -63,6 +67,13 @@ const (
 	EV_MAX       evType = 0x001F
 )
 
+const (
+	EV_CODE_SYN_REPORT    evKeyCode = 0x0000 // End of a frame of events
+	EV_CODE_SYN_CONFIG    evKeyCode = 0x0001
+	EV_CODE_SYN_MT_REPORT evKeyCode = 0x0002 // End of a multi touch contact
+	EV_CODE_SYN_DROPPED   evKeyCode = 0x0003 // Events have been dropped
+)
+
 const (
 	EV_CODE_X        evKeyCode = 0x0000
 	EV_CODE_Y        evKeyCode = 0x0001
@@ -73,6 +84,26 @@ const (
 	EV_CODE_SLOT_ID  evKeyCode = 0x0039 // Unique ID for multi touch position
 )
 
+// Relative scroll codes. High resolution codes report
+// fractions of a detent, in units of EV_SCROLL_HI_RES_DETENT
+const (
+	EV_CODE_HWHEEL        evKeyCode = 0x0006
+	EV_CODE_WHEEL         evKeyCode = 0x0008
+	EV_CODE_WHEEL_HI_RES  evKeyCode = 0x000B
+	EV_CODE_HWHEEL_HI_RES evKeyCode = 0x000C
+)
+
+// Maximum codes
+const (
+	EV_KEY_MAX evKeyCode = 0x02FF
+	EV_REL_MAX evKeyCode = 0x000F
+	EV_ABS_MAX evKeyCode = 0x003F
+)
+
+const (
+	EV_SCROLL_HI_RES_DETENT = 120
+)
+
 const (
 	EV_VALUE_KEY_NONE   evKeyAction = 0x00000000
 	EV_VALUE_KEY_UP     evKeyAction = 0x00000000
@@ -104,222 +135,427 @@ func (this *device) evReceive(dev *os.File, mode linux.FilePollMode) {
 	var raw_event evEvent
 	if err := binary.Read(dev, binary.LittleEndian, &raw_event); err == io.EOF {
 		return
+	} else if perr, ok := err.(*os.PathError); ok && perr.Err == syscall.ENODEV {
+		// Device has been removed, the manager will close it
+		return
 	} else if err != nil {
 		this.log.Error("sys.input.linux.InputDevice.Receive: %v", err)
 		return
 	}
 
-	// Decode the event
+	// Decode the event and emit any events when the frame is complete
+	for _, evt := range this.evDecode(&raw_event) {
+		this.Emit(evt)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// DECODE
+
+// evDecode adds a raw event to the current frame. When the frame is
+// completed with a SYN_REPORT the frame is decoded and the events
+// are returned in the order they were received
+func (this *device) evDecode(raw_event *evEvent) []gopi.InputEvent {
 	switch raw_event.Type {
 	case EV_SYN:
-		if evt := this.evDecodeSyn(&raw_event); evt != nil {
-			this.Emit(evt)
+		return this.evDecodeSyn(raw_event)
+	case EV_KEY, EV_ABS, EV_REL, EV_MSC, EV_SW:
+		this.frame = append(this.frame, *raw_event)
+	case EV_LED, EV_FF:
+		// Ignore EV_LED events, and EV_FF events which have
+		// been written to the device
+	case EV_FF_STATUS:
+		return this.evDecodeEffectStatus(raw_event)
+	default:
+		this.log.Warn("sys.input.linux.InputDevice.Receive: Ignoring event with type %v", raw_event.Type)
+	}
+	return nil
+}
+
+// Decode the EV_SYN syncronization raw event.
+func (this *device) evDecodeSyn(raw_event *evEvent) []gopi.InputEvent {
+	switch raw_event.Code {
+	case EV_CODE_SYN_REPORT:
+		if this.dropped {
+			// Events up to and including this report are discarded
+			// and the device state is queried instead
+			this.frame = this.frame[:0]
+			events := this.evResync(this.evTimestamp(raw_event))
+			this.dropped = false
+			return events
 		}
-	case EV_KEY:
-		this.evDecodeKey(&raw_event)
-	case EV_ABS:
-		if evt := this.evDecodeAbs(&raw_event); evt != nil {
-			this.Emit(evt)
+		if this.mt_protocol_a {
+			this.frame = this.evProtocolAFrame(this.frame)
 		}
-	case EV_REL:
-		this.evDecodeRel(&raw_event)
-	case EV_MSC:
-		this.evDecodeMsc(&raw_event)
-	case EV_LED:
-		// Ignore EV_LED events
+		events := this.evDecodeFrame(this.evTimestamp(raw_event))
+		this.frame = this.frame[:0]
+		return events
+	case EV_CODE_SYN_MT_REPORT:
+		// Marks the end of a contact for protocol A devices
+		this.mt_protocol_a = true
+		this.frame = append(this.frame, *raw_event)
+		return nil
+	case EV_CODE_SYN_DROPPED:
+		// The kernel buffer has overflowed, so discard the partial frame
+		this.log.Debug("evDecodeSyn: Events dropped, resyncing on next report")
+		this.frame = this.frame[:0]
+		this.dropped = true
+		return nil
 	default:
-		this.log.Warn("sys.input.linux.InputDevice.Receive: Ignoring event with type %v", raw_event.Type)
+		this.log.Debug("evDecodeSyn: Ignoring code %v", raw_event.Code)
+		return nil
 	}
 }
 
-////////////////////////////////////////////////////////////////////////////////
-// DECODE
+// evDecodeFrame decodes all raw events in a frame. Key and touch events
+// are returned in order. Axis changes are combined into a single relative
+// or absolute position event, placed where the first axis change occurred,
+// and multi-touch position changes are combined into one event per slot.
+// Stylus axis changes on tablets are combined into a single stylus event.
+// Events carry the device position (or the slot position for touch events)
+// at the end of the frame
+func (this *device) evDecodeFrame(ts time.Duration) []gopi.InputEvent {
+	events := make([]*input_event, 0, len(this.frame))
+	touch_events := make(map[uint32]*input_event)
+	hat_events := make(map[uint]*input_event)
+	stylus_frame := make([]evEvent, 0)
+	var rel_event, abs_event, scroll_event, stylus_event *input_event
+
+	for i := range this.frame {
+		raw_event := &this.frame[i]
+		switch raw_event.Type {
+		case EV_KEY:
+			if this.device_type&INPUT_TYPE_TABLET != 0 && evIsStylusKey(raw_event.Code) {
+				if evt := this.evDecodeStylusKey(raw_event); evt != nil {
+					events = append(events, evt)
+				}
+			} else if evt := this.evDecodeKey(raw_event); evt != nil {
+				events = append(events, evt)
+			}
+		case EV_REL:
+			switch raw_event.Code {
+			case EV_CODE_WHEEL, EV_CODE_HWHEEL, EV_CODE_WHEEL_HI_RES, EV_CODE_HWHEEL_HI_RES:
+				if scroll_event == nil {
+					scroll_event = this.evNewEvent(INPUT_EVENT_SCROLL)
+					events = append(events, scroll_event)
+				}
+				this.evDecodeScroll(raw_event, scroll_event)
+			default:
+				if rel_event == nil {
+					rel_event = this.evNewEvent(gopi.INPUT_EVENT_RELPOSITION)
+					events = append(events, rel_event)
+				}
+				this.evDecodeRel(raw_event, rel_event)
+			}
+		case EV_ABS:
+			if this.device_type&gopi.INPUT_TYPE_JOYSTICK != 0 && evIsJoystickCode(raw_event.Code) {
+				events = append(events, this.evDecodeJoystick(raw_event, hat_events)...)
+				continue
+			}
+			if this.device_type&INPUT_TYPE_TABLET != 0 && evIsStylusCode(raw_event.Code) {
+				if stylus_event == nil {
+					stylus_event = this.evNewEvent(INPUT_EVENT_STYLUS)
+					events = append(events, stylus_event)
+				}
+				stylus_frame = append(stylus_frame, *raw_event)
+				continue
+			}
+			if raw_event.Code == EV_CODE_X || raw_event.Code == EV_CODE_Y {
+				if abs_event == nil {
+					abs_event = this.evNewEvent(gopi.INPUT_EVENT_ABSPOSITION)
+					events = append(events, abs_event)
+				}
+			}
+			events = append(events, this.evDecodeAbs(raw_event, touch_events)...)
+		case EV_MSC:
+			this.evDecodeMsc(raw_event)
+		case EV_SW:
+			if evt := this.evDecodeSwitch(raw_event); evt != nil {
+				events = append(events, evt)
+			}
+		}
+	}
 
-// Decode the EV_SYN syncronization raw event.
-func (this *device) evDecodeSyn(raw_event *evEvent) gopi.InputEvent {
-	evt := &input_event{
-		source:    this,
-		timestamp: time.Duration(time.Duration(raw_event.Second)*time.Second + time.Duration(raw_event.Microsecond)*time.Microsecond),
-		device:    this.device_type,
-		device_id: this.device_id,
+	// Update the position from relative movement
+	if rel_event != nil {
+		this.position.X += rel_event.rel_position.X
+		this.position.Y += rel_event.rel_position.Y
 	}
 
-	// Mouse and keyboard movements
-	if this.rel_position.Equals(gopi.ZeroPoint) == false {
-		// Update position
-		this.last_position = this.position
-		this.position.X += this.rel_position.X
-		this.position.Y += this.rel_position.Y
-		// Set event values
-		evt.event = gopi.INPUT_EVENT_RELPOSITION
-		evt.rel_position = this.rel_position
-		evt.position = this.position
-	} else if this.position.Equals(this.last_position) == false {
-		evt.event = gopi.INPUT_EVENT_ABSPOSITION
-		evt.position = this.position
-		evt.rel_position = gopi.ZeroPoint
-	} else if this.key_action == EV_VALUE_KEY_UP {
-		evt.event = gopi.INPUT_EVENT_KEYRELEASE
-		evt.key_code = gopi.KeyCode(this.key_code)
-		evt.key_state = this.key_state
-		evt.scan_code = this.scan_code
-		this.key_action = EV_VALUE_KEY_NONE
-	} else if this.key_action == EV_VALUE_KEY_DOWN {
-		evt.event = gopi.INPUT_EVENT_KEYPRESS
-		evt.key_code = gopi.KeyCode(this.key_code)
-		evt.key_state = this.key_state
-		evt.scan_code = this.scan_code
-		this.key_action = EV_VALUE_KEY_NONE
-	} else if this.key_action == EV_VALUE_KEY_REPEAT {
-		evt.event = gopi.INPUT_EVENT_KEYREPEAT
-		evt.key_code = gopi.KeyCode(this.key_code)
-		evt.key_state = this.key_state
-		evt.scan_code = this.scan_code
-		this.key_action = EV_VALUE_KEY_NONE
-	} else {
-		return nil
+	// Apply the stylus axes for tablets
+	if this.device_type&INPUT_TYPE_TABLET != 0 {
+		events = this.evDecodeStylus(events, stylus_frame)
 	}
 
-	// Check for multi-touch positional changes
-	/*slot := device.slots[device.slot]
-	if slot.active {
-		this.log.Debug("SLOT=%v POSITION=%v", device.slot, slot.position)
-	}*/
+	// Convert touches into mouse-style events for touchpads
+	if this.touchpad.Mode == TOUCHPAD_POINTER {
+		events = this.evDecodeTouchpad(events, ts)
+	}
 
-	return evt
+	// Set the timestamp and position on all events. Touch release events
+	// already carry the last position of the slot
+	result := make([]gopi.InputEvent, 0, len(events))
+	for _, evt := range events {
+		evt.timestamp = ts
+		switch evt.event {
+		case gopi.INPUT_EVENT_TOUCHPRESS, gopi.INPUT_EVENT_TOUCHPOSITION:
+			evt.position = this.slots[evt.slot].position
+		case gopi.INPUT_EVENT_TOUCHRELEASE:
+			// Position is set on release
+		default:
+			evt.position = this.position
+		}
+		if this.device_type&INPUT_TYPE_TABLET != 0 && evt.event != INPUT_EVENT_PROXIMITYOUT {
+			this.evSetStylusState(evt)
+		}
+		if evt == rel_event && evt.rel_position.Equals(gopi.ZeroPoint) {
+			continue
+		}
+		if evt == scroll_event && evt.scroll.Equals(gopi.ZeroPoint) {
+			continue
+		}
+		if this.KeyRepeat().Mode == REPEAT_SOFTWARE {
+			this.evSoftwareRepeat(evt)
+		}
+		result = append(result, evt)
+	}
+	return result
 }
 
-func (this *device) evDecodeKey(raw_event *evEvent) {
-	this.key_code = evKeyCode(raw_event.Code)
-	this.key_action = evKeyAction(raw_event.Value)
+func (this *device) evDecodeKey(raw_event *evEvent) *input_event {
+	key_code := gopi.KeyCode(raw_event.Code)
+	key_action := evKeyAction(raw_event.Value)
+
+	// Record pressed keys, ignoring presses and releases which don't
+	// change the state
+	switch key_action {
+	case EV_VALUE_KEY_DOWN:
+		if this.keys.isSet(raw_event.Code) {
+			return nil
+		}
+		this.keys.set(raw_event.Code, true)
+	case EV_VALUE_KEY_UP:
+		if this.keys.isSet(raw_event.Code) == false {
+			return nil
+		}
+		this.keys.set(raw_event.Code, false)
+	}
 
 	// Set the device state from the key action. For the locks (Caps, Scroll
 	// and Num) we also reflect the change with the LED and "flip" the state
-	// from the current state.
-	key_state := gopi.KEYSTATE_NONE
-	switch gopi.KeyCode(this.key_code) {
+	// from the current state, except when resyncing, when the LED state is
+	// read from the device instead
+	var key_state gopi.KeyState
+	switch key_code {
 	case gopi.KEYCODE_CAPSLOCK:
 		// Flip CAPS LOCK state and set LED
-		if this.key_action == EV_VALUE_KEY_DOWN {
+		if key_action == EV_VALUE_KEY_DOWN && this.dropped == false {
 			this.key_state ^= gopi.KEYSTATE_CAPSLOCK
 			evSetLEDState(this.handle, EV_LED_CAPSL, this.key_state&gopi.KEYSTATE_CAPSLOCK != gopi.KEYSTATE_NONE)
 		}
 	case gopi.KEYCODE_NUMLOCK:
 		// Flip NUM LOCK state and set LED
-		if this.key_action == EV_VALUE_KEY_DOWN {
+		if key_action == EV_VALUE_KEY_DOWN && this.dropped == false {
 			this.key_state ^= gopi.KEYSTATE_NUMLOCK
 			evSetLEDState(this.handle, EV_LED_NUML, this.key_state&gopi.KEYSTATE_NUMLOCK != gopi.KEYSTATE_NONE)
 		}
 	case gopi.KEYCODE_SCROLLLOCK:
 		// Flip SCROLL LOCK state and set LED
-		if this.key_action == EV_VALUE_KEY_DOWN {
+		if key_action == EV_VALUE_KEY_DOWN && this.dropped == false {
 			this.key_state ^= gopi.KEYSTATE_SCROLLLOCK
 			evSetLEDState(this.handle, EV_LED_SCROLLL, this.key_state&gopi.KEYSTATE_SCROLLLOCK != gopi.KEYSTATE_NONE)
 		}
-	case gopi.KEYCODE_LEFTSHIFT:
-		key_state = gopi.KEYSTATE_LEFTSHIFT
-	case gopi.KEYCODE_RIGHTSHIFT:
-		key_state = gopi.KEYSTATE_RIGHTSHIFT
-	case gopi.KEYCODE_LEFTCTRL:
-		key_state = gopi.KEYSTATE_LEFTCTRL
-	case gopi.KEYCODE_RIGHTCTRL:
-		key_state = gopi.KEYSTATE_RIGHTCTRL
-	case gopi.KEYCODE_LEFTALT:
-		key_state = gopi.KEYSTATE_LEFTALT
-	case gopi.KEYCODE_RIGHTALT:
-		key_state = gopi.KEYSTATE_RIGHTALT
-	case gopi.KEYCODE_LEFTMETA:
-		key_state = gopi.KEYSTATE_LEFTMETA
-	case gopi.KEYCODE_RIGHTMETA:
-		key_state = gopi.KEYSTATE_RIGHTMETA
+	default:
+		key_state = evModifierKeyState(key_code)
 	}
 
 	// Set device state from key action
 	if key_state != gopi.KEYSTATE_NONE {
-		if this.key_action == EV_VALUE_KEY_DOWN || this.key_action == EV_VALUE_KEY_REPEAT {
+		if key_action == EV_VALUE_KEY_DOWN || key_action == EV_VALUE_KEY_REPEAT {
 			this.key_state |= key_state
-		} else if this.key_action == EV_VALUE_KEY_UP {
-			this.key_state ^= key_state
+		} else if key_action == EV_VALUE_KEY_UP {
+			this.key_state &^= key_state
+		}
+	}
+
+	// Create the event, consuming the scan code
+	var evt *input_event
+	switch key_action {
+	case EV_VALUE_KEY_UP:
+		evt = this.evNewEvent(gopi.INPUT_EVENT_KEYRELEASE)
+	case EV_VALUE_KEY_DOWN:
+		evt = this.evNewEvent(gopi.INPUT_EVENT_KEYPRESS)
+	case EV_VALUE_KEY_REPEAT:
+		if mode := this.KeyRepeat().Mode; mode == REPEAT_SOFTWARE || mode == REPEAT_NONE {
+			// Ignore repeats from the kernel
+			return nil
 		}
+		evt = this.evNewEvent(gopi.INPUT_EVENT_KEYREPEAT)
+	default:
+		this.log.Warn("evDecodeKey: Ignoring key %v with value %v", raw_event.Code, raw_event.Value)
+		return nil
 	}
+	evt.key_code = key_code
+	evt.scan_code = this.scan_code
+	this.scan_code = 0
+	return evt
 }
 
-func (this *device) evDecodeAbs(raw_event *evEvent) gopi.InputEvent {
-	if raw_event.Code == EV_CODE_X {
-		this.last_position.X = this.position.X
-		this.position.X = float32(int32(raw_event.Value))
-	} else if raw_event.Code == EV_CODE_Y {
-		this.last_position.Y = this.position.Y
-		this.position.Y = float32(int32(raw_event.Value))
-	} else if raw_event.Code == EV_CODE_SLOT {
+func (this *device) evDecodeAbs(raw_event *evEvent, touch_events map[uint32]*input_event) []*input_event {
+	switch raw_event.Code {
+	case EV_CODE_X:
+		this.raw_position.X = float32(int32(raw_event.Value))
+		this.position = this.evTransformAbs(this.raw_position, EV_CODE_X, EV_CODE_Y)
+	case EV_CODE_Y:
+		this.raw_position.Y = float32(int32(raw_event.Value))
+		this.position = this.evTransformAbs(this.raw_position, EV_CODE_X, EV_CODE_Y)
+	case EV_CODE_SLOT:
 		this.slot = raw_event.Value
-	} else if raw_event.Code == EV_CODE_SLOT_ID || raw_event.Code == EV_CODE_SLOT_X || raw_event.Code == EV_CODE_SLOT_Y {
-		switch {
-		case this.slot < uint32(0) || this.slot >= INPUT_MAX_MULTITOUCH_SLOTS:
+	case EV_CODE_SLOT_ID, EV_CODE_SLOT_X, EV_CODE_SLOT_Y:
+		if this.slot >= uint32(len(this.slots)) {
 			this.log.Warn("evDecodeAbs: Ignoring out-of-range slot %v", this.slot)
-		case raw_event.Code == EV_CODE_SLOT_ID:
-			return this.evDecodeAbsTouch(raw_event)
-		case raw_event.Code == EV_CODE_SLOT_X:
-			this.slots[this.slot].position.X = float32(int32(raw_event.Value))
-			this.slots[this.slot].active = true
-		case raw_event.Code == EV_CODE_SLOT_Y:
-			this.slots[this.slot].position.Y = float32(int32(raw_event.Value))
-			this.slots[this.slot].active = true
+			return nil
 		}
-	} else {
+		slot := &this.slots[this.slot]
+		switch raw_event.Code {
+		case EV_CODE_SLOT_ID:
+			return this.evDecodeAbsTouch(raw_event, touch_events)
+		case EV_CODE_SLOT_X:
+			slot.raw.X = float32(int32(raw_event.Value))
+			slot.position = this.evTransformAbs(slot.raw, EV_CODE_SLOT_X, EV_CODE_SLOT_Y)
+		case EV_CODE_SLOT_Y:
+			slot.raw.Y = float32(int32(raw_event.Value))
+			slot.position = this.evTransformAbs(slot.raw, EV_CODE_SLOT_X, EV_CODE_SLOT_Y)
+		}
+		// Emit one position event per active slot in the frame, unless
+		// the slot has been pressed in this frame
+		if slot.active && touch_events[this.slot] == nil {
+			evt := this.evNewTouchEvent(gopi.INPUT_EVENT_TOUCHPOSITION)
+			touch_events[this.slot] = evt
+			return []*input_event{evt}
+		}
+	default:
 		this.log.Warn("evDecodeAbs: %v Ignoring code %v", raw_event.Type, raw_event.Code)
 	}
 	return nil
 }
 
-func (this *device) evDecodeAbsTouch(raw_event *evEvent) gopi.InputEvent {
-	evt := &input_event{
-		source:    this,
-		timestamp: time.Duration(time.Duration(raw_event.Second)*time.Second + time.Duration(raw_event.Microsecond)*time.Microsecond),
-		device:    this.device_type,
-		device_id: this.device_id,
+// evDecodeAbsTouch decodes a change of tracking ID for the current slot. A
+// tracking ID of -1 releases the slot, and any other value presses the slot.
+// If the slot is re-used with a new tracking ID then it is released first
+func (this *device) evDecodeAbsTouch(raw_event *evEvent, touch_events map[uint32]*input_event) []*input_event {
+	events := make([]*input_event, 0, 2)
+	slot := &this.slots[this.slot]
+	tracking_id := int32(raw_event.Value)
+
+	if slot.active && tracking_id != slot.id {
+		evt := this.evNewTouchEvent(gopi.INPUT_EVENT_TOUCHRELEASE)
+		evt.position = slot.position
+		slot.active = false
+		touch_events[this.slot] = evt
+		events = append(events, evt)
 	}
-
-	// Decode the slot_id, if -1 then this is the release for a slot
-	if slot_id := int16(raw_event.Value); slot_id == -1 {
-		this.slots[this.slot].active = false
-		evt.event = gopi.INPUT_EVENT_TOUCHRELEASE
-	} else if slot_id < INPUT_MAX_MULTITOUCH_SLOTS {
-		this.slots[this.slot].active = true
-		this.slots[this.slot].id = slot_id
-		evt.event = gopi.INPUT_EVENT_TOUCHPRESS
-	} else {
-		this.log.Warn("evDecodeAbsTouch: %v Ignoring slot %v", raw_event.Type, slot_id)
+	if tracking_id != -1 && slot.active == false {
+		evt := this.evNewTouchEvent(gopi.INPUT_EVENT_TOUCHPRESS)
+		slot.active = true
+		slot.id = tracking_id
+		touch_events[this.slot] = evt
+		events = append(events, evt)
 	}
 
-	// Populate the slot and keycode
-	evt.slot = uint(this.slot)
-	evt.key_code = gopi.KEYCODE_BTNTOUCH
-
-	// Return the event to emit
-	return evt
+	return events
 }
 
-func (this *device) evDecodeRel(raw_event *evEvent) {
+func (this *device) evDecodeRel(raw_event *evEvent, evt *input_event) {
 	switch raw_event.Code {
 	case EV_CODE_X:
-		this.rel_position.X = float32(int32(raw_event.Value))
+		evt.rel_position.X += float32(int32(raw_event.Value))
 	case EV_CODE_Y:
-		this.rel_position.Y = float32(int32(raw_event.Value))
+		evt.rel_position.Y += float32(int32(raw_event.Value))
 	default:
 		this.log.Warn("evDecodeRel: %v Ignoring code %v", raw_event.Type, raw_event.Code)
 	}
 }
 
+// evDecodeScroll accumulates vertical and horizontal scroll in detents.
+// When the device reports high resolution scroll, the low resolution
+// codes are ignored, since both are reported for the same movement
+func (this *device) evDecodeScroll(raw_event *evEvent, evt *input_event) {
+	value := float32(int32(raw_event.Value))
+	switch raw_event.Code {
+	case EV_CODE_WHEEL:
+		if this.scroll_hi_res == false {
+			evt.scroll.Y += value
+		}
+	case EV_CODE_HWHEEL:
+		if this.scroll_hi_res == false {
+			evt.scroll.X += value
+		}
+	case EV_CODE_WHEEL_HI_RES:
+		evt.scroll.Y += value / EV_SCROLL_HI_RES_DETENT
+	case EV_CODE_HWHEEL_HI_RES:
+		evt.scroll.X += value / EV_SCROLL_HI_RES_DETENT
+	}
+}
+
 func (this *device) evDecodeMsc(raw_event *evEvent) {
 	switch raw_event.Code {
 	case EV_CODE_SCANCODE:
 		this.scan_code = raw_event.Value
+	case EV_CODE_SERIAL:
+		// Ignore the serial number of tablet tools
 	default:
 		this.log.Warn("evDecodeMsc: %v Ignoring code=%v, value=%v", raw_event.Type, raw_event.Code, raw_event.Value)
 	}
 }
 
+// evTransformAbs returns an absolute position after calibration
+// and scaling, given the axis codes for the position
+func (this *device) evTransformAbs(raw gopi.Point, x, y evKeyCode) gopi.Point {
+	pt := this.calibration.Transform(raw)
+	return gopi.Point{X: this.evScaleAbs(x, pt.X), Y: this.evScaleAbs(y, pt.Y)}
+}
+
+// evScaleAbs returns an absolute position value, normalised or scaled
+// using the range of the axis. Values are returned unscaled if the
+// range of the axis is not known
+func (this *device) evScaleAbs(code evKeyCode, value float32) float32 {
+	info, exists := this.abs_info[code]
+	if this.position_mode == POSITION_RAW || exists == false || info.Maximum <= info.Minimum {
+		return value
+	}
+	normalised := (value - float32(info.Minimum)) / float32(info.Maximum-info.Minimum)
+	switch {
+	case this.position_mode == POSITION_SCALED && (code == EV_CODE_X || code == EV_CODE_SLOT_X):
+		return normalised * this.size.W
+	case this.position_mode == POSITION_SCALED && (code == EV_CODE_Y || code == EV_CODE_SLOT_Y):
+		return normalised * this.size.H
+	default:
+		return normalised
+	}
+}
+
+// evNewTouchEvent returns a new multi-touch event for the current slot
+func (this *device) evNewTouchEvent(event_type gopi.InputEventType) *input_event {
+	evt := this.evNewEvent(event_type)
+	evt.slot = uint(this.slot)
+	if event_type != gopi.INPUT_EVENT_TOUCHPOSITION {
+		evt.key_code = gopi.KEYCODE_BTNTOUCH
+	}
+	return evt
+}
+
+// evNewEvent returns a new event for this device
+func (this *device) evNewEvent(event_type gopi.InputEventType) *input_event {
+	return &input_event{
+		source:    this,
+		device:    this.device_type,
+		device_id: this.device_id,
+		event:     event_type,
+		key_state: this.key_state,
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
@@ -336,6 +572,70 @@ func evFind(callback func(string)) error {
 	return nil
 }
 
+// evTimestamp returns the timestamp of a raw event on the monotonic
+// clock, converting from the realtime clock when the clock for the
+// device could not be set
+func (this *device) evTimestamp(raw_event *evEvent) time.Duration {
+	ts := time.Duration(raw_event.Second)*time.Second + time.Duration(raw_event.Microsecond)*time.Microsecond
+	if this.clock_realtime {
+		ts -= clockRealtimeOffset()
+	}
+	return ts
+}
+
+// evModifierKeyState returns the key state for a modifier key
+// (shift, control, alt and meta) or KEYSTATE_NONE otherwise
+func evModifierKeyState(key_code gopi.KeyCode) gopi.KeyState {
+	switch key_code {
+	case gopi.KEYCODE_LEFTSHIFT:
+		return gopi.KEYSTATE_LEFTSHIFT
+	case gopi.KEYCODE_RIGHTSHIFT:
+		return gopi.KEYSTATE_RIGHTSHIFT
+	case gopi.KEYCODE_LEFTCTRL:
+		return gopi.KEYSTATE_LEFTCTRL
+	case gopi.KEYCODE_RIGHTCTRL:
+		return gopi.KEYSTATE_RIGHTCTRL
+	case gopi.KEYCODE_LEFTALT:
+		return gopi.KEYSTATE_LEFTALT
+	case gopi.KEYCODE_RIGHTALT:
+		return gopi.KEYSTATE_RIGHTALT
+	case gopi.KEYCODE_LEFTMETA:
+		return gopi.KEYSTATE_LEFTMETA
+	case gopi.KEYCODE_RIGHTMETA:
+		return gopi.KEYSTATE_RIGHTMETA
+	default:
+		return gopi.KEYSTATE_NONE
+	}
+}
+
+// isSet returns true if a key is pressed
+func (bits *evKeyBitmap) isSet(code evKeyCode) bool {
+	if code > EV_KEY_MAX {
+		return false
+	}
+	return bits[code>>3]&(1<<(code&0x07)) != 0
+}
+
+// set marks a key as pressed or released
+func (bits *evKeyBitmap) set(code evKeyCode, state bool) {
+	if code > EV_KEY_MAX {
+		return
+	} else if state {
+		bits[code>>3] |= 1 << (code & 0x07)
+	} else {
+		bits[code>>3] &^= 1 << (code & 0x07)
+	}
+}
+
+// evBitIsSet returns true if a bit is set in a bitmap returned
+// from the device
+func evBitIsSet(bits []byte, code evKeyCode) bool {
+	if int(code>>3) >= len(bits) {
+		return false
+	}
+	return bits[code>>3]&(1<<(code&0x07)) != 0
+}
+
 // evSupportsEventType returns true if all event types are supported
 // else returns false
 func evSupportsEventType(capabilities []evType, types ...evType) bool {
@@ -0,0 +1,799 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"testing"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST LOGGER
+
+type testLogger struct {
+	t *testing.T
+}
+
+func (this *testLogger) Close() error { return nil }
+func (this *testLogger) Fatal(format string, v ...interface{}) error {
+	this.t.Logf(format, v...)
+	return nil
+}
+func (this *testLogger) Error(format string, v ...interface{}) error {
+	this.t.Logf(format, v...)
+	return nil
+}
+func (this *testLogger) Warn(format string, v ...interface{})   { this.t.Logf(format, v...) }
+func (this *testLogger) Info(format string, v ...interface{})   { this.t.Logf(format, v...) }
+func (this *testLogger) Debug(format string, v ...interface{})  { this.t.Logf(format, v...) }
+func (this *testLogger) Debug2(format string, v ...interface{}) {}
+func (this *testLogger) IsDebug() bool                          { return true }
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST DECODE
+
+type testEvent struct {
+	event    gopi.InputEventType
+	key_code gopi.KeyCode
+	position gopi.Point
+	relative gopi.Point
+	slot     uint
+}
+
+var (
+	syn_report    = evEvent{Type: EV_SYN, Code: EV_CODE_SYN_REPORT}
+	syn_mt_report = evEvent{Type: EV_SYN, Code: EV_CODE_SYN_MT_REPORT}
+)
+
+func testDevice(t *testing.T, device_type gopi.InputDeviceType) *device {
+	this := new(device)
+	this.log = &testLogger{t}
+	this.device_type = device_type
+	this.slots = make([]slot, INPUT_MAX_MULTITOUCH_SLOTS)
+	this.calibration = IdentityCalibration
+	return this
+}
+
+func testDecode(this *device, raw_events []evEvent) []gopi.InputEvent {
+	events := make([]gopi.InputEvent, 0)
+	for i := range raw_events {
+		events = append(events, this.evDecode(&raw_events[i])...)
+	}
+	return events
+}
+
+func testKey(code evKeyCode, value uint32) evEvent {
+	return evEvent{Type: EV_KEY, Code: code, Value: value}
+}
+
+func testAbs(code evKeyCode, value int32) evEvent {
+	return evEvent{Type: EV_ABS, Code: code, Value: uint32(value)}
+}
+
+// testFrame decodes raw events followed by a SYN_REPORT with a
+// timestamp in milliseconds
+func testFrame(this *device, ms uint32, raw_events ...[]evEvent) []gopi.InputEvent {
+	frame := make([]evEvent, 0)
+	for _, raw_event := range raw_events {
+		frame = append(frame, raw_event...)
+	}
+	return testDecode(this, append(frame, evEvent{Type: EV_SYN, Code: EV_CODE_SYN_REPORT, Second: ms / 1000, Microsecond: (ms % 1000) * 1000}))
+}
+
+// testExpect checks the types of decoded events
+func testExpect(t *testing.T, name string, events []gopi.InputEvent, expected ...gopi.InputEventType) {
+	if len(events) != len(expected) {
+		t.Errorf("%v: Expected %v events, got %v", name, len(expected), events)
+		return
+	}
+	for i, evt := range events {
+		if evt.EventType() != expected[i] {
+			t.Errorf("%v: Expected %v, got %v", name, expected[i], evt)
+		}
+	}
+}
+
+func TestDecodeFrame_000(t *testing.T) {
+	tests := []struct {
+		name        string
+		device_type gopi.InputDeviceType
+		raw_events  []evEvent
+		events      []testEvent
+	}{
+		{"empty frame", gopi.INPUT_TYPE_KEYBOARD, []evEvent{
+			syn_report,
+		}, []testEvent{}},
+		{"no report", gopi.INPUT_TYPE_KEYBOARD, []evEvent{
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_A), Value: 1},
+		}, []testEvent{}},
+		{"key press", gopi.INPUT_TYPE_KEYBOARD, []evEvent{
+			{Type: EV_MSC, Code: EV_CODE_SCANCODE, Value: 0x70004},
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_A), Value: 1},
+			syn_report,
+		}, []testEvent{
+			{event: gopi.INPUT_EVENT_KEYPRESS, key_code: gopi.KEYCODE_A},
+		}},
+		{"chord", gopi.INPUT_TYPE_KEYBOARD, []evEvent{
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_LEFTCTRL), Value: 1},
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_LEFTALT), Value: 1},
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_DELETE), Value: 1},
+			syn_report,
+		}, []testEvent{
+			{event: gopi.INPUT_EVENT_KEYPRESS, key_code: gopi.KEYCODE_LEFTCTRL},
+			{event: gopi.INPUT_EVENT_KEYPRESS, key_code: gopi.KEYCODE_LEFTALT},
+			{event: gopi.INPUT_EVENT_KEYPRESS, key_code: gopi.KEYCODE_DELETE},
+		}},
+		{"scanner burst", gopi.INPUT_TYPE_KEYBOARD, []evEvent{
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_1), Value: 1},
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_1), Value: 0},
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_2), Value: 1},
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_2), Value: 0},
+			syn_report,
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_ENTER), Value: 1},
+			syn_report,
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_ENTER), Value: 2},
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_ENTER), Value: 0},
+			syn_report,
+		}, []testEvent{
+			{event: gopi.INPUT_EVENT_KEYPRESS, key_code: gopi.KEYCODE_1},
+			{event: gopi.INPUT_EVENT_KEYRELEASE, key_code: gopi.KEYCODE_1},
+			{event: gopi.INPUT_EVENT_KEYPRESS, key_code: gopi.KEYCODE_2},
+			{event: gopi.INPUT_EVENT_KEYRELEASE, key_code: gopi.KEYCODE_2},
+			{event: gopi.INPUT_EVENT_KEYPRESS, key_code: gopi.KEYCODE_ENTER},
+			{event: gopi.INPUT_EVENT_KEYREPEAT, key_code: gopi.KEYCODE_ENTER},
+			{event: gopi.INPUT_EVENT_KEYRELEASE, key_code: gopi.KEYCODE_ENTER},
+		}},
+		{"mouse move and click", gopi.INPUT_TYPE_MOUSE, []evEvent{
+			{Type: EV_REL, Code: EV_CODE_X, Value: 5},
+			{Type: EV_REL, Code: EV_CODE_Y, Value: uint32(0xFFFFFFFE)},
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_BTNLEFT), Value: 1},
+			syn_report,
+			{Type: EV_REL, Code: EV_CODE_X, Value: 1},
+			syn_report,
+		}, []testEvent{
+			{event: gopi.INPUT_EVENT_RELPOSITION, relative: gopi.Point{X: 5, Y: -2}, position: gopi.Point{X: 5, Y: -2}},
+			{event: gopi.INPUT_EVENT_KEYPRESS, key_code: gopi.KEYCODE_BTNLEFT, position: gopi.Point{X: 5, Y: -2}},
+			{event: gopi.INPUT_EVENT_RELPOSITION, relative: gopi.Point{X: 1, Y: 0}, position: gopi.Point{X: 6, Y: -2}},
+		}},
+		{"touch press with position", gopi.INPUT_TYPE_TOUCHSCREEN, []evEvent{
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_BTNTOUCH), Value: 1},
+			{Type: EV_ABS, Code: EV_CODE_X, Value: 100},
+			{Type: EV_ABS, Code: EV_CODE_Y, Value: 200},
+			syn_report,
+			{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_BTNTOUCH), Value: 0},
+			syn_report,
+		}, []testEvent{
+			{event: gopi.INPUT_EVENT_KEYPRESS, key_code: gopi.KEYCODE_BTNTOUCH, position: gopi.Point{X: 100, Y: 200}},
+			{event: gopi.INPUT_EVENT_ABSPOSITION, position: gopi.Point{X: 100, Y: 200}},
+			{event: gopi.INPUT_EVENT_KEYRELEASE, key_code: gopi.KEYCODE_BTNTOUCH, position: gopi.Point{X: 100, Y: 200}},
+		}},
+		{"two finger touch", gopi.INPUT_TYPE_TOUCHSCREEN, []evEvent{
+			{Type: EV_ABS, Code: EV_CODE_SLOT, Value: 0},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: 100},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 10},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: 20},
+			{Type: EV_ABS, Code: EV_CODE_SLOT, Value: 1},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: 101},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 30},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: 40},
+			syn_report,
+			{Type: EV_ABS, Code: EV_CODE_SLOT, Value: 0},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 11},
+			{Type: EV_ABS, Code: EV_CODE_SLOT, Value: 1},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 31},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: 41},
+			syn_report,
+			{Type: EV_ABS, Code: EV_CODE_SLOT, Value: 0},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: uint32(0xFFFFFFFF)},
+			syn_report,
+		}, []testEvent{
+			{event: gopi.INPUT_EVENT_TOUCHPRESS, key_code: gopi.KEYCODE_BTNTOUCH, slot: 0, position: gopi.Point{X: 10, Y: 20}},
+			{event: gopi.INPUT_EVENT_TOUCHPRESS, key_code: gopi.KEYCODE_BTNTOUCH, slot: 1, position: gopi.Point{X: 30, Y: 40}},
+			{event: gopi.INPUT_EVENT_TOUCHPOSITION, slot: 0, position: gopi.Point{X: 11, Y: 20}},
+			{event: gopi.INPUT_EVENT_TOUCHPOSITION, slot: 1, position: gopi.Point{X: 31, Y: 41}},
+			{event: gopi.INPUT_EVENT_TOUCHRELEASE, key_code: gopi.KEYCODE_BTNTOUCH, slot: 0, position: gopi.Point{X: 11, Y: 20}},
+		}},
+		{"slot reused with new tracking id", gopi.INPUT_TYPE_TOUCHSCREEN, []evEvent{
+			{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: 5},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 10},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: 10},
+			syn_report,
+			{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: 6},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 50},
+			syn_report,
+		}, []testEvent{
+			{event: gopi.INPUT_EVENT_TOUCHPRESS, key_code: gopi.KEYCODE_BTNTOUCH, position: gopi.Point{X: 10, Y: 10}},
+			{event: gopi.INPUT_EVENT_TOUCHRELEASE, key_code: gopi.KEYCODE_BTNTOUCH, position: gopi.Point{X: 10, Y: 10}},
+			{event: gopi.INPUT_EVENT_TOUCHPRESS, key_code: gopi.KEYCODE_BTNTOUCH, position: gopi.Point{X: 50, Y: 10}},
+		}},
+		{"protocol a contacts", gopi.INPUT_TYPE_TOUCHSCREEN, []evEvent{
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 10},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: 20},
+			syn_mt_report,
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 300},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: 400},
+			syn_mt_report,
+			syn_report,
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 305},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: 405},
+			syn_mt_report,
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 12},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: 20},
+			syn_mt_report,
+			syn_report,
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 306},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: 405},
+			syn_mt_report,
+			syn_report,
+			syn_mt_report,
+			syn_report,
+		}, []testEvent{
+			{event: gopi.INPUT_EVENT_TOUCHPRESS, key_code: gopi.KEYCODE_BTNTOUCH, slot: 0, position: gopi.Point{X: 10, Y: 20}},
+			{event: gopi.INPUT_EVENT_TOUCHPRESS, key_code: gopi.KEYCODE_BTNTOUCH, slot: 1, position: gopi.Point{X: 300, Y: 400}},
+			{event: gopi.INPUT_EVENT_TOUCHPOSITION, slot: 1, position: gopi.Point{X: 305, Y: 405}},
+			{event: gopi.INPUT_EVENT_TOUCHPOSITION, slot: 0, position: gopi.Point{X: 12, Y: 20}},
+			{event: gopi.INPUT_EVENT_TOUCHRELEASE, key_code: gopi.KEYCODE_BTNTOUCH, slot: 0, position: gopi.Point{X: 12, Y: 20}},
+			{event: gopi.INPUT_EVENT_TOUCHPOSITION, slot: 1, position: gopi.Point{X: 306, Y: 405}},
+			{event: gopi.INPUT_EVENT_TOUCHRELEASE, key_code: gopi.KEYCODE_BTNTOUCH, slot: 1, position: gopi.Point{X: 306, Y: 405}},
+		}},
+	}
+
+	for _, test := range tests {
+		this := testDevice(t, test.device_type)
+		events := testDecode(this, test.raw_events)
+		if len(events) != len(test.events) {
+			t.Errorf("%v: expected %v events, got %v: %v", test.name, len(test.events), len(events), events)
+			continue
+		}
+		for i, evt := range events {
+			expected := test.events[i]
+			if evt.EventType() != expected.event {
+				t.Errorf("%v: event %v: expected %v, got %v", test.name, i, expected.event, evt.EventType())
+			}
+			if evt.KeyCode() != expected.key_code {
+				t.Errorf("%v: event %v: expected key code %v, got %v", test.name, i, expected.key_code, evt.KeyCode())
+			}
+			if evt.Position().Equals(expected.position) == false {
+				t.Errorf("%v: event %v: expected position %v, got %v", test.name, i, expected.position, evt.Position())
+			}
+			if evt.Relative().Equals(expected.relative) == false {
+				t.Errorf("%v: event %v: expected relative %v, got %v", test.name, i, expected.relative, evt.Relative())
+			}
+			if evt.Slot() != expected.slot {
+				t.Errorf("%v: event %v: expected slot %v, got %v", test.name, i, expected.slot, evt.Slot())
+			}
+		}
+	}
+}
+
+func TestDecodeKeyState_000(t *testing.T) {
+	this := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	events := testDecode(this, []evEvent{
+		{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_LEFTSHIFT), Value: 1},
+		{Type: EV_MSC, Code: EV_CODE_SCANCODE, Value: 0x70004},
+		{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_A), Value: 1},
+		{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_LEFTSHIFT), Value: 0},
+		{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_A), Value: 0},
+		syn_report,
+	})
+	if len(events) != 4 {
+		t.Fatalf("Expected 4 events, got %v", len(events))
+	}
+	if events[1].KeyState() != gopi.KEYSTATE_LEFTSHIFT {
+		t.Errorf("Expected shifted key press, got %v", events[1].KeyState())
+	}
+	if events[1].ScanCode() != 0x70004 {
+		t.Errorf("Expected scan code on key press, got 0x%08X", events[1].ScanCode())
+	}
+	if events[3].KeyState() != gopi.KEYSTATE_NONE {
+		t.Errorf("Expected unshifted key release, got %v", events[3].KeyState())
+	}
+	if events[3].ScanCode() != 0 {
+		t.Errorf("Expected no scan code on key release, got 0x%08X", events[3].ScanCode())
+	}
+	if this.KeyState() != gopi.KEYSTATE_NONE {
+		t.Errorf("Expected no key state, got %v", this.KeyState())
+	}
+}
+
+func TestDecodeKeyState_001(t *testing.T) {
+	// The pressed keys and lock states are set from the key and LED
+	// state of the device when it's opened
+	this := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	var keys evKeyBitmap
+	keys.set(evKeyCode(gopi.KEYCODE_LEFTSHIFT), true)
+	keys.set(evKeyCode(gopi.KEYCODE_A), true)
+	this.evSetPressedKeys(keys[:])
+	this.evSetLockState([]evLEDState{EV_LED_CAPSL})
+	if this.KeyState() != gopi.KEYSTATE_CAPSLOCK|gopi.KEYSTATE_LEFTSHIFT {
+		t.Errorf("Expected Caps Lock and Shift, got %v", this.KeyState())
+	}
+	if pressed := this.PressedKeys(); len(pressed) != 2 || pressed[0] != gopi.KEYCODE_A || pressed[1] != gopi.KEYCODE_LEFTSHIFT {
+		t.Errorf("Unexpected pressed keys: %v", pressed)
+	}
+
+	// Events have the key state, and the lock state is cleared when
+	// the LED is off
+	events := testDecode(this, []evEvent{testKey(evKeyCode(gopi.KEYCODE_A), 0), syn_report})
+	if len(events) != 1 || events[0].KeyState() != gopi.KEYSTATE_CAPSLOCK|gopi.KEYSTATE_LEFTSHIFT {
+		t.Errorf("Unexpected events: %v", events)
+	}
+	this.evSetLockState(nil)
+	if this.KeyState() != gopi.KEYSTATE_LEFTSHIFT {
+		t.Errorf("Expected Shift, got %v", this.KeyState())
+	}
+}
+
+func TestDecodeDropped_000(t *testing.T) {
+	this := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	testExpect(t, "press", testDecode(this, []evEvent{
+		testKey(evKeyCode(gopi.KEYCODE_A), 1), syn_report,
+	}), gopi.INPUT_EVENT_KEYPRESS)
+
+	// The partial frame before SYN_DROPPED and the events up to the next
+	// SYN_REPORT are discarded. The device has no handle, so no state is
+	// read when resyncing
+	testExpect(t, "dropped", testDecode(this, []evEvent{
+		testKey(evKeyCode(gopi.KEYCODE_B), 1),
+		{Type: EV_SYN, Code: EV_CODE_SYN_DROPPED},
+		testKey(evKeyCode(gopi.KEYCODE_C), 1),
+		testKey(evKeyCode(gopi.KEYCODE_A), 0),
+		syn_report,
+	}))
+	if this.dropped {
+		t.Error("Expected resync on SYN_REPORT")
+	}
+	if keys := this.PressedKeys(); len(keys) != 1 || keys[0] != gopi.KEYCODE_A {
+		t.Errorf("Expected discarded events not to change pressed keys, got %v", keys)
+	}
+
+	// Resyncing emits events for the keys which changed state while
+	// events were dropped
+	var keys evKeyBitmap
+	keys.set(evKeyCode(gopi.KEYCODE_C), true)
+	this.frame = this.evResyncKeys(keys[:])
+	events := this.evDecodeFrame(0)
+	this.frame = this.frame[:0]
+	testExpect(t, "resync", events, gopi.INPUT_EVENT_KEYRELEASE, gopi.INPUT_EVENT_KEYPRESS)
+	if len(events) == 2 && (events[0].KeyCode() != gopi.KEYCODE_A || events[1].KeyCode() != gopi.KEYCODE_C) {
+		t.Errorf("Unexpected resync events: %v", events)
+	}
+
+	// Frames are decoded after resyncing
+	events = testDecode(this, []evEvent{testKey(evKeyCode(gopi.KEYCODE_C), 0), syn_report})
+	testExpect(t, "release", events, gopi.INPUT_EVENT_KEYRELEASE)
+	if len(this.PressedKeys()) != 0 {
+		t.Errorf("Unexpected pressed keys: %v", this.PressedKeys())
+	}
+}
+
+func TestScaleAbs_000(t *testing.T) {
+	this := testDevice(t, gopi.INPUT_TYPE_TOUCHSCREEN)
+	this.abs_info = map[evKeyCode]evAbsInfo{
+		EV_CODE_SLOT_X: {Minimum: 0, Maximum: 4095},
+		EV_CODE_SLOT_Y: {Minimum: 100, Maximum: 1100},
+	}
+	tests := []struct {
+		mode     PositionMode
+		size     gopi.Size
+		position gopi.Point
+	}{
+		{POSITION_RAW, gopi.ZeroSize, gopi.Point{X: 4095, Y: 600}},
+		{POSITION_NORMALISED, gopi.ZeroSize, gopi.Point{X: 1, Y: 0.5}},
+		{POSITION_SCALED, gopi.Size{W: 800, H: 480}, gopi.Point{X: 800, Y: 240}},
+	}
+	for _, test := range tests {
+		if err := this.SetPositionMode(test.mode, test.size); err != nil {
+			t.Fatalf("%v: %v", test.mode, err)
+		}
+		position := gopi.Point{
+			X: this.evScaleAbs(EV_CODE_SLOT_X, 4095),
+			Y: this.evScaleAbs(EV_CODE_SLOT_Y, 600),
+		}
+		if position != test.position {
+			t.Errorf("%v: expected position %v, got %v", test.mode, test.position, position)
+		}
+	}
+	if err := this.SetPositionMode(POSITION_SCALED, gopi.ZeroSize); err != gopi.ErrBadParameter {
+		t.Errorf("Expected error when scaling to zero size, got %v", err)
+	}
+}
+
+func TestDecodeScroll_000(t *testing.T) {
+	tests := []struct {
+		name       string
+		hi_res     bool
+		raw_events []evEvent
+		scroll     []gopi.Point
+	}{
+		{"wheel", false, []evEvent{
+			{Type: EV_REL, Code: EV_CODE_WHEEL, Value: 1},
+			syn_report,
+			{Type: EV_REL, Code: EV_CODE_WHEEL, Value: 0xFFFFFFFE},
+			{Type: EV_REL, Code: EV_CODE_HWHEEL, Value: 1},
+			syn_report,
+		}, []gopi.Point{{X: 0, Y: 1}, {X: 1, Y: -2}}},
+		{"hi res wheel", true, []evEvent{
+			{Type: EV_REL, Code: EV_CODE_WHEEL_HI_RES, Value: 60},
+			syn_report,
+			{Type: EV_REL, Code: EV_CODE_WHEEL, Value: 1},
+			{Type: EV_REL, Code: EV_CODE_WHEEL_HI_RES, Value: 60},
+			syn_report,
+			{Type: EV_REL, Code: EV_CODE_HWHEEL, Value: 0xFFFFFFFF},
+			syn_report,
+		}, []gopi.Point{{X: 0, Y: 0.5}, {X: 0, Y: 0.5}}},
+	}
+	for _, test := range tests {
+		this := testDevice(t, gopi.INPUT_TYPE_MOUSE)
+		this.scroll_hi_res = test.hi_res
+		events := testDecode(this, test.raw_events)
+		if len(events) != len(test.scroll) {
+			t.Errorf("%v: expected %v events, got %v", test.name, len(test.scroll), len(events))
+			continue
+		}
+		for i, evt := range events {
+			if evt.EventType() != INPUT_EVENT_SCROLL {
+				t.Errorf("%v: event %v: expected INPUT_EVENT_SCROLL, got %v", test.name, i, evt.EventType())
+			} else if scroll := evt.(ScrollEvent).Scroll(); scroll != test.scroll[i] {
+				t.Errorf("%v: event %v: expected scroll %v, got %v", test.name, i, test.scroll[i], scroll)
+			}
+		}
+	}
+}
+
+func TestDecodeJoystick_000(t *testing.T) {
+	this := testDevice(t, gopi.INPUT_TYPE_JOYSTICK)
+	this.abs_info = map[evKeyCode]evAbsInfo{
+		EV_CODE_X:         {Minimum: -32768, Maximum: 32767, Fuzz: 16, Flat: 4096},
+		EV_CODE_Z:         {Minimum: 0, Maximum: 255},
+		EV_CODE_HAT0X:     {Minimum: -1, Maximum: 1},
+		EV_CODE_HAT0X + 1: {Minimum: -1, Maximum: 1},
+	}
+	this.evInitJoystick(0)
+
+	// Movement within the dead zone or fuzz is ignored
+	if events := testDecode(this, []evEvent{{Type: EV_ABS, Code: EV_CODE_X, Value: 2000}, syn_report}); len(events) != 0 {
+		t.Errorf("Expected no events within dead zone, got %v", events)
+	}
+	if events := testDecode(this, []evEvent{{Type: EV_ABS, Code: EV_CODE_Z, Value: 0}, syn_report}); len(events) != 0 {
+		t.Errorf("Expected no events for unchanged trigger, got %v", events)
+	}
+
+	// Stick and trigger
+	events := testDecode(this, []evEvent{
+		{Type: EV_ABS, Code: EV_CODE_X, Value: uint32(0xFFFFFFFF - 32767)},
+		{Type: EV_ABS, Code: EV_CODE_Z, Value: 255},
+		syn_report,
+	})
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %v", len(events))
+	}
+	if evt := events[0].(AxisEvent); evt.EventType() != INPUT_EVENT_AXIS || evt.Axis() != ABS_X || evt.Value() != -1 {
+		t.Errorf("Unexpected stick event: %v", evt)
+	}
+	if evt := events[1].(AxisEvent); evt.EventType() != INPUT_EVENT_AXIS || evt.Axis() != ABS_Z || evt.Value() != 1 {
+		t.Errorf("Unexpected trigger event: %v", evt)
+	}
+	if this.AxisValue(ABS_X) != -1 || this.AxisValue(ABS_Z) != 1 {
+		t.Errorf("Unexpected axis values: %v, %v", this.AxisValue(ABS_X), this.AxisValue(ABS_Z))
+	}
+
+	// Hat moved diagonally in one frame, then centred
+	events = testDecode(this, []evEvent{
+		{Type: EV_ABS, Code: EV_CODE_HAT0X, Value: 1},
+		{Type: EV_ABS, Code: EV_CODE_HAT0X + 1, Value: 0xFFFFFFFF},
+		syn_report,
+		{Type: EV_ABS, Code: EV_CODE_HAT0X, Value: 0},
+		{Type: EV_ABS, Code: EV_CODE_HAT0X + 1, Value: 0},
+		syn_report,
+	})
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %v", len(events))
+	}
+	if evt := events[0].(HatEvent); evt.EventType() != INPUT_EVENT_HAT || evt.Hat() != 0 || evt.Direction() != HAT_UP|HAT_RIGHT {
+		t.Errorf("Unexpected hat event: %v", evt)
+	}
+	if evt := events[1].(HatEvent); evt.Direction() != HAT_CENTRE {
+		t.Errorf("Unexpected hat event: %v", evt)
+	}
+
+	// Dead zone
+	if err := this.SetDeadZone(ABS_Z, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	events = testDecode(this, []evEvent{{Type: EV_ABS, Code: EV_CODE_Z, Value: 64}, syn_report})
+	if len(events) != 1 || events[0].(AxisEvent).Value() != 0 {
+		t.Errorf("Expected trigger within dead zone, got %v", events)
+	}
+	if err := this.SetDeadZone(ABS_RX, 0.5); err != gopi.ErrBadParameter {
+		t.Errorf("Expected error for unsupported axis, got %v", err)
+	}
+}
+
+func TestDecodeEffectStatus_000(t *testing.T) {
+	this := testDevice(t, gopi.INPUT_TYPE_JOYSTICK)
+	events := testDecode(this, []evEvent{
+		{Type: EV_FF, Code: 3, Value: 1},
+		{Type: EV_FF_STATUS, Code: 3, Value: EV_VALUE_FF_STATUS_PLAYING},
+		{Type: EV_FF_STATUS, Code: 3, Value: EV_VALUE_FF_STATUS_STOPPED},
+	})
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %v", len(events))
+	}
+	for i, status := range []EffectStatus{EFFECT_STATUS_PLAYING, EFFECT_STATUS_STOPPED} {
+		if evt := events[i].(EffectEvent); evt.EventType() != INPUT_EVENT_EFFECT || evt.Effect() != 3 || evt.EffectStatus() != status {
+			t.Errorf("Unexpected effect event: %v", evt)
+		}
+	}
+}
+
+func TestSoftwareRepeat_000(t *testing.T) {
+	this := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	this.repeat = KeyRepeat{Mode: REPEAT_SOFTWARE, Delay: 20 * time.Millisecond, Period: 10 * time.Millisecond}
+	subscriber := this.Subscribe()
+	defer func() {
+		// Drain any repeat emitted before the release
+		go func() {
+			for range subscriber {
+			}
+		}()
+		this.Publisher.Close()
+	}()
+
+	// Kernel repeats are ignored
+	events := testDecode(this, []evEvent{
+		{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_A), Value: 1},
+		syn_report,
+		{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_A), Value: 2},
+		syn_report,
+	})
+	if len(events) != 1 || events[0].(gopi.InputEvent).EventType() != gopi.INPUT_EVENT_KEYPRESS {
+		t.Fatalf("Expected key press, got %v", events)
+	}
+
+	// Wait for two software repeats
+	for i := 0; i < 2; i++ {
+		select {
+		case evt := <-subscriber:
+			if evt := evt.(gopi.InputEvent); evt.EventType() != gopi.INPUT_EVENT_KEYREPEAT || evt.KeyCode() != gopi.KEYCODE_A {
+				t.Errorf("Expected key repeat, got %v", evt)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for key repeat")
+		}
+	}
+
+	// Releasing the key stops the repeat
+	testDecode(this, []evEvent{
+		{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_A), Value: 0},
+		syn_report,
+	})
+	this.soft_repeat.Lock()
+	timer := this.soft_repeat.timer
+	this.soft_repeat.Unlock()
+	if timer != nil {
+		t.Error("Expected key repeat to stop on release")
+	}
+}
+
+func TestDecodeSwitch_000(t *testing.T) {
+	this := testDevice(t, INPUT_TYPE_SWITCH)
+	events := testDecode(this, []evEvent{
+		{Type: EV_SW, Code: evKeyCode(SW_LID), Value: 1},
+		{Type: EV_SW, Code: evKeyCode(SW_HEADPHONE_INSERT), Value: 0},
+		syn_report,
+		{Type: EV_SW, Code: evKeyCode(SW_HEADPHONE_INSERT), Value: 1},
+		syn_report,
+		{Type: EV_SW, Code: evKeyCode(SW_LID), Value: 0},
+		syn_report,
+	})
+	expected := []struct {
+		code  SwitchCode
+		state bool
+	}{
+		{SW_LID, true}, {SW_HEADPHONE_INSERT, true}, {SW_LID, false},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %v events, got %v", len(expected), len(events))
+	}
+	for i, evt := range events {
+		if evt := evt.(SwitchEvent); evt.EventType() != INPUT_EVENT_SWITCH || evt.Switch() != expected[i].code || evt.SwitchState() != expected[i].state {
+			t.Errorf("Unexpected switch event: %v", evt)
+		}
+	}
+	if switches := this.Switches(); len(switches) != 1 || switches[0] != SW_HEADPHONE_INSERT {
+		t.Errorf("Unexpected switches: %v", switches)
+	}
+}
+
+func TestDecodeCapabilities_000(t *testing.T) {
+	var keys, ff evKeyBitmap
+	keys.set(evKeyCode(gopi.KEYCODE_A), true)
+	keys.set(evKeyCode(gopi.KEYCODE_BTNLEFT), true)
+	ff.set(evKeyCode(EFFECT_RUMBLE), true)
+	ff.set(evKeyCode(EFFECT_WAVEFORM_SINE), true)
+	bits := map[evType][]byte{
+		EV_KEY: keys[:],
+		EV_REL: []byte{0x03, 0x09},
+		EV_SW:  []byte{0x01},
+		EV_LED: []byte{0x07},
+		EV_FF:  ff[:],
+	}
+	caps := evDecodeCapabilities(bits, []byte{0x42})
+
+	if len(caps.Keys) != 2 || caps.HasKey(gopi.KEYCODE_A) == false || caps.HasKey(gopi.KEYCODE_BTNLEFT) == false {
+		t.Errorf("Unexpected keys: %v", caps.Keys)
+	}
+	if len(caps.RelAxes) != 4 || caps.HasRelAxis(REL_X) == false || caps.HasRelAxis(REL_Y) == false || caps.HasRelAxis(REL_WHEEL) == false || caps.HasRelAxis(REL_WHEEL_HI_RES) == false {
+		t.Errorf("Unexpected relative axes: %v", caps.RelAxes)
+	}
+	if len(caps.Switches) != 1 || caps.Switches[0] != SW_LID {
+		t.Errorf("Unexpected switches: %v", caps.Switches)
+	}
+	if len(caps.LEDs) != 3 || caps.LEDs[2] != LED_SCROLLL {
+		t.Errorf("Unexpected LEDs: %v", caps.LEDs)
+	}
+	if len(caps.Effects) != 1 || caps.Effects[0] != EFFECT_RUMBLE || len(caps.Waveforms) != 1 || caps.Waveforms[0] != EFFECT_WAVEFORM_SINE {
+		t.Errorf("Unexpected effects: %v %v", caps.Effects, caps.Waveforms)
+	}
+	if len(caps.Properties) != 2 || caps.HasProperty(INPUT_PROP_DIRECT) == false || caps.HasProperty(INPUT_PROP_ACCELEROMETER) == false || caps.HasProperty(INPUT_PROP_POINTER) {
+		t.Errorf("Unexpected properties: %v", caps.Properties)
+	}
+}
+
+func TestDecodeTouchpad_000(t *testing.T) {
+	this := testDevice(t, INPUT_TYPE_TOUCHPAD)
+	this.abs_info = map[evKeyCode]evAbsInfo{
+		EV_CODE_SLOT:   {Minimum: 0, Maximum: 1},
+		EV_CODE_SLOT_X: {Minimum: 0, Maximum: 1000, Resolution: 10},
+		EV_CODE_SLOT_Y: {Minimum: 0, Maximum: 600, Resolution: 10},
+	}
+	this.caps.Properties = []InputProperty{INPUT_PROP_POINTER, INPUT_PROP_BUTTONPAD}
+	if err := this.SetTouchpad(Touchpad{Mode: TOUCHPAD_POINTER, Tap: true, Speed: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Helpers to generate raw events
+	touch := func(slot, id uint32, x, y int32) []evEvent {
+		return []evEvent{
+			{Type: EV_ABS, Code: EV_CODE_SLOT, Value: slot},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: id},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: uint32(x)},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: uint32(y)},
+		}
+	}
+	release := func(slot uint32) []evEvent {
+		return []evEvent{
+			{Type: EV_ABS, Code: EV_CODE_SLOT, Value: slot},
+			{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: 0xFFFFFFFF},
+		}
+	}
+
+	// One finger moves the pointer
+	testExpect(t, "touch", testFrame(this, 0, touch(0, 1, 500, 300), []evEvent{testKey(EV_CODE_BTN_TOUCH, 1), testKey(EV_CODE_BTN_TOOL_FINGER, 1)}))
+	events := testFrame(this, 10, []evEvent{{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 600}})
+	testExpect(t, "move", events, gopi.INPUT_EVENT_RELPOSITION)
+	if len(events) == 1 && (events[0].Relative() != gopi.Point{X: 10} || events[0].Position() != gopi.Point{X: 10}) {
+		t.Errorf("Unexpected movement: %v", events[0])
+	}
+	testExpect(t, "release after move", testFrame(this, 20, release(0), []evEvent{testKey(EV_CODE_BTN_TOUCH, 0), testKey(EV_CODE_BTN_TOOL_FINGER, 0)}))
+
+	// One and two finger taps
+	testFrame(this, 1000, touch(0, 2, 500, 300), []evEvent{testKey(EV_CODE_BTN_TOUCH, 1), testKey(EV_CODE_BTN_TOOL_FINGER, 1)})
+	events = testFrame(this, 1050, release(0), []evEvent{testKey(EV_CODE_BTN_TOUCH, 0), testKey(EV_CODE_BTN_TOOL_FINGER, 0)})
+	testExpect(t, "tap", events, gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYRELEASE)
+	if len(events) == 2 && events[0].KeyCode() != gopi.KEYCODE_BTNLEFT {
+		t.Errorf("Expected left button, got %v", events[0])
+	}
+	testFrame(this, 2000, touch(0, 3, 400, 300), touch(1, 4, 600, 300), []evEvent{testKey(EV_CODE_BTN_TOUCH, 1), testKey(EV_CODE_BTN_TOOL_DOUBLETAP, 1)})
+	events = testFrame(this, 2050, release(0), release(1), []evEvent{testKey(EV_CODE_BTN_TOUCH, 0), testKey(EV_CODE_BTN_TOOL_DOUBLETAP, 0)})
+	testExpect(t, "two finger tap", events, gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYRELEASE)
+	if len(events) == 2 && events[0].KeyCode() != gopi.KEYCODE_BTNRIGHT {
+		t.Errorf("Expected right button, got %v", events[0])
+	}
+
+	// A slow tap is not a tap
+	testFrame(this, 2500, touch(0, 5, 500, 300), []evEvent{testKey(EV_CODE_BTN_TOUCH, 1), testKey(EV_CODE_BTN_TOOL_FINGER, 1)})
+	testExpect(t, "slow tap", testFrame(this, 2800, release(0), []evEvent{testKey(EV_CODE_BTN_TOUCH, 0), testKey(EV_CODE_BTN_TOOL_FINGER, 0)}))
+
+	// Two fingers scroll
+	testFrame(this, 3000, touch(0, 6, 400, 300), touch(1, 7, 600, 300), []evEvent{testKey(EV_CODE_BTN_TOUCH, 1), testKey(EV_CODE_BTN_TOOL_DOUBLETAP, 1)})
+	events = testFrame(this, 3010,
+		[]evEvent{{Type: EV_ABS, Code: EV_CODE_SLOT, Value: 0}, {Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: 250}},
+		[]evEvent{{Type: EV_ABS, Code: EV_CODE_SLOT, Value: 1}, {Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: 250}},
+	)
+	testExpect(t, "scroll", events, INPUT_EVENT_SCROLL)
+	if len(events) == 1 && events[0].(ScrollEvent).Scroll() != (gopi.Point{X: 0, Y: 1}) {
+		t.Errorf("Unexpected scroll: %v", events[0])
+	}
+	testExpect(t, "release after scroll", testFrame(this, 3020, release(0), release(1), []evEvent{testKey(EV_CODE_BTN_TOUCH, 0), testKey(EV_CODE_BTN_TOOL_DOUBLETAP, 0)}))
+
+	// Clicking in the right of the button area presses the right button
+	events = testFrame(this, 4000, touch(0, 8, 800, 580), []evEvent{testKey(EV_CODE_BTN_TOUCH, 1), testKey(EV_CODE_BTN_TOOL_FINGER, 1), testKey(EV_CODE_BTN_MOUSE, 1)})
+	testExpect(t, "click", events, gopi.INPUT_EVENT_KEYPRESS)
+	if len(events) == 1 && events[0].KeyCode() != gopi.KEYCODE_BTNRIGHT {
+		t.Errorf("Expected right button, got %v", events[0])
+	}
+	events = testFrame(this, 4010, []evEvent{testKey(EV_CODE_BTN_MOUSE, 0)})
+	testExpect(t, "unclick", events, gopi.INPUT_EVENT_KEYRELEASE)
+	if len(events) == 1 && events[0].KeyCode() != gopi.KEYCODE_BTNRIGHT {
+		t.Errorf("Expected right button, got %v", events[0])
+	}
+	testExpect(t, "release after click", testFrame(this, 4020, release(0), []evEvent{testKey(EV_CODE_BTN_TOUCH, 0), testKey(EV_CODE_BTN_TOOL_FINGER, 0)}))
+}
+
+func TestDecodeStylus_000(t *testing.T) {
+	this := testDevice(t, INPUT_TYPE_TABLET)
+	this.abs_info = map[evKeyCode]evAbsInfo{
+		EV_CODE_X:        {Minimum: 0, Maximum: 15200},
+		EV_CODE_Y:        {Minimum: 0, Maximum: 9500},
+		EV_CODE_PRESSURE: {Minimum: 0, Maximum: 4095},
+		EV_CODE_DISTANCE: {Minimum: 0, Maximum: 63},
+		EV_CODE_TILT_X:   {Minimum: -64, Maximum: 63},
+		EV_CODE_TILT_Y:   {Minimum: -64, Maximum: 63},
+	}
+
+	// Proximity in is reported before the stylus position
+	events := testDecode(this, []evEvent{
+		testAbs(EV_CODE_X, 1000), testAbs(EV_CODE_Y, 2000), testAbs(EV_CODE_DISTANCE, 63), testAbs(EV_CODE_MISC, 0x802),
+		testKey(EV_CODE_BTN_TOOL_PEN, 1), {Type: EV_MSC, Code: EV_CODE_SERIAL, Value: 0x1234}, syn_report,
+	})
+	testExpect(t, "proximity in", events, INPUT_EVENT_PROXIMITYIN, INPUT_EVENT_STYLUS)
+	for _, evt := range events {
+		if evt := evt.(StylusEvent); evt.Tool() != STYLUS_TOOL_PEN || evt.Distance() != 1 || evt.Position() != (gopi.Point{X: 1000, Y: 2000}) {
+			t.Errorf("Unexpected stylus state: %v", evt)
+		}
+	}
+
+	// Tip touches the surface with pressure and tilt
+	events = testDecode(this, []evEvent{
+		testAbs(EV_CODE_DISTANCE, 0), testAbs(EV_CODE_PRESSURE, 4095), testAbs(EV_CODE_TILT_X, 30), testAbs(EV_CODE_TILT_Y, -20),
+		testKey(EV_CODE_BTN_TOUCH, 1), syn_report,
+	})
+	testExpect(t, "tip", events, INPUT_EVENT_STYLUS, gopi.INPUT_EVENT_KEYPRESS)
+	if len(events) == 2 {
+		if evt := events[0].(StylusEvent); evt.Pressure() != 1 || evt.Distance() != 0 || evt.Tilt() != (gopi.Point{X: 30, Y: -20}) || evt.Buttons() != STYLUS_BUTTON_TIP {
+			t.Errorf("Unexpected stylus state: %v", evt)
+		}
+		if events[1].KeyCode() != gopi.KEYCODE_BTNTOUCH {
+			t.Errorf("Expected tip, got %v", events[1])
+		}
+	}
+
+	// Barrel button
+	events = testDecode(this, []evEvent{testKey(EV_CODE_BTN_STYLUS, 1), syn_report})
+	testExpect(t, "barrel", events, gopi.INPUT_EVENT_KEYPRESS)
+	if len(events) == 1 && (events[0].KeyCode() != KEYCODE_BTNSTYLUS || events[0].(StylusEvent).Buttons() != STYLUS_BUTTON_TIP|STYLUS_BUTTON_BARREL) {
+		t.Errorf("Unexpected barrel button: %v", events[0])
+	}
+	testExpect(t, "release", testDecode(this, []evEvent{
+		testAbs(EV_CODE_PRESSURE, 0), testKey(EV_CODE_BTN_STYLUS, 0), testKey(EV_CODE_BTN_TOUCH, 0), syn_report,
+	}), INPUT_EVENT_STYLUS, gopi.INPUT_EVENT_KEYRELEASE, gopi.INPUT_EVENT_KEYRELEASE)
+
+	// Proximity out discards the reset axes and keeps the last position
+	events = testDecode(this, []evEvent{
+		testAbs(EV_CODE_X, 0), testAbs(EV_CODE_Y, 0), testAbs(EV_CODE_TILT_X, 0), testAbs(EV_CODE_TILT_Y, 0), testAbs(EV_CODE_MISC, 0),
+		testKey(EV_CODE_BTN_TOOL_PEN, 0), syn_report,
+	})
+	testExpect(t, "proximity out", events, INPUT_EVENT_PROXIMITYOUT)
+	if len(events) == 1 {
+		if evt := events[0].(StylusEvent); evt.Tool() != STYLUS_TOOL_PEN || evt.Position() != (gopi.Point{X: 1000, Y: 2000}) {
+			t.Errorf("Unexpected proximity out: %v", evt)
+		}
+	}
+	if this.stylus.tool != STYLUS_TOOL_NONE || this.stylus.tilt != gopi.ZeroPoint {
+		t.Errorf("Unexpected stylus state after proximity out: %+v", this.stylus)
+	}
+}
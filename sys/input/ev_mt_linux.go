@@ -0,0 +1,154 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"sort"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Represents a pairing of a protocol A contact and an active slot
+type evContactMatch struct {
+	contact  int
+	slot     int
+	distance float32
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PROTOCOL A
+
+// evProtocolAFrame converts a frame of anonymous protocol A contacts, each
+// ended by SYN_MT_REPORT, into protocol B slot events. Each contact is
+// matched to the nearest active slot from the previous frame, new contacts
+// are assigned free slots with synthetic tracking IDs, and slots without
+// contacts are released. Events which are not multi-touch events are
+// retained in order, and the slot events are placed where the first contact
+// started
+func (this *device) evProtocolAFrame(frame []evEvent) []evEvent {
+	contacts := make([]gopi.Point, 0)
+	result := make([]evEvent, 0, len(frame))
+	contact, position := gopi.ZeroPoint, -1
+	has_contact := false
+
+	for _, raw_event := range frame {
+		switch {
+		case raw_event.Type == EV_SYN && raw_event.Code == EV_CODE_SYN_MT_REPORT:
+			if has_contact {
+				contacts = append(contacts, contact)
+			}
+			contact, has_contact = gopi.ZeroPoint, false
+		case raw_event.Type == EV_ABS && raw_event.Code == EV_CODE_SLOT_X:
+			contact.X, has_contact = float32(int32(raw_event.Value)), true
+		case raw_event.Type == EV_ABS && raw_event.Code == EV_CODE_SLOT_Y:
+			contact.Y, has_contact = float32(int32(raw_event.Value)), true
+		case raw_event.Type == EV_ABS && raw_event.Code > EV_CODE_SLOT:
+			// Ignore other multi-touch values
+		default:
+			result = append(result, raw_event)
+			continue
+		}
+		if position == -1 {
+			position = len(result)
+		}
+	}
+	if position == -1 {
+		position = len(result)
+	}
+
+	// Insert slot events into the frame
+	slot_events := this.evMatchContacts(contacts)
+	return append(result[:position], append(slot_events, result[position:]...)...)
+}
+
+// evMatchContacts returns protocol B slot events for a set of contacts,
+// which are matched using positions before calibration and scaling
+func (this *device) evMatchContacts(contacts []gopi.Point) []evEvent {
+	// Determine all distances between contacts and active slots
+	matches := make([]evContactMatch, 0)
+	for i, contact := range contacts {
+		for j := range this.slots {
+			if this.slots[j].active {
+				dx := contact.X - this.slots[j].raw.X
+				dy := contact.Y - this.slots[j].raw.Y
+				matches = append(matches, evContactMatch{i, j, dx*dx + dy*dy})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	// Pair nearest contacts and slots first
+	contact_slot := make([]int, len(contacts))
+	for i := range contact_slot {
+		contact_slot[i] = -1
+	}
+	slot_matched := make([]bool, len(this.slots))
+	for _, match := range matches {
+		if contact_slot[match.contact] == -1 && slot_matched[match.slot] == false {
+			contact_slot[match.contact] = match.slot
+			slot_matched[match.slot] = true
+		}
+	}
+
+	// Release slots which no longer have contacts
+	frame := make([]evEvent, 0)
+	for j := range this.slots {
+		if this.slots[j].active && slot_matched[j] == false {
+			frame = append(frame,
+				evEvent{Type: EV_ABS, Code: EV_CODE_SLOT, Value: uint32(j)},
+				evEvent{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: uint32(0xFFFFFFFF)},
+			)
+		}
+	}
+
+	// Move matched contacts and assign new contacts to free slots
+	for i, contact := range contacts {
+		j := contact_slot[i]
+		if j == -1 {
+			if j = this.evFreeSlot(slot_matched); j == -1 {
+				this.log.Warn("evMatchContacts: No free slot for contact %v", contact)
+				continue
+			}
+			slot_matched[j] = true
+			this.tracking_id = (this.tracking_id + 1) & 0x7FFFFFFF
+			frame = append(frame,
+				evEvent{Type: EV_ABS, Code: EV_CODE_SLOT, Value: uint32(j)},
+				evEvent{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: uint32(this.tracking_id)},
+			)
+		} else {
+			frame = append(frame, evEvent{Type: EV_ABS, Code: EV_CODE_SLOT, Value: uint32(j)})
+		}
+		frame = append(frame,
+			evEvent{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: uint32(int32(contact.X))},
+			evEvent{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: uint32(int32(contact.Y))},
+		)
+	}
+
+	return frame
+}
+
+// evFreeSlot returns the first slot which is not active and not
+// matched, or -1 if there are no free slots
+func (this *device) evFreeSlot(slot_matched []bool) int {
+	for j := range this.slots {
+		if this.slots[j].active == false && slot_matched[j] == false {
+			return j
+		}
+	}
+	return -1
+}
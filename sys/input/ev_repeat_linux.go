@@ -0,0 +1,187 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"sync"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Represents the key being repeated in software. The generation is
+// incremented whenever the repeat stops, so that timers which fire
+// after stopping are ignored
+type evRepeat struct {
+	sync.Mutex
+	timer      *time.Timer
+	generation uint
+	event      input_event
+	start      time.Time
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RepeatDevice INTERFACE
+
+// KeyRepeat returns the key repeat mode, delay and period
+func (this *device) KeyRepeat() KeyRepeat {
+	this.soft_repeat.Lock()
+	defer this.soft_repeat.Unlock()
+	return this.repeat
+}
+
+// SetKeyRepeat sets the key repeat mode, delay and period. Kernel repeat
+// is turned off when keys are repeated in software or not repeated
+func (this *device) SetKeyRepeat(repeat KeyRepeat) error {
+	this.log.Debug2("<sys.input.InputDevice.SetKeyRepeat>{ repeat=%v }", repeat)
+	if evSupportsEventType(this.capabilities, EV_REP) == false {
+		return gopi.ErrNotImplemented
+	}
+	switch repeat.Mode {
+	case REPEAT_KERNEL, REPEAT_SOFTWARE:
+		if repeat.Delay <= 0 || repeat.Period <= 0 {
+			return gopi.ErrBadParameter
+		}
+	case REPEAT_NONE:
+		repeat.Delay, repeat.Period = 0, 0
+	default:
+		return gopi.ErrBadParameter
+	}
+
+	// Set the kernel repeat
+	if repeat.Mode == REPEAT_KERNEL {
+		if err := evSetRepeat(this.handle, evRepeatMilliseconds(repeat.Delay), evRepeatMilliseconds(repeat.Period)); err != nil {
+			return err
+		}
+	} else if err := evSetRepeat(this.handle, 0, 0); err != nil {
+		return err
+	}
+
+	// Stop any software repeat and set the mode
+	this.evStopRepeat(gopi.KEYCODE_NONE)
+	this.soft_repeat.Lock()
+	this.repeat = repeat
+	this.soft_repeat.Unlock()
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evGetKeyRepeat reads the kernel key repeat settings
+func (this *device) evGetKeyRepeat() error {
+	if delay, period, err := evGetRepeat(this.handle); err != nil {
+		return err
+	} else if delay == 0 || period == 0 {
+		this.repeat = KeyRepeat{Mode: REPEAT_NONE}
+	} else {
+		this.repeat = KeyRepeat{
+			Mode:   REPEAT_KERNEL,
+			Delay:  time.Duration(delay) * time.Millisecond,
+			Period: time.Duration(period) * time.Millisecond,
+		}
+	}
+	this.repeat_restore = this.repeat
+	return nil
+}
+
+// evRestoreKeyRepeat restores the kernel key repeat settings
+// which were read when the device was opened
+func (this *device) evRestoreKeyRepeat() error {
+	this.evStopRepeat(gopi.KEYCODE_NONE)
+	if this.KeyRepeat() == this.repeat_restore {
+		return nil
+	}
+	return evSetRepeat(this.handle, evRepeatMilliseconds(this.repeat_restore.Delay), evRepeatMilliseconds(this.repeat_restore.Period))
+}
+
+// evSoftwareRepeat starts repeating a key when it is pressed, and
+// stops when it is released. Like the kernel, only the last key
+// pressed is repeated
+func (this *device) evSoftwareRepeat(evt *input_event) {
+	switch evt.event {
+	case gopi.INPUT_EVENT_KEYPRESS:
+		this.evStartRepeat(evt)
+	case gopi.INPUT_EVENT_KEYRELEASE:
+		this.evStopRepeat(evt.key_code)
+	}
+}
+
+// evStartRepeat starts repeating the key for a key press event
+func (this *device) evStartRepeat(evt *input_event) {
+	this.soft_repeat.Lock()
+	defer this.soft_repeat.Unlock()
+
+	if this.soft_repeat.timer != nil {
+		this.soft_repeat.timer.Stop()
+	}
+	this.soft_repeat.generation++
+	this.soft_repeat.event = *evt
+	this.soft_repeat.event.event = gopi.INPUT_EVENT_KEYREPEAT
+	this.soft_repeat.start = time.Now()
+
+	generation := this.soft_repeat.generation
+	this.soft_repeat.timer = time.AfterFunc(this.repeat.Delay, func() {
+		this.evRepeatKey(generation)
+	})
+}
+
+// evStopRepeat stops repeating a key, or any key when key_code
+// is KEYCODE_NONE
+func (this *device) evStopRepeat(key_code gopi.KeyCode) {
+	this.soft_repeat.Lock()
+	defer this.soft_repeat.Unlock()
+
+	if this.soft_repeat.timer == nil {
+		return
+	}
+	if key_code != gopi.KEYCODE_NONE && key_code != this.soft_repeat.event.key_code {
+		return
+	}
+	this.soft_repeat.timer.Stop()
+	this.soft_repeat.timer = nil
+	this.soft_repeat.generation++
+}
+
+// evRepeatKey emits a key repeat event and schedules the next repeat,
+// unless the repeat has been stopped. The repeat period is read while
+// holding the lock, since it can be changed by SetKeyRepeat. The timestamp of the event is
+// the time since the key was pressed, added to the timestamp of the
+// key press
+func (this *device) evRepeatKey(generation uint) {
+	this.soft_repeat.Lock()
+	if this.soft_repeat.generation != generation {
+		this.soft_repeat.Unlock()
+		return
+	}
+	evt := this.soft_repeat.event
+	evt.timestamp += time.Since(this.soft_repeat.start)
+	this.soft_repeat.timer = time.AfterFunc(this.repeat.Period, func() {
+		this.evRepeatKey(generation)
+	})
+	this.soft_repeat.Unlock()
+
+	this.Emit(&evt)
+}
+
+// evRepeatMilliseconds returns a duration in milliseconds
+func evRepeatMilliseconds(value time.Duration) uint32 {
+	if value <= 0 {
+		return 0
+	}
+	return uint32(value / time.Millisecond)
+}
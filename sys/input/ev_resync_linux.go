@@ -0,0 +1,170 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// RESYNC
+
+// evResync queries the device state after events have been dropped, and
+// returns synthetic events for any differences from the state which was
+// known before the events were dropped
+func (this *device) evResync(ts time.Duration) []gopi.InputEvent {
+	frame := make([]evEvent, 0)
+
+	// Keys which have been pressed or released
+	if keys, err := evGetKeyState(this.handle); err != nil {
+		this.log.Warn("evResync: %v", err)
+	} else {
+		frame = append(frame, this.evResyncKeys(keys)...)
+	}
+
+	// Absolute axes and multi-touch slots
+	if abs, err := evGetSupportedCodes(this.handle, EV_ABS); err != nil {
+		this.log.Warn("evResync: %v", err)
+	} else {
+		frame = append(frame, this.evResyncAbs(abs)...)
+	}
+
+	// Switches which have changed
+	if evSupportsEventType(this.capabilities, EV_SW) {
+		if switches, err := evGetSwitchState(this.handle); err != nil {
+			this.log.Warn("evResync: %v", err)
+		} else {
+			frame = append(frame, this.evResyncSwitches(switches)...)
+		}
+	}
+
+	// Decode the synthetic frame
+	this.frame = frame
+	events := this.evDecodeFrame(ts)
+	this.frame = this.frame[:0]
+
+	// Set the lock states from the LED states
+	if leds, err := evGetLEDState(this.handle); err != nil {
+		this.log.Warn("evResync: %v", err)
+	} else {
+		this.evSetLockState(leds)
+	}
+
+	return events
+}
+
+// evResyncKeys returns key events for any keys which differ
+// from the current key bitmap
+func (this *device) evResyncKeys(keys []byte) []evEvent {
+	frame := make([]evEvent, 0)
+	for code := evKeyCode(0); code <= EV_KEY_MAX; code++ {
+		if state := evBitIsSet(keys, code); state != this.keys.isSet(code) {
+			raw_event := evEvent{Type: EV_KEY, Code: code}
+			if state {
+				raw_event.Value = uint32(EV_VALUE_KEY_DOWN)
+			}
+			frame = append(frame, raw_event)
+		}
+	}
+	return frame
+}
+
+// evResyncAbs returns absolute axis events for any axes or
+// multi-touch slots which differ from the current state
+func (this *device) evResyncAbs(abs []byte) []evEvent {
+	if this.device_type&gopi.INPUT_TYPE_JOYSTICK != 0 {
+		return this.evResyncJoystick()
+	}
+	frame := make([]evEvent, 0)
+
+	// Single-touch position
+	if evBitIsSet(abs, EV_CODE_X) {
+		if info, err := evGetAbsInfo(this.handle, EV_CODE_X); err != nil {
+			this.log.Warn("evResync: %v", err)
+		} else if float32(info.Value) != this.raw_position.X {
+			frame = append(frame, evEvent{Type: EV_ABS, Code: EV_CODE_X, Value: uint32(info.Value)})
+		}
+	}
+	if evBitIsSet(abs, EV_CODE_Y) {
+		if info, err := evGetAbsInfo(this.handle, EV_CODE_Y); err != nil {
+			this.log.Warn("evResync: %v", err)
+		} else if float32(info.Value) != this.raw_position.Y {
+			frame = append(frame, evEvent{Type: EV_ABS, Code: EV_CODE_Y, Value: uint32(info.Value)})
+		}
+	}
+
+	// Multi-touch slots
+	if evBitIsSet(abs, EV_CODE_SLOT) == false || evBitIsSet(abs, EV_CODE_SLOT_ID) == false {
+		return frame
+	}
+	info, err := evGetAbsInfo(this.handle, EV_CODE_SLOT)
+	if err != nil {
+		this.log.Warn("evResync: %v", err)
+		return frame
+	}
+	num_slots := int(info.Maximum) + 1
+	if num_slots > len(this.slots) {
+		num_slots = len(this.slots)
+	}
+	ids, err := evGetMTSlots(this.handle, EV_CODE_SLOT_ID, num_slots)
+	if err != nil {
+		this.log.Warn("evResync: %v", err)
+		return frame
+	}
+	xs, _ := evGetMTSlots(this.handle, EV_CODE_SLOT_X, num_slots)
+	ys, _ := evGetMTSlots(this.handle, EV_CODE_SLOT_Y, num_slots)
+	for i := 0; i < num_slots; i++ {
+		slot := this.slots[i]
+		slot_frame := make([]evEvent, 0)
+		if ids[i] == -1 && slot.active {
+			slot_frame = append(slot_frame, evEvent{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: uint32(ids[i])})
+		} else if ids[i] != -1 {
+			if slot.active == false || slot.id != ids[i] {
+				slot_frame = append(slot_frame, evEvent{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: uint32(ids[i])})
+			}
+			if xs != nil && float32(xs[i]) != slot.raw.X {
+				slot_frame = append(slot_frame, evEvent{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: uint32(xs[i])})
+			}
+			if ys != nil && float32(ys[i]) != slot.raw.Y {
+				slot_frame = append(slot_frame, evEvent{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: uint32(ys[i])})
+			}
+		}
+		if len(slot_frame) > 0 {
+			frame = append(frame, evEvent{Type: EV_ABS, Code: EV_CODE_SLOT, Value: uint32(i)})
+			frame = append(frame, slot_frame...)
+		}
+	}
+
+	// Restore the current slot
+	frame = append(frame, evEvent{Type: EV_ABS, Code: EV_CODE_SLOT, Value: uint32(info.Value)})
+
+	return frame
+}
+
+// evSetLockState sets the caps, num and scroll lock states
+// from the LEDs which are on
+func (this *device) evSetLockState(leds []evLEDState) {
+	this.key_state &^= gopi.KEYSTATE_CAPSLOCK | gopi.KEYSTATE_NUMLOCK | gopi.KEYSTATE_SCROLLLOCK
+	for _, led := range leds {
+		switch led {
+		case EV_LED_CAPSL:
+			this.key_state |= gopi.KEYSTATE_CAPSLOCK
+		case EV_LED_NUML:
+			this.key_state |= gopi.KEYSTATE_NUMLOCK
+		case EV_LED_SCROLLL:
+			this.key_state |= gopi.KEYSTATE_SCROLLLOCK
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"math"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Represents the state of the stylus on a graphics tablet
+type evStylus struct {
+	tool     StylusTool
+	buttons  StylusButton
+	pressure float32
+	tilt     gopi.Point
+	distance float32
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Stylus buttons and tools
+const (
+	EV_CODE_BTN_TOOL_LENS evKeyCode = 0x0147 // Last stylus tool
+	EV_CODE_BTN_STYLUS3   evKeyCode = 0x0149
+	EV_CODE_BTN_STYLUS2   evKeyCode = 0x014C
+)
+
+// Stylus axes
+const (
+	EV_CODE_DISTANCE evKeyCode = 0x0019
+	EV_CODE_TILT_X   evKeyCode = 0x001A
+	EV_CODE_TILT_Y   evKeyCode = 0x001B
+	EV_CODE_MISC     evKeyCode = 0x0028 // Tool identifier
+)
+
+// Miscellaneous codes
+const (
+	EV_CODE_SERIAL evKeyCode = 0x0000 // Tool serial number
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evDecodeStylusKey decodes the tool, tip and barrel buttons of a stylus.
+// A tool which comes into range returns a proximity in event and a tool
+// which goes out of range returns a proximity out event, while the tip
+// and barrel buttons return key events
+func (this *device) evDecodeStylusKey(raw_event *evEvent) *input_event {
+	switch raw_event.Code {
+	case EV_CODE_BTN_TOUCH, EV_CODE_BTN_STYLUS, EV_CODE_BTN_STYLUS2, EV_CODE_BTN_STYLUS3:
+		if raw_event.Value == uint32(EV_VALUE_KEY_UP) {
+			this.stylus.buttons &^= evStylusButton(raw_event.Code)
+		} else {
+			this.stylus.buttons |= evStylusButton(raw_event.Code)
+		}
+		return this.evDecodeKey(raw_event)
+	}
+
+	// Record the tool state for resyncing
+	tool := StylusTool(raw_event.Code)
+	state := raw_event.Value != uint32(EV_VALUE_KEY_UP)
+	this.keys.set(raw_event.Code, state)
+
+	switch {
+	case state && this.stylus.tool != tool:
+		this.stylus.tool = tool
+		return this.evNewEvent(INPUT_EVENT_PROXIMITYIN)
+	case state == false && this.stylus.tool == tool:
+		evt := this.evNewEvent(INPUT_EVENT_PROXIMITYOUT)
+		evt.tool = tool
+		evt.buttons = this.stylus.buttons
+		this.stylus = evStylus{buttons: this.stylus.buttons}
+		return evt
+	default:
+		return nil
+	}
+}
+
+// evDecodeStylus applies the stylus axes in a frame, and moves proximity
+// in events to the start of the frame and proximity out events to the
+// end of the frame. Tablets reset the axes when the tool goes out of
+// range, so the axes are discarded in that case
+func (this *device) evDecodeStylus(events []*input_event, frame []evEvent) []*input_event {
+	var proximity_in, proximity_out *input_event
+	result := make([]*input_event, 0, len(events))
+	for _, evt := range events {
+		switch evt.event {
+		case INPUT_EVENT_PROXIMITYIN:
+			proximity_in = evt
+		case INPUT_EVENT_PROXIMITYOUT:
+			proximity_out = evt
+		default:
+			result = append(result, evt)
+		}
+	}
+	if proximity_out != nil {
+		for i, evt := range result {
+			if evt.event == INPUT_EVENT_STYLUS {
+				result = append(result[:i], result[i+1:]...)
+				break
+			}
+		}
+		return append(result, proximity_out)
+	}
+	for i := range frame {
+		this.evDecodeStylusAbs(&frame[i])
+	}
+	if proximity_in != nil {
+		result = append([]*input_event{proximity_in}, result...)
+	}
+	return result
+}
+
+// evDecodeStylusAbs sets the position, pressure, distance and tilt of
+// the stylus from an absolute axis event
+func (this *device) evDecodeStylusAbs(raw_event *evEvent) {
+	value := float32(int32(raw_event.Value))
+	switch raw_event.Code {
+	case EV_CODE_X, EV_CODE_Y:
+		this.evDecodeAbs(raw_event, nil)
+	case EV_CODE_PRESSURE:
+		this.stylus.pressure = this.evNormaliseAbs(raw_event.Code, value)
+	case EV_CODE_DISTANCE:
+		this.stylus.distance = this.evNormaliseAbs(raw_event.Code, value)
+	case EV_CODE_TILT_X:
+		this.stylus.tilt.X = this.evStylusTilt(raw_event.Code, value)
+	case EV_CODE_TILT_Y:
+		this.stylus.tilt.Y = this.evStylusTilt(raw_event.Code, value)
+	case EV_CODE_MISC:
+		// Ignore the tool identifier
+	}
+}
+
+// evSetStylusState sets the stylus state on an event
+func (this *device) evSetStylusState(evt *input_event) {
+	evt.tool = this.stylus.tool
+	evt.buttons = this.stylus.buttons
+	evt.pressure = this.stylus.pressure
+	evt.tilt = this.stylus.tilt
+	evt.distance = this.stylus.distance
+}
+
+// evNormaliseAbs returns an axis value between 0.0 and 1.0 using the
+// range of the axis, or the unscaled value if the range is not known
+func (this *device) evNormaliseAbs(code evKeyCode, value float32) float32 {
+	info, exists := this.abs_info[code]
+	if exists == false || info.Maximum <= info.Minimum {
+		return value
+	}
+	normalised := (value - float32(info.Minimum)) / float32(info.Maximum-info.Minimum)
+	return float32(math.Max(0, math.Min(1, float64(normalised))))
+}
+
+// evStylusTilt returns a tilt value in degrees. The resolution of the tilt
+// axes is in units per radian, and values are assumed to be in degrees
+// when the resolution is not known
+func (this *device) evStylusTilt(code evKeyCode, value float32) float32 {
+	info, exists := this.abs_info[code]
+	if exists == false || info.Resolution <= 0 {
+		return value
+	}
+	return value / float32(info.Resolution) * 180 / math.Pi
+}
+
+// evIsStylusKey returns true if the code is a stylus tool,
+// tip or barrel button
+func evIsStylusKey(code evKeyCode) bool {
+	switch {
+	case code >= EV_CODE_BTN_TOOL_PEN && code <= EV_CODE_BTN_TOOL_LENS:
+		return code != EV_CODE_BTN_TOOL_FINGER
+	case code == EV_CODE_BTN_TOUCH, code == EV_CODE_BTN_STYLUS, code == EV_CODE_BTN_STYLUS2, code == EV_CODE_BTN_STYLUS3:
+		return true
+	default:
+		return false
+	}
+}
+
+// evIsStylusCode returns true if the code is a stylus axis
+func evIsStylusCode(code evKeyCode) bool {
+	switch code {
+	case EV_CODE_X, EV_CODE_Y, EV_CODE_PRESSURE, EV_CODE_DISTANCE, EV_CODE_TILT_X, EV_CODE_TILT_Y, EV_CODE_MISC:
+		return true
+	default:
+		return false
+	}
+}
+
+// evStylusButton returns the stylus button for a key code
+func evStylusButton(code evKeyCode) StylusButton {
+	switch code {
+	case EV_CODE_BTN_TOUCH:
+		return STYLUS_BUTTON_TIP
+	case EV_CODE_BTN_STYLUS:
+		return STYLUS_BUTTON_BARREL
+	case EV_CODE_BTN_STYLUS2:
+		return STYLUS_BUTTON_BARREL2
+	case EV_CODE_BTN_STYLUS3:
+		return STYLUS_BUTTON_BARREL3
+	default:
+		return STYLUS_BUTTON_NONE
+	}
+}
@@ -0,0 +1,94 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+////////////////////////////////////////////////////////////////////////////////
+// SwitchDevice INTERFACE
+
+// SwitchState returns the state of a switch
+func (this *device) SwitchState(code SwitchCode) bool {
+	if code > SW_MAX {
+		return false
+	}
+	return this.switches&(1<<code) != 0
+}
+
+// Switches returns the switches which are currently on
+func (this *device) Switches() []SwitchCode {
+	switches := make([]SwitchCode, 0)
+	for code := SwitchCode(0); code <= SW_MAX; code++ {
+		if this.SwitchState(code) {
+			switches = append(switches, code)
+		}
+	}
+	return switches
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evDecodeSwitch returns a switch event when the state of a switch
+// changes, and ignores events which don't change the state
+func (this *device) evDecodeSwitch(raw_event *evEvent) *input_event {
+	code := SwitchCode(raw_event.Code)
+	if code > SW_MAX {
+		this.log.Warn("evDecodeSwitch: Ignoring code %v", raw_event.Code)
+		return nil
+	}
+	state := raw_event.Value != 0
+	if state == this.SwitchState(code) {
+		return nil
+	}
+	if state {
+		this.switches |= 1 << code
+	} else {
+		this.switches &^= 1 << code
+	}
+	evt := this.evNewEvent(INPUT_EVENT_SWITCH)
+	evt.switch_code = code
+	evt.switch_state = state
+	return evt
+}
+
+// evResyncSwitches returns switch events for any switches
+// which differ from the current state
+func (this *device) evResyncSwitches(switches []byte) []evEvent {
+	frame := make([]evEvent, 0)
+	for code := SwitchCode(0); code <= SW_MAX; code++ {
+		if state := evBitIsSet(switches, evKeyCode(code)); state != this.SwitchState(code) {
+			raw_event := evEvent{Type: EV_SW, Code: evKeyCode(code)}
+			if state {
+				raw_event.Value = 1
+			}
+			frame = append(frame, raw_event)
+		}
+	}
+	return frame
+}
+
+// evSyncSwitchState reads the switch states from the device
+func (this *device) evSyncSwitchState() error {
+	if evSupportsEventType(this.capabilities, EV_SW) == false {
+		return nil
+	}
+	if switches, err := evGetSwitchState(this.handle); err != nil {
+		return err
+	} else {
+		this.switches = 0
+		for code := SwitchCode(0); code <= SW_MAX; code++ {
+			if evBitIsSet(switches, evKeyCode(code)) {
+				this.switches |= 1 << code
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"math"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Represents the state of a touchpad in pointer mode. Positions
+// of fingers are in millimetres from the top left of the touchpad
+type evTouchpad struct {
+	Touchpad
+
+	// Axes, resolution in units per millimetre and size of the touchpad
+	x, y       evKeyCode
+	resolution gopi.Point
+	size       gopi.Size
+
+	// Pointer position
+	position gopi.Point
+
+	// Number of fingers, the maximum number of fingers during the
+	// current touch, and the position of the fingers when the touch
+	// started and in the last frame
+	fingers     uint
+	max_fingers uint
+	start       gopi.Point
+	start_ts    time.Duration
+	last        gopi.Point
+	last_ts     time.Duration
+
+	// Whether the fingers moved or a button was clicked during
+	// the current touch, and the button pressed on a clickpad
+	moved   bool
+	clicked bool
+	button  gopi.KeyCode
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	EV_CODE_BTN_TOOL_QUINTTAP  evKeyCode = 0x0148
+	EV_CODE_BTN_TOOL_DOUBLETAP evKeyCode = 0x014D
+	EV_CODE_BTN_TOOL_TRIPLETAP evKeyCode = 0x014E
+	EV_CODE_BTN_TOOL_QUADTAP   evKeyCode = 0x014F // Last digitizer button
+)
+
+const (
+	EV_TOUCHPAD_TAP_TIME         = 180 * time.Millisecond // Maximum duration of a tap
+	EV_TOUCHPAD_TAP_DISTANCE     = 3.0                    // Maximum movement of a tap in millimetres
+	EV_TOUCHPAD_SCROLL_DISTANCE  = 5.0                    // Movement for each scroll detent in millimetres
+	EV_TOUCHPAD_BUTTON_AREA      = 10.0                   // Height of the clickpad button area in millimetres
+	EV_TOUCHPAD_MAX_ACCELERATION = 4.0                    // Maximum increase in pointer speed
+	EV_TOUCHPAD_WIDTH            = 100.0                  // Width in millimetres when resolution is not reported
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TouchpadDevice INTERFACE
+
+// Touchpad returns the touchpad settings
+func (this *device) Touchpad() Touchpad {
+	return this.touchpad.Touchpad
+}
+
+// SetTouchpad sets the touchpad settings, and resets the pointer position
+func (this *device) SetTouchpad(touchpad Touchpad) error {
+	if this.device_type&INPUT_TYPE_TOUCHPAD == 0 {
+		return gopi.ErrNotImplemented
+	}
+	switch touchpad.Mode {
+	case TOUCHPAD_RAW:
+		this.touchpad = evTouchpad{Touchpad: touchpad}
+		return nil
+	case TOUCHPAD_POINTER:
+		if touchpad.Speed <= 0 || touchpad.Acceleration < 0 {
+			return gopi.ErrBadParameter
+		}
+	default:
+		return gopi.ErrBadParameter
+	}
+
+	// Determine the axes, resolution and size of the touchpad. When the
+	// resolution is not reported, assume a width and square units
+	this.touchpad = evTouchpad{Touchpad: touchpad, x: EV_CODE_X, y: EV_CODE_Y}
+	if _, exists := this.abs_info[EV_CODE_SLOT_X]; exists {
+		this.touchpad.x, this.touchpad.y = EV_CODE_SLOT_X, EV_CODE_SLOT_Y
+	}
+	x, y := this.abs_info[this.touchpad.x], this.abs_info[this.touchpad.y]
+	this.touchpad.resolution = gopi.Point{X: float32(x.Resolution), Y: float32(y.Resolution)}
+	if this.touchpad.resolution.X <= 0 {
+		this.touchpad.resolution.X = float32(x.Maximum-x.Minimum) / EV_TOUCHPAD_WIDTH
+	}
+	if this.touchpad.resolution.X <= 0 {
+		this.touchpad.resolution.X = 1
+	}
+	if this.touchpad.resolution.Y <= 0 {
+		this.touchpad.resolution.Y = this.touchpad.resolution.X
+	}
+	this.touchpad.size = gopi.Size{
+		W: float32(x.Maximum-x.Minimum) / this.touchpad.resolution.X,
+		H: float32(y.Maximum-y.Minimum) / this.touchpad.resolution.Y,
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evDecodeTouchpad converts the events for a frame from a touchpad into
+// mouse-style events. Touch events are removed, one finger moves the pointer,
+// two fingers scroll and tapping presses a button. On clickpads, the button
+// depends on where the touchpad was pressed
+func (this *device) evDecodeTouchpad(events []*input_event, ts time.Duration) []*input_event {
+	tp := &this.touchpad
+	fingers, contacts := this.evTouchpadContacts()
+	position := evCentroid(contacts)
+
+	// Start a new touch
+	if fingers > 0 && tp.fingers == 0 {
+		tp.start, tp.start_ts = position, ts
+		tp.last, tp.last_ts = position, ts
+		tp.max_fingers, tp.moved, tp.clicked = fingers, false, false
+	}
+
+	// Remove touch events, and determine the button for clickpads
+	result := make([]*input_event, 0, len(events))
+	for _, evt := range events {
+		switch evt.event {
+		case gopi.INPUT_EVENT_TOUCHPRESS, gopi.INPUT_EVENT_TOUCHRELEASE, gopi.INPUT_EVENT_TOUCHPOSITION, gopi.INPUT_EVENT_ABSPOSITION:
+			continue
+		case gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYRELEASE:
+			if code := evKeyCode(evt.key_code); code >= EV_CODE_BTN_TOOL_PEN && code <= EV_CODE_BTN_TOOL_QUADTAP {
+				continue
+			}
+			if evt.key_code == gopi.KEYCODE_BTNLEFT && this.caps.HasProperty(INPUT_PROP_BUTTONPAD) {
+				if evt.event == gopi.INPUT_EVENT_KEYPRESS {
+					tp.button = this.evTouchpadButton(contacts)
+				}
+				evt.key_code = tp.button
+			}
+			tp.clicked = true
+		}
+		result = append(result, evt)
+	}
+
+	// Determine gestures
+	switch {
+	case fingers == 0 && tp.fingers > 0:
+		// The touch ended, which is a tap if the fingers didn't move
+		if tp.Tap && tp.moved == false && tp.clicked == false && ts-tp.start_ts <= EV_TOUCHPAD_TAP_TIME {
+			result = append(result, this.evTouchpadTap(tp.max_fingers)...)
+		}
+	case fingers != tp.fingers:
+		// Fingers were added or removed, so don't move
+		if fingers > tp.max_fingers {
+			tp.max_fingers = fingers
+		}
+	case fingers > 0:
+		delta := gopi.Point{X: position.X - tp.last.X, Y: position.Y - tp.last.Y}
+		if evDistance(tp.start, position) > EV_TOUCHPAD_TAP_DISTANCE {
+			tp.moved = true
+		}
+		switch fingers {
+		case 1:
+			if evt := this.evTouchpadMove(delta, ts-tp.last_ts); evt != nil {
+				result = append(result, evt)
+			}
+		case 2:
+			if delta.Equals(gopi.ZeroPoint) == false {
+				evt := this.evNewEvent(INPUT_EVENT_SCROLL)
+				evt.scroll = gopi.Point{X: delta.X / EV_TOUCHPAD_SCROLL_DISTANCE, Y: -delta.Y / EV_TOUCHPAD_SCROLL_DISTANCE}
+				result = append(result, evt)
+			}
+		}
+	}
+	tp.fingers, tp.last, tp.last_ts = fingers, position, ts
+
+	// Report the pointer position rather than the touch position
+	this.position = tp.position
+	return result
+}
+
+// evTouchpadContacts returns the number of fingers on the touchpad and
+// the positions of the contacts in millimetres. Some touchpads track fewer
+// contacts than there are fingers, so the number of fingers is also
+// determined from the tool buttons
+func (this *device) evTouchpadContacts() (uint, []gopi.Point) {
+	contacts := make([]gopi.Point, 0)
+	if this.touchpad.x == EV_CODE_SLOT_X {
+		for _, slot := range this.slots {
+			if slot.active {
+				contacts = append(contacts, this.evTouchpadMillimetres(slot.raw))
+			}
+		}
+	} else if this.keys.isSet(EV_CODE_BTN_TOUCH) {
+		contacts = append(contacts, this.evTouchpadMillimetres(this.raw_position))
+	}
+	fingers := uint(len(contacts))
+	if fingers == 0 {
+		return 0, contacts
+	}
+	tools := []evKeyCode{EV_CODE_BTN_TOOL_FINGER, EV_CODE_BTN_TOOL_DOUBLETAP, EV_CODE_BTN_TOOL_TRIPLETAP, EV_CODE_BTN_TOOL_QUADTAP, EV_CODE_BTN_TOOL_QUINTTAP}
+	for i, tool := range tools {
+		if this.keys.isSet(tool) && uint(i+1) > fingers {
+			fingers = uint(i + 1)
+		}
+	}
+	return fingers, contacts
+}
+
+// evTouchpadMillimetres returns a position in millimetres
+func (this *device) evTouchpadMillimetres(raw gopi.Point) gopi.Point {
+	x, y := this.abs_info[this.touchpad.x], this.abs_info[this.touchpad.y]
+	return gopi.Point{
+		X: (raw.X - float32(x.Minimum)) / this.touchpad.resolution.X,
+		Y: (raw.Y - float32(y.Minimum)) / this.touchpad.resolution.Y,
+	}
+}
+
+// evTouchpadButton returns the button for a clickpad, which is the right
+// button when the lowest contact is in the right of the button area
+func (this *device) evTouchpadButton(contacts []gopi.Point) gopi.KeyCode {
+	if len(contacts) == 0 {
+		return gopi.KEYCODE_BTNLEFT
+	}
+	lowest := contacts[0]
+	for _, contact := range contacts {
+		if contact.Y > lowest.Y {
+			lowest = contact
+		}
+	}
+	if lowest.Y >= this.touchpad.size.H-EV_TOUCHPAD_BUTTON_AREA && lowest.X >= this.touchpad.size.W/2 {
+		return gopi.KEYCODE_BTNRIGHT
+	}
+	return gopi.KEYCODE_BTNLEFT
+}
+
+// evTouchpadTap returns button press and release events for a tap
+// with one, two or three fingers
+func (this *device) evTouchpadTap(fingers uint) []*input_event {
+	var key_code gopi.KeyCode
+	switch fingers {
+	case 1:
+		key_code = gopi.KEYCODE_BTNLEFT
+	case 2:
+		key_code = gopi.KEYCODE_BTNRIGHT
+	case 3:
+		key_code = gopi.KEYCODE_BTNMIDDLE
+	default:
+		return nil
+	}
+	press := this.evNewEvent(gopi.INPUT_EVENT_KEYPRESS)
+	press.key_code = key_code
+	release := this.evNewEvent(gopi.INPUT_EVENT_KEYRELEASE)
+	release.key_code = key_code
+	return []*input_event{press, release}
+}
+
+// evTouchpadMove returns a relative position event for finger movement
+// in millimetres, accelerated by the velocity of the finger, and moves
+// the pointer. It returns nil if there is no movement
+func (this *device) evTouchpadMove(delta gopi.Point, interval time.Duration) *input_event {
+	if delta.Equals(gopi.ZeroPoint) {
+		return nil
+	}
+	speed := this.touchpad.Speed
+	if interval > 0 {
+		velocity := evDistance(gopi.ZeroPoint, delta) * float32(time.Millisecond) / float32(interval)
+		speed *= float32(math.Min(float64(1+this.touchpad.Acceleration*velocity), EV_TOUCHPAD_MAX_ACCELERATION))
+	}
+	evt := this.evNewEvent(gopi.INPUT_EVENT_RELPOSITION)
+	evt.rel_position = gopi.Point{X: delta.X * speed, Y: delta.Y * speed}
+	this.touchpad.position.X += evt.rel_position.X
+	this.touchpad.position.Y += evt.rel_position.Y
+	return evt
+}
+
+// evCentroid returns the average of a set of positions
+func evCentroid(points []gopi.Point) gopi.Point {
+	centroid := gopi.ZeroPoint
+	if len(points) == 0 {
+		return centroid
+	}
+	for _, pt := range points {
+		centroid.X += pt.X
+		centroid.Y += pt.Y
+	}
+	centroid.X /= float32(len(points))
+	centroid.Y /= float32(len(points))
+	return centroid
+}
+
+// evDistance returns the distance between two positions
+func evDistance(a, b gopi.Point) float32 {
+	dx, dy := float64(b.X-a.X), float64(b.Y-a.Y)
+	return float32(math.Sqrt(dx*dx + dy*dy))
+}
@@ -11,6 +11,7 @@ package input
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	// Frameworks
@@ -33,8 +34,122 @@ type input_event struct {
 	scan_code    uint32
 	device_id    uint32
 	slot         uint
+	scroll       gopi.Point
+	axis         AbsAxis
+	value        float32
+	hat          uint
+	direction    HatDirection
+	effect       EffectID
+	status       EffectStatus
+	switch_code  SwitchCode
+	switch_state bool
+	tool         StylusTool
+	pressure     float32
+	tilt         gopi.Point
+	distance     float32
+	buttons      StylusButton
+	battery      Battery
 }
 
+// ScrollEvent is an input event for INPUT_EVENT_SCROLL, which
+// reports scroll wheel movement. Scroll returns zero for other
+// event types
+type ScrollEvent interface {
+	gopi.InputEvent
+
+	// Vertical (Y) and horizontal (X) scroll in detents, where
+	// positive values scroll up and right. High resolution scroll
+	// wheels report fractions of a detent
+	Scroll() gopi.Point
+}
+
+// AxisEvent is an input event for INPUT_EVENT_AXIS, which reports
+// movement of a joystick or gamepad axis. Axis and Value return zero
+// for other event types
+type AxisEvent interface {
+	gopi.InputEvent
+
+	// The axis which has moved
+	Axis() AbsAxis
+
+	// The position of the axis, between -1.0 and 1.0 for sticks
+	// and between 0.0 and 1.0 for triggers and throttles
+	Value() float32
+}
+
+// HatEvent is an input event for INPUT_EVENT_HAT, which reports
+// a change of direction of a joystick hat or gamepad D-pad. Hat
+// and Direction return zero for other event types
+type HatEvent interface {
+	gopi.InputEvent
+
+	// The hat which has changed, between 0 and 3
+	Hat() uint
+
+	// The direction of the hat
+	Direction() HatDirection
+}
+
+// EffectEvent is an input event for INPUT_EVENT_EFFECT, which reports
+// a change in the status of an effect. Effect and EffectStatus return
+// zero for other event types
+type EffectEvent interface {
+	gopi.InputEvent
+
+	// The effect which has changed status
+	Effect() EffectID
+
+	// The status of the effect
+	EffectStatus() EffectStatus
+}
+
+// SwitchEvent is an input event for INPUT_EVENT_SWITCH, which reports
+// a switch turning on or off. Switch and SwitchState return zero for
+// other event types
+type SwitchEvent interface {
+	gopi.InputEvent
+
+	// The switch which has changed
+	Switch() SwitchCode
+
+	// The state of the switch
+	SwitchState() bool
+}
+
+// IdentityEvent is an input event which reports the device ID of
+// the device which emitted the event, or zero if not known
+type IdentityEvent interface {
+	gopi.InputEvent
+
+	// The device ID, as returned by the IdentityDevice
+	DeviceID() uint32
+}
+
+// HatDirection is the direction of a hat, as a combination of
+// up, down, left and right
+type HatDirection uint
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Event types, in addition to the gopi.InputEventType values
+const (
+	INPUT_EVENT_SCROLL gopi.InputEventType = 0x0009 // Scroll wheel movement
+	INPUT_EVENT_AXIS   gopi.InputEventType = 0x000A // Joystick axis movement
+	INPUT_EVENT_HAT    gopi.InputEventType = 0x000B // Joystick hat direction
+	INPUT_EVENT_EFFECT gopi.InputEventType = 0x000C // Force feedback effect status
+	INPUT_EVENT_SWITCH gopi.InputEventType = 0x000D // Switch on or off
+)
+
+// Hat directions
+const (
+	HAT_CENTRE HatDirection = 0x00
+	HAT_UP     HatDirection = 0x01
+	HAT_DOWN   HatDirection = 0x02
+	HAT_LEFT   HatDirection = 0x04
+	HAT_RIGHT  HatDirection = 0x08
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // gopi.InputEvent INTERFACE
 
@@ -43,6 +158,7 @@ func NewInputEvent(source gopi.InputDevice, timestamp time.Duration, event_type
 		source:       source,
 		timestamp:    timestamp,
 		device:       source.Type(),
+		device_id:    sourceDeviceID(source),
 		event:        event_type,
 		position:     position,
 		rel_position: rel_position,
@@ -53,6 +169,108 @@ func NewInputEvent(source gopi.InputDevice, timestamp time.Duration, event_type
 	}
 }
 
+// NewAxisEvent returns an INPUT_EVENT_AXIS event
+func NewAxisEvent(source gopi.InputDevice, timestamp time.Duration, axis AbsAxis, value float32) AxisEvent {
+	return &input_event{
+		source:    source,
+		timestamp: timestamp,
+		device:    source.Type(),
+		device_id: sourceDeviceID(source),
+		event:     INPUT_EVENT_AXIS,
+		key_state: source.KeyState(),
+		axis:      axis,
+		value:     value,
+	}
+}
+
+// NewHatEvent returns an INPUT_EVENT_HAT event
+func NewHatEvent(source gopi.InputDevice, timestamp time.Duration, hat uint, direction HatDirection) HatEvent {
+	return &input_event{
+		source:    source,
+		timestamp: timestamp,
+		device:    source.Type(),
+		device_id: sourceDeviceID(source),
+		event:     INPUT_EVENT_HAT,
+		key_state: source.KeyState(),
+		hat:       hat,
+		direction: direction,
+	}
+}
+
+// NewEffectEvent returns an INPUT_EVENT_EFFECT event
+func NewEffectEvent(source gopi.InputDevice, timestamp time.Duration, effect EffectID, status EffectStatus) EffectEvent {
+	return &input_event{
+		source:    source,
+		timestamp: timestamp,
+		device:    source.Type(),
+		device_id: sourceDeviceID(source),
+		event:     INPUT_EVENT_EFFECT,
+		key_state: source.KeyState(),
+		effect:    effect,
+		status:    status,
+	}
+}
+
+// NewSwitchEvent returns an INPUT_EVENT_SWITCH event
+func NewSwitchEvent(source gopi.InputDevice, timestamp time.Duration, switch_code SwitchCode, state bool) SwitchEvent {
+	return &input_event{
+		source:       source,
+		timestamp:    timestamp,
+		device:       source.Type(),
+		device_id:    sourceDeviceID(source),
+		event:        INPUT_EVENT_SWITCH,
+		key_state:    source.KeyState(),
+		switch_code:  switch_code,
+		switch_state: state,
+	}
+}
+
+// NewScrollEvent returns an INPUT_EVENT_SCROLL event
+func NewScrollEvent(source gopi.InputDevice, timestamp time.Duration, position gopi.Point, scroll gopi.Point) ScrollEvent {
+	return &input_event{
+		source:    source,
+		timestamp: timestamp,
+		device:    source.Type(),
+		device_id: sourceDeviceID(source),
+		event:     INPUT_EVENT_SCROLL,
+		position:  position,
+		key_state: source.KeyState(),
+		scroll:    scroll,
+	}
+}
+
+// NewBatteryEvent returns an INPUT_EVENT_BATTERY event
+func NewBatteryEvent(source gopi.InputDevice, timestamp time.Duration, battery Battery) BatteryEvent {
+	return &input_event{
+		source:    source,
+		timestamp: timestamp,
+		device:    source.Type(),
+		device_id: sourceDeviceID(source),
+		event:     INPUT_EVENT_BATTERY,
+		key_state: source.KeyState(),
+		battery:   battery,
+	}
+}
+
+// NewStylusEvent returns an INPUT_EVENT_STYLUS, INPUT_EVENT_PROXIMITYIN
+// or INPUT_EVENT_PROXIMITYOUT event
+func NewStylusEvent(source gopi.InputDevice, timestamp time.Duration, event_type gopi.InputEventType, tool StylusTool, position gopi.Point, pressure float32, tilt gopi.Point, distance float32, buttons StylusButton) StylusEvent {
+	return &input_event{
+		source:    source,
+		timestamp: timestamp,
+		device:    source.Type(),
+		device_id: sourceDeviceID(source),
+		event:     event_type,
+		position:  position,
+		key_state: source.KeyState(),
+		tool:      tool,
+		pressure:  pressure,
+		tilt:      tilt,
+		distance:  distance,
+		buttons:   buttons,
+	}
+}
+
 func (this *input_event) Name() string {
 	return "InputEvent"
 }
@@ -69,6 +287,10 @@ func (this *input_event) DeviceType() gopi.InputDeviceType {
 	return this.device
 }
 
+func (this *input_event) DeviceID() uint32 {
+	return this.device_id
+}
+
 func (this *input_event) EventType() gopi.InputEventType {
 	return this.event
 }
@@ -97,22 +319,133 @@ func (this *input_event) Slot() uint {
 	return this.slot
 }
 
+func (this *input_event) Scroll() gopi.Point {
+	return this.scroll
+}
+
+func (this *input_event) Axis() AbsAxis {
+	return this.axis
+}
+
+func (this *input_event) Value() float32 {
+	return this.value
+}
+
+func (this *input_event) Hat() uint {
+	return this.hat
+}
+
+func (this *input_event) Direction() HatDirection {
+	return this.direction
+}
+
+func (this *input_event) Effect() EffectID {
+	return this.effect
+}
+
+func (this *input_event) EffectStatus() EffectStatus {
+	return this.status
+}
+
+func (this *input_event) Switch() SwitchCode {
+	return this.switch_code
+}
+
+func (this *input_event) SwitchState() bool {
+	return this.switch_state
+}
+
+func (this *input_event) Tool() StylusTool {
+	return this.tool
+}
+
+func (this *input_event) Pressure() float32 {
+	return this.pressure
+}
+
+func (this *input_event) Tilt() gopi.Point {
+	return this.tilt
+}
+
+func (this *input_event) Distance() float32 {
+	return this.distance
+}
+
+func (this *input_event) Buttons() StylusButton {
+	return this.buttons
+}
+
+func (this *input_event) Battery() Battery {
+	return this.battery
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// sourceDeviceID returns the device ID of the device which emits
+// an event, or zero if not known
+func sourceDeviceID(source gopi.InputDevice) uint32 {
+	if identity, ok := source.(IdentityDevice); ok {
+		return identity.DeviceID()
+	} else {
+		return 0
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
 func (this *input_event) String() string {
 	switch this.event {
 	case gopi.INPUT_EVENT_RELPOSITION:
-		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v relative=%v position=%v ts=%v }", this.event, this.device, this.rel_position, this.position, this.timestamp)
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v relative=%v position=%v ts=%v }", this.event, DeviceTypeString(this.device), this.rel_position, this.position, this.timestamp)
 	case gopi.INPUT_EVENT_ABSPOSITION:
-		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v position=%v ts=%v }", this.event, this.device, this.position, this.timestamp)
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v position=%v ts=%v }", this.event, DeviceTypeString(this.device), this.position, this.timestamp)
 	case gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYRELEASE, gopi.INPUT_EVENT_KEYREPEAT:
-		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v key_code=%v key_state=%v scan_code=0x%08X ts=%v }", this.event, this.device, this.key_code, this.key_state, this.scan_code, this.timestamp)
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v key_code=%v key_state=%v scan_code=0x%08X ts=%v }", this.event, DeviceTypeString(this.device), this.key_code, this.key_state, this.scan_code, this.timestamp)
 	case gopi.INPUT_EVENT_TOUCHPRESS, gopi.INPUT_EVENT_TOUCHRELEASE:
-		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v key_code=%v key_state=%v slot=%v position=%v ts=%v }", this.event, this.device, this.key_code, this.key_state, this.position, this.slot, this.timestamp)
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v key_code=%v key_state=%v slot=%v position=%v ts=%v }", this.event, DeviceTypeString(this.device), this.key_code, this.key_state, this.slot, this.position, this.timestamp)
 	case gopi.INPUT_EVENT_TOUCHPOSITION:
-		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v slot=%v position=%v ts=%v }", this.event, this.device, this.position, this.slot, this.timestamp)
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v slot=%v position=%v ts=%v }", this.event, DeviceTypeString(this.device), this.slot, this.position, this.timestamp)
+	case INPUT_EVENT_SCROLL:
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=INPUT_EVENT_SCROLL device=%v scroll=%v position=%v ts=%v }", DeviceTypeString(this.device), this.scroll, this.position, this.timestamp)
+	case INPUT_EVENT_AXIS:
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=INPUT_EVENT_AXIS device=%v axis=%v value=%v ts=%v }", DeviceTypeString(this.device), this.axis, this.value, this.timestamp)
+	case INPUT_EVENT_HAT:
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=INPUT_EVENT_HAT device=%v hat=%v direction=%v ts=%v }", DeviceTypeString(this.device), this.hat, this.direction, this.timestamp)
+	case INPUT_EVENT_EFFECT:
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=INPUT_EVENT_EFFECT device=%v effect=%v status=%v ts=%v }", DeviceTypeString(this.device), this.effect, this.status, this.timestamp)
+	case INPUT_EVENT_SWITCH:
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=INPUT_EVENT_SWITCH device=%v switch=%v state=%v ts=%v }", DeviceTypeString(this.device), this.switch_code, this.switch_state, this.timestamp)
+	case INPUT_EVENT_STYLUS:
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=INPUT_EVENT_STYLUS device=%v tool=%v position=%v pressure=%v tilt=%v distance=%v buttons=%v ts=%v }", DeviceTypeString(this.device), this.tool, this.position, this.pressure, this.tilt, this.distance, this.buttons, this.timestamp)
+	case INPUT_EVENT_PROXIMITYIN:
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=INPUT_EVENT_PROXIMITYIN device=%v tool=%v position=%v ts=%v }", DeviceTypeString(this.device), this.tool, this.position, this.timestamp)
+	case INPUT_EVENT_PROXIMITYOUT:
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=INPUT_EVENT_PROXIMITYOUT device=%v tool=%v position=%v ts=%v }", DeviceTypeString(this.device), this.tool, this.position, this.timestamp)
+	case INPUT_EVENT_BATTERY:
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=INPUT_EVENT_BATTERY device=%v battery=%v ts=%v }", DeviceTypeString(this.device), this.battery, this.timestamp)
 	default:
-		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v ts=%v }", this.event, this.device, this.timestamp)
+		return fmt.Sprintf("<sys.input.InputEvent>{ type=%v device=%v ts=%v }", this.event, DeviceTypeString(this.device), this.timestamp)
+	}
+}
+
+func (d HatDirection) String() string {
+	if d == HAT_CENTRE {
+		return "HAT_CENTRE"
+	}
+	flags := ""
+	if d&HAT_UP != HAT_CENTRE {
+		flags = flags + "|HAT_UP"
+	}
+	if d&HAT_DOWN != HAT_CENTRE {
+		flags = flags + "|HAT_DOWN"
+	}
+	if d&HAT_LEFT != HAT_CENTRE {
+		flags = flags + "|HAT_LEFT"
+	}
+	if d&HAT_RIGHT != HAT_CENTRE {
+		flags = flags + "|HAT_RIGHT"
 	}
+	return strings.TrimLeft(flags, "|")
 }
@@ -12,6 +12,11 @@
 package input
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/djthorpe/gopi"
 	"github.com/djthorpe/gopi/sys/hw/linux"
 )
@@ -23,6 +28,12 @@ const (
 	// Pattern for finding event-driven input devices
 	INPUT_PATH_DEVICES = "/sys/class/input/event*"
 
+	// Folder which contains the device nodes
+	INPUT_PATH_DEVNODES = "/dev/input"
+
+	// Path to the uinput device for virtual devices
+	INPUT_PATH_UINPUT = "/dev/uinput"
+
 	// Maximum multi-touch slots
 	INPUT_MAX_MULTITOUCH_SLOTS = 32
 )
@@ -38,13 +49,141 @@ func init() {
 		Type:     gopi.MODULE_TYPE_INPUT,
 		Config: func(config *gopi.AppConfig) {
 			config.AppFlags.FlagBool("input.exclusive", true, "Input device exclusivity")
+			config.AppFlags.FlagBool("input.autoopen", false, "Open matching devices when plugged in")
+			config.AppFlags.FlagString("input.scale", "", "Absolute position scaling (none, normal or <width>x<height>)")
+			config.AppFlags.FlagString("input.calibration", "", "Folder containing touchscreen calibration files")
+			config.AppFlags.FlagFloat64("input.deadzone", 0, "Joystick axis dead zone between 0.0 and 1.0 (default: reported by device)")
+			config.AppFlags.FlagString("input.repeat", "", "Keyboard repeat (none, <delay>,<period> or software:<delay>,<period>)")
+			config.AppFlags.FlagString("input.touchpad", "pointer", "Touchpad mode (raw, pointer or tap)")
+			config.AppFlags.FlagString("input.rules", "", "File containing device aliases and policy")
 		},
 		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
 			exclusive, _ := app.AppFlags.GetBool("input.exclusive")
-			return gopi.Open(InputManager{
-				FilePoll:  app.ModuleInstance("linux/filepoll").(linux.FilePollInterface),
-				Exclusive: exclusive,
+			auto_open, _ := app.AppFlags.GetBool("input.autoopen")
+			scale, _ := app.AppFlags.GetString("input.scale")
+			calibration_path, _ := app.AppFlags.GetString("input.calibration")
+			dead_zone, _ := app.AppFlags.GetFloat64("input.deadzone")
+			repeat_value, _ := app.AppFlags.GetString("input.repeat")
+			touchpad_value, _ := app.AppFlags.GetString("input.touchpad")
+			rules_path, _ := app.AppFlags.GetString("input.rules")
+			if mode, size, err := parsePositionMode(scale); err != nil {
+				return nil, err
+			} else if repeat, err := parseKeyRepeat(repeat_value); err != nil {
+				return nil, err
+			} else if touchpad, err := parseTouchpad(touchpad_value); err != nil {
+				return nil, err
+			} else if rules, err := parseRules(rules_path); err != nil {
+				return nil, err
+			} else {
+				return gopi.Open(InputManager{
+					FilePoll:        app.ModuleInstance("linux/filepoll").(linux.FilePollInterface),
+					Exclusive:       exclusive,
+					AutoOpen:        auto_open,
+					PositionMode:    mode,
+					Size:            size,
+					CalibrationPath: calibration_path,
+					DeadZone:        float32(dead_zone),
+					Repeat:          repeat,
+					Touchpad:        touchpad,
+					Rules:           rules,
+				}, app.Logger)
+			}
+		},
+	})
+
+	// Register media keys, which emit events for media, volume
+	// and system control keys from the input manager
+	gopi.RegisterModule(gopi.Module{
+		Name:     "input/media",
+		Requires: []string{"input"},
+		Type:     gopi.MODULE_TYPE_OTHER,
+		New: func(app *gopi.AppInstance) (gopi.Driver, error) {
+			return gopi.Open(MediaKeys{
+				InputManager: app.Input,
 			}, app.Logger)
 		},
 	})
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// parsePositionMode returns the position mode and size from the value of
+// the -input.scale flag, which is empty or "none" for raw positions,
+// "normal" for positions between 0 and 1 or "<width>x<height>"
+func parsePositionMode(value string) (PositionMode, gopi.Size, error) {
+	switch value := strings.ToLower(strings.TrimSpace(value)); value {
+	case "", "none", "raw":
+		return POSITION_RAW, gopi.ZeroSize, nil
+	case "normal", "normalised", "normalized":
+		return POSITION_NORMALISED, gopi.ZeroSize, nil
+	default:
+		if wh := strings.SplitN(value, "x", 2); len(wh) != 2 {
+			return POSITION_RAW, gopi.ZeroSize, fmt.Errorf("Invalid -input.scale value: %v", value)
+		} else if w, err := strconv.ParseUint(wh[0], 10, 32); err != nil || w == 0 {
+			return POSITION_RAW, gopi.ZeroSize, fmt.Errorf("Invalid -input.scale value: %v", value)
+		} else if h, err := strconv.ParseUint(wh[1], 10, 32); err != nil || h == 0 {
+			return POSITION_RAW, gopi.ZeroSize, fmt.Errorf("Invalid -input.scale value: %v", value)
+		} else {
+			return POSITION_SCALED, gopi.Size{W: float32(w), H: float32(h)}, nil
+		}
+	}
+}
+
+// parseKeyRepeat returns the key repeat from the value of the -input.repeat
+// flag, which is empty to keep the device settings, "none" to turn off key
+// repeat, "<delay>,<period>" for kernel repeat or "software:<delay>,<period>"
+// for software repeat
+func parseKeyRepeat(value string) (KeyRepeat, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	repeat := KeyRepeat{Mode: REPEAT_KERNEL}
+	switch {
+	case value == "":
+		return KeyRepeat{Mode: REPEAT_DEFAULT}, nil
+	case value == "none" || value == "off":
+		return KeyRepeat{Mode: REPEAT_NONE}, nil
+	case strings.HasPrefix(value, "software:"):
+		repeat.Mode = REPEAT_SOFTWARE
+		value = strings.TrimPrefix(value, "software:")
+	}
+	if delay_period := strings.SplitN(value, ",", 2); len(delay_period) != 2 {
+		return repeat, fmt.Errorf("Invalid -input.repeat value: %v", value)
+	} else if delay, err := time.ParseDuration(delay_period[0]); err != nil || delay <= 0 {
+		return repeat, fmt.Errorf("Invalid -input.repeat value: %v", value)
+	} else if period, err := time.ParseDuration(delay_period[1]); err != nil || period <= 0 {
+		return repeat, fmt.Errorf("Invalid -input.repeat value: %v", value)
+	} else {
+		repeat.Delay, repeat.Period = delay, period
+		return repeat, nil
+	}
+}
+
+// parseTouchpad returns the touchpad settings from the value of the
+// -input.touchpad flag, which is "raw" to report touches, "pointer" to
+// move the pointer and scroll or "tap" to also tap to click
+func parseTouchpad(value string) (Touchpad, error) {
+	touchpad := DefaultTouchpad
+	switch value := strings.ToLower(strings.TrimSpace(value)); value {
+	case "raw", "none":
+		return Touchpad{Mode: TOUCHPAD_RAW}, nil
+	case "", "pointer":
+		return touchpad, nil
+	case "tap":
+		touchpad.Tap = true
+		return touchpad, nil
+	default:
+		return touchpad, fmt.Errorf("Invalid -input.touchpad value: %v", value)
+	}
+}
+
+// parseRules returns the device rules from the file set by the
+// -input.rules flag, or no rules if the flag is empty
+func parseRules(path string) ([]DeviceRule, error) {
+	if path == "" {
+		return nil, nil
+	} else if rules, err := ReadDeviceRules(path); err != nil {
+		return nil, fmt.Errorf("Invalid -input.rules file: %v", err)
+	} else {
+		return rules, nil
+	}
+}
@@ -13,6 +13,9 @@ package input
 
 import (
 	"fmt"
+	"os"
+	"sync"
+	"syscall"
 
 	// Frameworks
 
@@ -31,6 +34,32 @@ type InputManager struct {
 
 	// Whether to try and get exclusivity when opening devices
 	Exclusive bool
+
+	// Whether to open devices which are plugged in whilst running
+	// and which match the filters used with OpenDevicesByName
+	AutoOpen bool
+
+	// Scaling of absolute positions, and the screen size
+	// when positions are scaled
+	PositionMode PositionMode
+	Size         gopi.Size
+
+	// Folder containing calibration files for devices
+	CalibrationPath string
+
+	// Dead zone for joystick axes between 0.0 and 1.0, or zero
+	// to use the dead zone reported by each device
+	DeadZone float32
+
+	// Key repeat for keyboards, or REPEAT_DEFAULT to keep
+	// the settings of each device
+	Repeat KeyRepeat
+
+	// Touchpad settings, or TOUCHPAD_RAW to report touches
+	Touchpad Touchpad
+
+	// Rules which set the alias and policy for devices
+	Rules []DeviceRule
 }
 
 // Driver of multiple input devices
@@ -44,18 +73,52 @@ type manager struct {
 	// Whether to try and get exclusivity when opening devices
 	exclusive bool
 
+	// Whether to open newly plugged in devices
+	auto_open bool
+
+	// Scaling of absolute positions
+	position_mode    PositionMode
+	size             gopi.Size
+	calibration_path string
+	dead_zone        float32
+	repeat           KeyRepeat
+	touchpad         Touchpad
+	rules            []DeviceRule
+
 	// List of open devices
 	devices []gopi.InputDevice
 
+	// Filters used to open devices
+	filters []filter
+
+	// Handle for watching device nodes, and the paths of
+	// device nodes which have been reported as added
+	watch   *os.File
+	plugged map[string]bool
+
+	// Lock for the list of devices, and the paths of devices
+	// which are being opened
+	lock    sync.Mutex
+	opening map[string]bool
+
 	// event merger (also acts as publisher)
 	event.Merger
 }
 
+// Represents a filter used to open devices, and the matcher
+// parsed from the alias
+type filter struct {
+	alias   string
+	flags   gopi.InputDeviceType
+	bus     gopi.InputDeviceBus
+	matcher *Matcher
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // OPEN AND CLOSE
 
 func (config InputManager) Open(log gopi.Logger) (gopi.Driver, error) {
-	log.Debug("<sys.input.InputManager.Open>{ exclusive=%v }", config.Exclusive)
+	log.Debug("<sys.input.InputManager.Open>{ exclusive=%v auto_open=%v position_mode=%v size=%v calibration_path=%v dead_zone=%v repeat=%v touchpad=%v rules=%v }", config.Exclusive, config.AutoOpen, config.PositionMode, config.Size, config.CalibrationPath, config.DeadZone, config.Repeat, config.Touchpad, config.Rules)
 
 	// create new input device manager
 	this := new(manager)
@@ -63,11 +126,41 @@ func (config InputManager) Open(log gopi.Logger) (gopi.Driver, error) {
 	if config.FilePoll == nil {
 		return nil, gopi.ErrBadParameter
 	}
+	if config.DeadZone < 0 || config.DeadZone >= 1 {
+		return nil, gopi.ErrBadParameter
+	}
 
 	this.exclusive = config.Exclusive
+	this.auto_open = config.AutoOpen
+	this.position_mode = config.PositionMode
+	this.size = config.Size
+	this.calibration_path = config.CalibrationPath
+	this.dead_zone = config.DeadZone
+	this.repeat = config.Repeat
+	this.touchpad = config.Touchpad
+	this.rules = config.Rules
 	this.log = log
 	this.filepoll = config.FilePoll
 	this.devices = make([]gopi.InputDevice, 0)
+	this.filters = make([]filter, 0)
+	this.plugged = make(map[string]bool)
+	this.opening = make(map[string]bool)
+
+	// Watch for devices being plugged in and removed. Failure
+	// to watch is not fatal, but devices won't be hotplugged
+	if watch, err := evWatchDevices(INPUT_PATH_DEVNODES); err != nil {
+		this.log.Warn("<sys.input.InputManager.Open> Not watching for devices: %v", err)
+	} else if err := this.filepoll.Watch(watch, linux.FILEPOLL_MODE_READ, this.evHotplug); err != nil {
+		watch.Close()
+		this.log.Warn("<sys.input.InputManager.Open> Not watching for devices: %v", err)
+	} else {
+		this.watch = watch
+	}
+
+	// Devices which already exist are not reported as added
+	evFind(func(path string) {
+		this.plugged[path] = true
+	})
 
 	// success
 	return this, nil
@@ -77,12 +170,21 @@ func (config InputManager) Open(log gopi.Logger) (gopi.Driver, error) {
 func (this *manager) Close() error {
 	this.log.Debug("<sys.input.InputManager.Close>{ }")
 
+	// Stop watching for devices
+	if this.watch != nil {
+		if err := this.filepoll.Unwatch(this.watch); err != nil {
+			this.log.Warn("<sys.input.InputManager.Close> Error: %v", err)
+		}
+		if err := this.watch.Close(); err != nil {
+			this.log.Warn("<sys.input.InputManager.Close> Error: %v", err)
+		}
+		this.watch = nil
+	}
+
 	// Close open devices
-	for _, device := range this.devices {
-		if device != nil {
-			if err := this.CloseDevice(device); err != nil {
-				this.log.Warn("<sys.input.InputManager.Close> Error: %v", err)
-			}
+	for _, device := range this.GetOpenDevices() {
+		if err := this.CloseDevice(device); err != nil {
+			this.log.Warn("<sys.input.InputManager.Close> Error: %v", err)
 		}
 	}
 
@@ -94,6 +196,8 @@ func (this *manager) Close() error {
 	// Empty
 	this.filepoll = nil
 	this.devices = nil
+	this.filters = nil
+	this.plugged = nil
 
 	return nil
 }
@@ -102,49 +206,41 @@ func (this *manager) Close() error {
 // STRINGIFY
 
 func (this *manager) String() string {
-	return fmt.Sprintf("<sys.input.InputManager>{ exclusive=%v }", this.exclusive)
+	return fmt.Sprintf("<sys.input.InputManager>{ exclusive=%v auto_open=%v }", this.exclusive, this.auto_open)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 // OPEN AND CLOSE DEVICES
 
 // OpenDevicesByName can be called often in order to open any newly plugged in
-// devices. It will only return any newly opened devices.
+// devices. It will only return any newly opened devices. The alias is a name,
+// uniq or phys value, an alias from the device rules or a matcher, as parsed
+// by ParseMatcher
 func (this *manager) OpenDevicesByName(alias string, flags gopi.InputDeviceType, bus gopi.InputDeviceBus) ([]gopi.InputDevice, error) {
 	this.log.Debug2("<sys.input.InputManager.OpenDevicesByName>{ alias='%v' flags=%v bus=%v }", alias, flags, bus)
 
+	// Parse the alias once for all devices
+	matcher, err := ParseMatcher(alias)
+	if err != nil {
+		return nil, err
+	}
+	f := filter{alias, flags, bus, matcher}
+
 	opened_devices := make([]gopi.InputDevice, 0)
-	new_devices := make([]gopi.InputDevice, 0)
 
-	// Discover devices using evFind and add any new ones to the new_devices
-	// array, they are left in an opened state
+	// Remember the filter so that devices plugged in later can be opened
+	this.addFilter(f)
+
+	// Discover devices using evFind and open any new ones which match
+	// the filter
 	evFind(func(path string) {
 		this.log.Debug2("<evFind>{ path=%v }", path)
-		// Don't consider devices which are already opened
-		if this.deviceByPath(path) == nil {
-			if input_device, err := gopi.Open(InputDevice{Path: path, Exclusive: this.exclusive, FilePoll: this.filepoll}, this.log); err != nil {
-				this.log.Warn("OpenDevicesByName: %v: %v", path, err)
-			} else {
-				this.log.Debug2("OpenDevicesByName: Adding device %v", input_device)
-				new_devices = append(new_devices, input_device.(gopi.InputDevice))
-			}
-		}
-	})
-
-	// Now check devices against filters and close devices which don't match
-	for _, device := range new_devices {
-		if device.Matches(alias, flags, bus) {
+		if device, err := this.openDevice(path, []filter{f}); err != nil {
+			this.log.Warn("OpenDevicesByName: %v: %v", path, err)
+		} else if device != nil {
 			opened_devices = append(opened_devices, device)
-		} else if err := device.Close(); err != nil {
-			this.log.Warn("OpenDevicesByName: %v", err)
 		}
-	}
-
-	// Subscribe to events from device
-	for _, device := range opened_devices {
-		this.Merger.Merge(device)
-		this.devices = append(this.devices, device)
-	}
+	})
 
 	return opened_devices, nil
 }
@@ -152,6 +248,9 @@ func (this *manager) OpenDevicesByName(alias string, flags gopi.InputDeviceType,
 func (this *manager) CloseDevice(device_ gopi.InputDevice) error {
 	this.log.Debug2("<sys.input.InputManager.CloseDevice>{ device=%v }", device_)
 
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	// Find device in array of devices
 	found := -1
 	for i, d := range this.devices {
@@ -184,6 +283,9 @@ func (this *manager) CloseDevice(device_ gopi.InputDevice) error {
 // RETURN OPENED DEVICES
 
 func (this *manager) GetOpenDevices() []gopi.InputDevice {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	devices := make([]gopi.InputDevice, 0, len(this.devices))
 	for _, device := range this.devices {
 		if device != nil {
@@ -193,12 +295,23 @@ func (this *manager) GetOpenDevices() []gopi.InputDevice {
 	return devices
 }
 
+// DeviceByID returns the opened device with a device ID, or nil if
+// no opened device has the device ID
+func (this *manager) DeviceByID(device_id uint32) gopi.InputDevice {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.deviceByID(device_id)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // ADD NEW INPUT DEVICE
 
 func (this *manager) AddDevice(device gopi.InputDevice) error {
 	this.log.Debug2("<sys.input.InputManager.AddDevice>{ device=%v }", device)
 
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	// If device is already added, then return bad parameter error
 	for _, d := range this.devices {
 		if device == d {
@@ -212,12 +325,161 @@ func (this *manager) AddDevice(device gopi.InputDevice) error {
 	return nil
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// HOTPLUG
+
+// evHotplug is called when device nodes are created or removed
+func (this *manager) evHotplug(handle *os.File, mode linux.FilePollMode) {
+	if err := evReadWatchEvents(handle, func(path string, action evWatchAction) {
+		this.log.Debug2("<sys.input.InputManager.Hotplug>{ path=%v action=%v }", path, action)
+		switch action {
+		case EV_WATCH_CREATE, EV_WATCH_ATTRIB:
+			this.deviceAdded(path, action)
+		case EV_WATCH_DELETE:
+			this.deviceRemoved(path)
+		}
+	}); err != nil {
+		this.log.Error("sys.input.linux.InputManager.Hotplug: %v", err)
+	}
+}
+
+// deviceAdded reports a newly plugged in device and opens it
+// if it matches any of the filters. When the device node is
+// created it may not be accessible until the permissions have
+// been set, in which case the report is deferred until the
+// attributes change and the device is accessible
+func (this *manager) deviceAdded(path string, action evWatchAction) {
+	if this.isPlugged(path) {
+		return
+	}
+	if err := syscall.Access(path, evAccessReadWrite); err != nil {
+		this.log.Debug("<sys.input.InputManager.Hotplug> Deferring %v (%v): %v", path, action, err)
+		return
+	}
+
+	// Open the device once if it matches any of the filters
+	var device gopi.InputDevice
+	if filters := this.getFilters(); this.auto_open && len(filters) > 0 {
+		if d, err := this.openDevice(path, filters); err != nil {
+			this.log.Warn("Hotplug: %v: %v", path, err)
+		} else {
+			device = d
+		}
+	}
+
+	// Emit added event
+	this.setPlugged(path, true)
+	this.Merger.Emit(NewDeviceEvent(this, DEVICE_EVENT_ADDED, path, device))
+}
+
+// deviceRemoved closes a device which has been removed
+// and reports the removal
+func (this *manager) deviceRemoved(path string) {
+	this.lock.Lock()
+	device := this.deviceByPath(path)
+	this.lock.Unlock()
+	if device != nil {
+		if err := this.CloseDevice(device); err != nil {
+			this.log.Warn("Hotplug: %v: %v", path, err)
+		}
+	}
+	if this.isPlugged(path) {
+		this.setPlugged(path, false)
+		this.Merger.Emit(NewDeviceEvent(this, DEVICE_EVENT_REMOVED, path, device))
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// openDevice opens a device which is not already opened and adds it to the
+// list of devices if it matches any of the filters. Returns nil if the device
+// was already opened or doesn't match
+func (this *manager) openDevice(path string, filters []filter) (gopi.InputDevice, error) {
+	// Don't consider devices which are already opened, or which are
+	// being opened from another goroutine
+	this.lock.Lock()
+	if this.deviceByPath(path) != nil || this.opening[path] {
+		this.lock.Unlock()
+		return nil, nil
+	} else {
+		this.opening[path] = true
+		this.lock.Unlock()
+	}
+	defer func() {
+		this.lock.Lock()
+		delete(this.opening, path)
+		this.lock.Unlock()
+	}()
+
+	input_device, err := gopi.Open(InputDevice{
+		Path:            path,
+		Exclusive:       this.exclusive,
+		FilePoll:        this.filepoll,
+		PositionMode:    this.position_mode,
+		Size:            this.size,
+		CalibrationPath: this.calibration_path,
+		DeadZone:        this.dead_zone,
+		Repeat:          this.repeat,
+		Touchpad:        this.touchpad,
+		Rules:           this.rules,
+	}, this.log)
+	if err == ErrIgnoredDevice {
+		this.log.Debug("OpenDevicesByName: Ignoring %v", path)
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	device := input_device.(gopi.InputDevice)
+	if matchesAnyFilter(device, filters) == false {
+		if err := device.Close(); err != nil {
+			this.log.Warn("OpenDevicesByName: %v", err)
+		}
+		return nil, nil
+	}
+
+	// Check again that the device hasn't been opened in the meantime
+	// and add it to the list of devices, holding the lock so that
+	// devices are checked and added together
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.deviceByPath(path) != nil {
+		if err := device.Close(); err != nil {
+			this.log.Warn("OpenDevicesByName: %v", err)
+		}
+		return nil, nil
+	}
+
+	// Identical devices which report the same unique identifier
+	// have the same device ID, which is made unique
+	this.uniqueDeviceID(device)
+
+	// Subscribe to events from device
+	this.log.Debug2("OpenDevicesByName: Adding device %v", device)
+	this.Merger.Merge(device)
+	this.devices = append(this.devices, device)
+
+	return device, nil
+}
+
+// matchesAnyFilter returns true if the device matches any of the filters,
+// using the parsed matcher for linux devices
+func matchesAnyFilter(input_device gopi.InputDevice, filters []filter) bool {
+	for _, f := range filters {
+		if linux_device, is_linux := input_device.(*device); is_linux && f.matcher != nil {
+			if linux_device.matches(f.matcher, f.flags, f.bus) {
+				return true
+			}
+		} else if input_device.Matches(f.alias, f.flags, f.bus) {
+			return true
+		}
+	}
+	return false
+}
+
 // deviceByPath returns an opened device based on it's path, assuming
 // it is a linux device or returns nil if a device with this path is
-// not found
+// not found. The lock must be held when calling
 func (this *manager) deviceByPath(path string) gopi.InputDevice {
 	for _, d := range this.devices {
 		if linux_device, is_linux := d.(*device); is_linux {
@@ -228,3 +490,66 @@ func (this *manager) deviceByPath(path string) gopi.InputDevice {
 	}
 	return nil
 }
+
+// deviceByID returns an opened device with a device ID, or nil if
+// no device has the device ID. The lock must be held when calling
+func (this *manager) deviceByID(device_id uint32) gopi.InputDevice {
+	for _, device := range this.devices {
+		if identity, ok := device.(IdentityDevice); ok && identity.DeviceID() == device_id {
+			return device
+		}
+	}
+	return nil
+}
+
+// uniqueDeviceID changes the device ID of a linux device when another
+// opened device has the same ID. The lock must be held when calling
+func (this *manager) uniqueDeviceID(input_device gopi.InputDevice) {
+	if linux_device, is_linux := input_device.(*device); is_linux {
+		if other := this.deviceByID(linux_device.device_id); other != nil {
+			linux_device.evUniqueDeviceID(evGetSysfsPath(linux_device.path), func(device_id uint32) bool {
+				return this.deviceByID(device_id) != nil
+			})
+			this.log.Debug("OpenDevicesByName: %v has the same device ID as %v, using 0x%08X", linux_device.Name(), other.Name(), linux_device.device_id)
+		}
+	}
+}
+
+// addFilter stores a filter for opening devices which are plugged
+// in later, ignoring duplicates
+func (this *manager) addFilter(f filter) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	for _, other := range this.filters {
+		if other.alias == f.alias && other.flags == f.flags && other.bus == f.bus {
+			return
+		}
+	}
+	this.filters = append(this.filters, f)
+}
+
+func (this *manager) getFilters() []filter {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return append([]filter{}, this.filters...)
+}
+
+func (this *manager) isPlugged(path string) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.plugged[path]
+}
+
+func (this *manager) setPlugged(path string, state bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if state {
+		this.plugged[path] = true
+	} else {
+		delete(this.plugged, path)
+	}
+}
@@ -0,0 +1,75 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST HOTPLUG
+
+func TestHotplug_000(t *testing.T) {
+	folder, err := ioutil.TempDir("", "input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	path := filepath.Join(folder, "event99")
+	this := &manager{log: &testLogger{t}, plugged: make(map[string]bool)}
+
+	// A device which can't be accessed is not added when it's
+	// created or its attributes change
+	this.deviceAdded(path, EV_WATCH_CREATE)
+	this.deviceAdded(path, EV_WATCH_ATTRIB)
+	if this.isPlugged(path) {
+		t.Error("Unexpected device added before it is accessible")
+	}
+
+	// The device is added once it can be accessed
+	if err := ioutil.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	this.deviceAdded(path, EV_WATCH_ATTRIB)
+	if this.isPlugged(path) == false {
+		t.Error("Expected device to be added")
+	}
+	this.deviceRemoved(path)
+	if this.isPlugged(path) {
+		t.Error("Expected device to be removed")
+	}
+}
+
+func TestHotplug_001(t *testing.T) {
+	// A device which is being opened from another goroutine, or which
+	// is already opened, is not opened again
+	path := "/dev/input/event99"
+	this := &manager{log: &testLogger{t}, opening: map[string]bool{path: true}}
+	if device, err := this.openDevice(path, nil); device != nil || err != nil {
+		t.Errorf("Unexpected device opened: %v, %v", device, err)
+	}
+	keyboard := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	keyboard.path = "/dev/input/event98"
+	this.devices = append(this.devices, keyboard)
+	if device, err := this.openDevice(keyboard.path, nil); device != nil || err != nil {
+		t.Errorf("Unexpected device opened: %v, %v", device, err)
+	}
+	if len(this.opening) != 1 {
+		t.Errorf("Unexpected devices being opened: %v", this.opening)
+	}
+}
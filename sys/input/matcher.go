@@ -0,0 +1,426 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Matcher matches input devices against a set of terms, all of which
+// must match. An empty matcher matches any device
+type Matcher struct {
+	terms []matcherTerm
+}
+
+// A term of a matcher, which is negated when the term starts with '!'
+type matcherTerm struct {
+	text   string
+	negate bool
+	match  func(device gopi.InputDevice, alias string) bool
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Separator between the terms of a matcher
+	MATCHER_SEPARATOR = ";"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	matcher_id     = regexp.MustCompile("^([0-9A-Fa-f]{1,4}|\\*):([0-9A-Fa-f]{1,4}|\\*)$")
+	matcher_field  = regexp.MustCompile("^(name|phys|uniq|alias|bus|has|controls)([=~])(.*)$")
+	matcher_codes  map[string]interface{}
+	matcher_busses map[string]gopi.InputDeviceBus
+	matcher_names  struct{ types, busses []string }
+	matcher_once   sync.Once
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// MATCHER
+
+// ParseMatcher returns a matcher from terms separated by ';', where
+// each term is one of the following:
+//
+//	<vendor>:<product>  Vendor and product IDs in hexadecimal, or '*' for any
+//	name=<pattern>      Name matches a pattern, where '*' matches any characters
+//	                    and '?' matches any single character
+//	name~<regexp>       Name matches a regular expression
+//	phys=, phys~        Physical path matches a pattern or regular expression
+//	uniq=, uniq~        Unique identifier matches a pattern or regular expression
+//	alias=, alias~      Alias from the device rules matches a pattern or regular expression
+//	bus=<bus>,...       Device is connected to one of the busses (usb, bluetooth, ...)
+//	has=<code>,...      Device supports all the keys and buttons (leftctrl,
+//	                    btnleft, ...), axes (rel_wheel, abs_x, ...) and
+//	                    properties (input_prop_direct, ...)
+//	controls=<type>,... Device is one of the types of control device
+//	                    (consumer, system)
+//	<alias>             Name, uniq, phys or the alias from the device rules
+//	                    is equal to the alias, or matches it as a pattern
+//
+// A term which starts with '!' matches devices which don't match the term
+func ParseMatcher(value string) (*Matcher, error) {
+	this := new(Matcher)
+	this.terms = make([]matcherTerm, 0)
+	if strings.TrimSpace(value) == "" {
+		return this, nil
+	}
+	for _, text := range strings.Split(value, MATCHER_SEPARATOR) {
+		term := matcherTerm{text: strings.TrimSpace(text)}
+		value := term.text
+		if strings.HasPrefix(value, "!") {
+			term.negate = true
+			value = strings.TrimSpace(strings.TrimPrefix(value, "!"))
+		}
+		if value == "" {
+			return nil, fmt.Errorf("Invalid matcher: %v", strconv.Quote(text))
+		} else if match, err := matcherParseTerm(value); err != nil {
+			return nil, err
+		} else {
+			term.match = match
+			this.terms = append(this.terms, term)
+		}
+	}
+	return this, nil
+}
+
+// Matches returns true if the device matches all the terms
+func (this *Matcher) Matches(device gopi.InputDevice) bool {
+	if identity, ok := device.(IdentityDevice); ok {
+		return this.matches(device, identity.Alias())
+	} else {
+		return this.matches(device, "")
+	}
+}
+
+// matches returns true if the device with an alias matches all the terms
+func (this *Matcher) matches(device gopi.InputDevice, alias string) bool {
+	for _, term := range this.terms {
+		if term.match(device, alias) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// DEVICE TYPE AND BUS
+
+// ParseDeviceBus returns the device bus from a comma-separated list of
+// names (usb, bluetooth, ...) as used by the -bus flag
+func ParseDeviceBus(value string) (gopi.InputDeviceBus, error) {
+	device_bus := gopi.INPUT_BUS_NONE
+	if busses, err := matcherParseBusses(value); err != nil {
+		return gopi.INPUT_BUS_NONE, err
+	} else {
+		for _, bus := range busses {
+			device_bus |= bus
+		}
+	}
+	return device_bus, nil
+}
+
+// ParseFilter returns the name, device types and bus to pass to
+// OpenDevicesByName from the values of the -name, -type and -bus
+// flags, where an empty type or bus matches any device
+func ParseFilter(name, device_type, device_bus string) (string, gopi.InputDeviceType, gopi.InputDeviceBus, error) {
+	flags, bus := gopi.INPUT_TYPE_ANY, gopi.INPUT_BUS_ANY
+	if strings.TrimSpace(device_type) != "" {
+		if t, err := ParseDeviceType(device_type); err != nil {
+			return "", gopi.INPUT_TYPE_NONE, gopi.INPUT_BUS_NONE, err
+		} else {
+			flags = t
+		}
+	}
+	if strings.TrimSpace(device_bus) != "" {
+		if b, err := ParseDeviceBus(device_bus); err != nil {
+			return "", gopi.INPUT_TYPE_NONE, gopi.INPUT_BUS_NONE, err
+		} else {
+			bus = b
+		}
+	}
+	return name, flags, bus, nil
+}
+
+// DeviceTypeNames returns the names of the device types which
+// can be parsed by ParseDeviceType
+func DeviceTypeNames() []string {
+	matcherInit()
+	return append([]string{}, matcher_names.types...)
+}
+
+// DeviceBusNames returns the names of the device busses which
+// can be parsed by ParseDeviceBus
+func DeviceBusNames() []string {
+	matcherInit()
+	return append([]string{}, matcher_names.busses...)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// matcherParseTerm returns a function which returns true when
+// a device matches a term
+func matcherParseTerm(value string) (func(gopi.InputDevice, string) bool, error) {
+	if id := matcher_id.FindStringSubmatch(value); id != nil {
+		// Vendor and product ID
+		vendor, product := matcherParseID(id[1]), matcherParseID(id[2])
+		return func(device gopi.InputDevice, _ string) bool {
+			if identity, ok := device.(IdentityDevice); ok == false {
+				return false
+			} else if vendor >= 0 && int32(identity.Vendor()) != vendor {
+				return false
+			} else if product >= 0 && int32(identity.Product()) != product {
+				return false
+			} else {
+				return true
+			}
+		}, nil
+	} else if field := matcher_field.FindStringSubmatch(value); field == nil {
+		// Name, uniq, phys or alias
+		pattern := matcherPattern(value)
+		return func(device gopi.InputDevice, alias string) bool {
+			name, phys, uniq := matcherIdentity(device)
+			for _, value := range []string{name, phys, uniq, alias} {
+				if value != "" && pattern.MatchString(value) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	} else if field[1] == "bus" && field[2] == "=" {
+		// One of the busses
+		busses, err := matcherParseBusses(field[3])
+		if err != nil {
+			return nil, err
+		}
+		return func(device gopi.InputDevice, _ string) bool {
+			for _, bus := range busses {
+				if device.Bus() == bus {
+					return true
+				}
+			}
+			return false
+		}, nil
+	} else if field[1] == "has" && field[2] == "=" {
+		// All the codes and properties
+		codes, err := matcherParseCodes(field[3])
+		if err != nil {
+			return nil, err
+		}
+		return func(device gopi.InputDevice, _ string) bool {
+			if caps_device, ok := device.(CapabilitiesDevice); ok == false {
+				return false
+			} else {
+				return matcherHasCodes(caps_device.Capabilities(), codes)
+			}
+		}, nil
+	} else if field[1] == "controls" && field[2] == "=" {
+		// One of the types of control device
+		controls, err := matcherParseControls(field[3])
+		if err != nil {
+			return nil, err
+		}
+		return func(device gopi.InputDevice, _ string) bool {
+			if control_device, ok := device.(ControlDevice); ok == false {
+				return false
+			} else {
+				return control_device.Controls()&controls != 0
+			}
+		}, nil
+	} else if field[1] == "bus" || field[1] == "has" || field[1] == "controls" {
+		return nil, fmt.Errorf("Invalid matcher: %v", strconv.Quote(value))
+	} else {
+		// Name, phys, uniq or alias with a pattern or regular expression
+		pattern := matcherPattern(field[3])
+		if field[2] == "~" {
+			if expr, err := regexp.Compile(field[3]); err != nil {
+				return nil, fmt.Errorf("Invalid matcher: %v: %v", strconv.Quote(value), err)
+			} else {
+				pattern = expr
+			}
+		}
+		return func(device gopi.InputDevice, alias string) bool {
+			name, phys, uniq := matcherIdentity(device)
+			switch field[1] {
+			case "phys":
+				return pattern.MatchString(phys)
+			case "uniq":
+				return pattern.MatchString(uniq)
+			case "alias":
+				return pattern.MatchString(alias)
+			default:
+				return pattern.MatchString(name)
+			}
+		}, nil
+	}
+}
+
+// matcherParseID returns a vendor or product ID, or -1 for any
+func matcherParseID(value string) int32 {
+	if id, err := strconv.ParseUint(value, 16, 16); err != nil {
+		return -1
+	} else {
+		return int32(id)
+	}
+}
+
+// matcherParseBusses returns busses from a comma-separated list of names
+func matcherParseBusses(value string) ([]gopi.InputDeviceBus, error) {
+	matcherInit()
+	busses := make([]gopi.InputDeviceBus, 0)
+	for _, name := range strings.Split(value, ",") {
+		if bus, exists := matcher_busses[strings.ToLower(strings.TrimSpace(name))]; exists == false {
+			return nil, fmt.Errorf("Invalid bus: %v", name)
+		} else {
+			busses = append(busses, bus)
+		}
+	}
+	return busses, nil
+}
+
+// matcherParseControls returns the types of control device from a
+// comma-separated list of names
+func matcherParseControls(value string) (ControlType, error) {
+	controls := CONTROL_NONE
+	for _, name := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "consumer":
+			controls |= CONTROL_CONSUMER
+		case "system":
+			controls |= CONTROL_SYSTEM
+		default:
+			return CONTROL_NONE, fmt.Errorf("Invalid controls: %v", name)
+		}
+	}
+	return controls, nil
+}
+
+// matcherParseCodes returns key codes, axes and properties from a
+// comma-separated list of names
+func matcherParseCodes(value string) ([]interface{}, error) {
+	matcherInit()
+	codes := make([]interface{}, 0)
+	for _, name := range strings.Split(value, ",") {
+		if code, exists := matcher_codes[strings.ToLower(strings.TrimSpace(name))]; exists {
+			codes = append(codes, code)
+		} else if key_code, err := ParseKeyCode(strings.TrimSpace(name)); err == nil {
+			codes = append(codes, key_code)
+		} else {
+			return nil, fmt.Errorf("Invalid code: %v", name)
+		}
+	}
+	return codes, nil
+}
+
+// matcherHasCodes returns true if the capabilities include all
+// the codes and properties
+func matcherHasCodes(caps Capabilities, codes []interface{}) bool {
+	for _, code := range codes {
+		switch code := code.(type) {
+		case gopi.KeyCode:
+			if caps.HasKey(code) == false {
+				return false
+			}
+		case RelAxis:
+			if caps.HasRelAxis(code) == false {
+				return false
+			}
+		case AbsAxis:
+			if caps.HasAbsAxis(code) == false {
+				return false
+			}
+		case InputProperty:
+			if caps.HasProperty(code) == false {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matcherPattern returns a regular expression for a pattern, where
+// '*' matches any characters and '?' matches any single character
+func matcherPattern(value string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(value)
+	expr = strings.Replace(expr, "\\*", ".*", -1)
+	expr = strings.Replace(expr, "\\?", ".", -1)
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+// matcherIdentity returns the name, phys and uniq of a device
+func matcherIdentity(device gopi.InputDevice) (string, string, string) {
+	if identity, ok := device.(IdentityDevice); ok {
+		return device.Name(), identity.Phys(), identity.Uniq()
+	} else {
+		return device.Name(), "", ""
+	}
+}
+
+// matcherInit creates the maps of lowercase names to busses,
+// axes and properties, and the names of device types
+func matcherInit() {
+	matcher_once.Do(func() {
+		matcher_codes = make(map[string]interface{})
+		matcher_busses = make(map[string]gopi.InputDeviceBus)
+		for t := gopi.INPUT_TYPE_NONE; t < gopi.INPUT_TYPE_ANY; t++ {
+			if name := DeviceTypeString(t); strings.HasPrefix(name, "INPUT_TYPE_") && strings.Contains(name, "|") == false {
+				name = strings.ToLower(strings.TrimPrefix(name, "INPUT_TYPE_"))
+				matcher_names.types = append(matcher_names.types, name)
+			}
+		}
+		for bus := gopi.INPUT_BUS_NONE; bus < gopi.INPUT_BUS_ANY; bus++ {
+			if name := fmt.Sprint(bus); strings.HasPrefix(name, "INPUT_BUS_") {
+				name = strings.ToLower(strings.TrimPrefix(name, "INPUT_BUS_"))
+				matcher_busses[name] = bus
+				matcher_names.busses = append(matcher_names.busses, name)
+			}
+		}
+		for axis := REL_X; axis <= REL_MAX; axis++ {
+			if name := fmt.Sprint(axis); strings.HasPrefix(name, "REL_") {
+				matcher_codes[strings.ToLower(name)] = axis
+			}
+		}
+		for axis := ABS_X; axis <= ABS_MAX; axis++ {
+			if name := fmt.Sprint(axis); strings.HasPrefix(name, "ABS_") {
+				matcher_codes[strings.ToLower(name)] = axis
+			}
+		}
+		for property := INPUT_PROP_POINTER; property <= INPUT_PROP_MAX; property++ {
+			if name := fmt.Sprint(property); strings.HasPrefix(name, "INPUT_PROP_") {
+				matcher_codes[strings.ToLower(name)] = property
+			}
+		}
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Matcher) String() string {
+	terms := make([]string, len(this.terms))
+	for i, term := range this.terms {
+		terms[i] = strconv.Quote(term.text)
+	}
+	return fmt.Sprintf("<input.Matcher>{ terms=[%v] }", strings.Join(terms, " "))
+}
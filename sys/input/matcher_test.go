@@ -0,0 +1,147 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"testing"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST MATCHER
+
+func TestMatcher_000(t *testing.T) {
+	for _, invalid := range []string{"!", "a;;b", "bus=usb,firewire", "bus~usb", "has=nokey", "has~btnleft", "controls=media", "controls~consumer", "name~("} {
+		if _, err := ParseMatcher(invalid); err == nil {
+			t.Errorf("Expected error for %v", invalid)
+		}
+	}
+}
+
+func TestMatcher_001(t *testing.T) {
+	mouse := testDevice(t, gopi.INPUT_TYPE_MOUSE)
+	mouse.name, mouse.phys, mouse.bus = "Logitech USB Optical Mouse", "usb-3f980000.usb-1.2/input0", gopi.INPUT_BUS_USB
+	mouse.vendor, mouse.product = 0x046D, 0xC077
+	mouse.caps.Keys = []gopi.KeyCode{gopi.KEYCODE_BTNLEFT, gopi.KEYCODE_BTNRIGHT}
+	mouse.caps.RelAxes = []RelAxis{REL_X, REL_Y, REL_WHEEL}
+	touchscreen := testDevice(t, gopi.INPUT_TYPE_TOUCHSCREEN)
+	touchscreen.name, touchscreen.uniq, touchscreen.bus = "FT5406 memory based driver", "ft5406", gopi.INPUT_BUS_HOST
+	touchscreen.abs_info = map[evKeyCode]evAbsInfo{EV_CODE_X: {Maximum: 799}, EV_CODE_Y: {Maximum: 479}}
+	touchscreen.caps.Properties = []InputProperty{INPUT_PROP_DIRECT}
+
+	tests := []struct {
+		value       string
+		mouse       bool
+		touchscreen bool
+	}{
+		{"", true, true},
+		{"Logitech USB Optical Mouse", true, false},
+		{"ft5406", false, true},
+		{"Logitech", false, false},
+		{"Logitech*", true, false},
+		{"usb-*/input?", true, false},
+		{"046d:c077", true, false},
+		{"46D:*", true, false},
+		{"*:c078", false, false},
+		{"name=*Mouse", true, false},
+		{"name~(?i)^ft[0-9]+ ", false, true},
+		{"phys~^usb-", true, false},
+		{"uniq=ft5406", false, true},
+		{"bus=usb,bluetooth", true, false},
+		{"has=btnleft,rel_wheel", true, false},
+		{"has=abs_x;has=INPUT_PROP_DIRECT", false, true},
+		{"!has=btnleft", false, true},
+		{"bus=usb;!046d:*", false, false},
+		{"!name=FT*;!phys=usb-*", false, false},
+	}
+	for _, test := range tests {
+		if matcher, err := ParseMatcher(test.value); err != nil {
+			t.Errorf("%v: %v", test.value, err)
+		} else if matcher.Matches(mouse) != test.mouse {
+			t.Errorf("%v: Expected mouse match to be %v", matcher, test.mouse)
+		} else if matcher.Matches(touchscreen) != test.touchscreen {
+			t.Errorf("%v: Expected touchscreen match to be %v", matcher, test.touchscreen)
+		}
+	}
+
+	// The device type and bus are matched as well as the alias
+	if mouse.Matches("046d:c077", gopi.INPUT_TYPE_KEYBOARD, gopi.INPUT_BUS_ANY) {
+		t.Error("Unexpected match for keyboard")
+	} else if mouse.Matches("046d:c077", gopi.INPUT_TYPE_MOUSE, gopi.INPUT_BUS_USB) == false {
+		t.Error("Expected match for mouse")
+	}
+}
+
+func TestMatcher_002(t *testing.T) {
+	keyboard := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	keyboard.name = "Logitech USB Receiver"
+	consumer := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	consumer.name, consumer.controls = "Logitech USB Receiver Consumer Control", CONTROL_CONSUMER
+
+	tests := []struct {
+		value    string
+		keyboard bool
+		consumer bool
+	}{
+		{"controls=consumer", false, true},
+		{"controls=system", false, false},
+		{"controls=system,consumer", false, true},
+		{"Logitech*;!controls=consumer,system", true, false},
+	}
+	for _, test := range tests {
+		if matcher, err := ParseMatcher(test.value); err != nil {
+			t.Errorf("%v: %v", test.value, err)
+		} else if matcher.Matches(keyboard) != test.keyboard {
+			t.Errorf("%v: Expected keyboard match to be %v", matcher, test.keyboard)
+		} else if matcher.Matches(consumer) != test.consumer {
+			t.Errorf("%v: Expected consumer control match to be %v", matcher, test.consumer)
+		}
+	}
+}
+
+func TestMatcher_003(t *testing.T) {
+	tests := []struct {
+		device_type string
+		device_bus  string
+		flags       gopi.InputDeviceType
+		bus         gopi.InputDeviceBus
+		err         bool
+	}{
+		{"", "", gopi.INPUT_TYPE_ANY, gopi.INPUT_BUS_ANY, false},
+		{"keyboard", "", gopi.INPUT_TYPE_KEYBOARD, gopi.INPUT_BUS_ANY, false},
+		{"keyboard,mouse", "usb", gopi.INPUT_TYPE_KEYBOARD | gopi.INPUT_TYPE_MOUSE, gopi.INPUT_BUS_USB, false},
+		{"", "virtual", gopi.INPUT_TYPE_ANY, gopi.INPUT_BUS_VIRTUAL, false},
+		{"printer", "", gopi.INPUT_TYPE_NONE, gopi.INPUT_BUS_NONE, true},
+		{"", "parallel", gopi.INPUT_TYPE_NONE, gopi.INPUT_BUS_NONE, true},
+	}
+	for _, test := range tests {
+		if _, flags, bus, err := ParseFilter("", test.device_type, test.device_bus); test.err && err == nil {
+			t.Errorf("%v,%v: Expected error", test.device_type, test.device_bus)
+		} else if test.err == false && err != nil {
+			t.Errorf("%v,%v: %v", test.device_type, test.device_bus, err)
+		} else if flags != test.flags || bus != test.bus {
+			t.Errorf("%v,%v: Unexpected filter %v,%v", test.device_type, test.device_bus, flags, bus)
+		}
+	}
+
+	// The names of device types and busses can be parsed
+	for _, name := range DeviceBusNames() {
+		if _, err := ParseDeviceBus(name); err != nil {
+			t.Error(err)
+		}
+	}
+	if len(DeviceTypeNames()) == 0 {
+		t.Error("Expected device type names")
+	}
+}
@@ -0,0 +1,383 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+	"github.com/djthorpe/gopi/util/event"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACES
+
+// ControlDevice is implemented by input devices which can report whether
+// they are a consumer control device (media and volume keys) or a system
+// control device (power and sleep keys), such as the separate nodes of
+// multimedia keyboards and remote receivers
+type ControlDevice interface {
+	gopi.InputDevice
+
+	// Return the type of control device, or CONTROL_NONE
+	Controls() ControlType
+}
+
+// MediaEvent is emitted by the media keys driver when a media, volume
+// or system control key is pressed, repeated or released on any device
+type MediaEvent interface {
+	gopi.Event
+
+	// The media key
+	Key() MediaKey
+
+	// INPUT_EVENT_KEYPRESS, INPUT_EVENT_KEYREPEAT or INPUT_EVENT_KEYRELEASE
+	EventType() gopi.InputEventType
+
+	// The timestamp of the key event
+	Timestamp() time.Duration
+
+	// The device which the key event was emitted from, and the device ID
+	// or zero if not known
+	Device() gopi.InputDevice
+	DeviceID() uint32
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// MediaKeys subscribes to the input manager and emits a MediaEvent for
+// each media, volume or system control key event
+type MediaKeys struct {
+	InputManager gopi.InputManager
+}
+
+// ControlType is the type of control device, as a combination
+// of consumer and system control
+type ControlType uint
+
+// MediaKey is a media, volume or system control key
+type MediaKey uint
+
+// Media keys driver
+type media struct {
+	log   gopi.Logger
+	input gopi.InputManager
+	done  chan struct{}
+
+	// Publisher
+	event.Publisher
+}
+
+// Media event
+type media_event struct {
+	source    gopi.Driver
+	key       MediaKey
+	event     gopi.InputEventType
+	timestamp time.Duration
+	device    gopi.InputDevice
+	device_id uint32
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Types of control device
+const (
+	CONTROL_NONE     ControlType = 0x00
+	CONTROL_CONSUMER ControlType = 0x01 // Media and volume keys
+	CONTROL_SYSTEM   ControlType = 0x02 // Power and sleep keys
+)
+
+// Media keys
+const (
+	MEDIA_KEY_NONE MediaKey = iota
+	MEDIA_KEY_PLAYPAUSE
+	MEDIA_KEY_PLAY
+	MEDIA_KEY_PAUSE
+	MEDIA_KEY_STOP
+	MEDIA_KEY_NEXT
+	MEDIA_KEY_PREVIOUS
+	MEDIA_KEY_FASTFORWARD
+	MEDIA_KEY_REWIND
+	MEDIA_KEY_RECORD
+	MEDIA_KEY_EJECT
+	MEDIA_KEY_VOLUMEUP
+	MEDIA_KEY_VOLUMEDOWN
+	MEDIA_KEY_MUTE
+	MEDIA_KEY_POWER
+	MEDIA_KEY_SLEEP
+	MEDIA_KEY_WAKEUP
+)
+
+// Media keys, in addition to the gopi.KeyCode values
+const (
+	KEYCODE_EJECTCD      gopi.KeyCode = 0x00A1
+	KEYCODE_NEXTSONG     gopi.KeyCode = 0x00A3
+	KEYCODE_PLAYPAUSE    gopi.KeyCode = 0x00A4
+	KEYCODE_PREVIOUSSONG gopi.KeyCode = 0x00A5
+	KEYCODE_STOPCD       gopi.KeyCode = 0x00A6
+	KEYCODE_RECORD       gopi.KeyCode = 0x00A7
+	KEYCODE_REWIND       gopi.KeyCode = 0x00A8
+	KEYCODE_PLAYCD       gopi.KeyCode = 0x00C8
+	KEYCODE_PAUSECD      gopi.KeyCode = 0x00C9
+	KEYCODE_FASTFORWARD  gopi.KeyCode = 0x00D0
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// Key codes for each media key
+	media_keys = map[gopi.KeyCode]MediaKey{
+		KEYCODE_PLAYPAUSE:       MEDIA_KEY_PLAYPAUSE,
+		gopi.KEYCODE_PLAY:       MEDIA_KEY_PLAY,
+		KEYCODE_PLAYCD:          MEDIA_KEY_PLAY,
+		KEYCODE_PAUSECD:         MEDIA_KEY_PAUSE,
+		KEYCODE_STOPCD:          MEDIA_KEY_STOP,
+		KEYCODE_NEXTSONG:        MEDIA_KEY_NEXT,
+		KEYCODE_PREVIOUSSONG:    MEDIA_KEY_PREVIOUS,
+		KEYCODE_FASTFORWARD:     MEDIA_KEY_FASTFORWARD,
+		KEYCODE_REWIND:          MEDIA_KEY_REWIND,
+		KEYCODE_RECORD:          MEDIA_KEY_RECORD,
+		KEYCODE_EJECTCD:         MEDIA_KEY_EJECT,
+		gopi.KEYCODE_VOLUMEUP:   MEDIA_KEY_VOLUMEUP,
+		gopi.KEYCODE_VOLUMEDOWN: MEDIA_KEY_VOLUMEDOWN,
+		gopi.KEYCODE_MUTE:       MEDIA_KEY_MUTE,
+		gopi.KEYCODE_POWER:      MEDIA_KEY_POWER,
+		gopi.KEYCODE_SLEEP:      MEDIA_KEY_SLEEP,
+		gopi.KEYCODE_WAKEUP:     MEDIA_KEY_WAKEUP,
+	}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Open the media keys driver, which emits events until closed
+func (config MediaKeys) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<sys.input.MediaKeys.Open>{ input=%v }", config.InputManager)
+
+	// Check for required input manager
+	if config.InputManager == nil {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(media)
+	this.log = log
+	this.input = config.InputManager
+	this.done = make(chan struct{})
+
+	// Receive events from the input manager
+	go this.receiveEvents(this.input.Subscribe())
+
+	// Return success
+	return this, nil
+}
+
+// Close the media keys driver
+func (this *media) Close() error {
+	this.log.Debug("<sys.input.MediaKeys.Close>{ }")
+
+	// Wait for receiveEvents completion
+	this.done <- gopi.DONE
+	<-this.done
+
+	// Close publisher
+	this.Publisher.Close()
+
+	// Return success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// MEDIA KEYS
+
+// MediaKeyFor returns the media key for a key code, or MEDIA_KEY_NONE
+// if the key code is not a media, volume or system control key
+func MediaKeyFor(key_code gopi.KeyCode) MediaKey {
+	if key, exists := media_keys[key_code]; exists {
+		return key
+	} else {
+		return MEDIA_KEY_NONE
+	}
+}
+
+// Control returns whether a media key is a consumer control key or
+// a system control key
+func (k MediaKey) Control() ControlType {
+	switch k {
+	case MEDIA_KEY_NONE:
+		return CONTROL_NONE
+	case MEDIA_KEY_POWER, MEDIA_KEY_SLEEP, MEDIA_KEY_WAKEUP:
+		return CONTROL_SYSTEM
+	default:
+		return CONTROL_CONSUMER
+	}
+}
+
+// NewMediaEvent returns a media event from a key event, or nil
+// if the event is not for a media, volume or system control key
+func NewMediaEvent(source gopi.Driver, evt gopi.InputEvent) MediaEvent {
+	switch evt.EventType() {
+	case gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYREPEAT, gopi.INPUT_EVENT_KEYRELEASE:
+		if key := MediaKeyFor(evt.KeyCode()); key != MEDIA_KEY_NONE {
+			media_event := &media_event{
+				source:    source,
+				key:       key,
+				event:     evt.EventType(),
+				timestamp: evt.Timestamp(),
+			}
+			if device, ok := evt.Source().(gopi.InputDevice); ok {
+				media_event.device = device
+			}
+			if identity_event, ok := evt.(IdentityEvent); ok {
+				media_event.device_id = identity_event.DeviceID()
+			}
+			return media_event
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// MediaEvent INTERFACE
+
+func (this *media_event) Name() string {
+	return "MediaEvent"
+}
+
+func (this *media_event) Source() gopi.Driver {
+	return this.source
+}
+
+func (this *media_event) Key() MediaKey {
+	return this.key
+}
+
+func (this *media_event) EventType() gopi.InputEventType {
+	return this.event
+}
+
+func (this *media_event) Timestamp() time.Duration {
+	return this.timestamp
+}
+
+func (this *media_event) Device() gopi.InputDevice {
+	return this.device
+}
+
+func (this *media_event) DeviceID() uint32 {
+	return this.device_id
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// receiveEvents emits media events for key events from the input
+// manager until the driver is closed. When the input manager is closed
+// first, no more events are received and the driver waits to be closed
+func (this *media) receiveEvents(events <-chan gopi.Event) {
+FOR_LOOP:
+	for {
+		select {
+		case evt, ok := <-events:
+			if ok == false {
+				<-this.done
+				break FOR_LOOP
+			} else if input_event, ok := evt.(gopi.InputEvent); ok {
+				if media_event := NewMediaEvent(this, input_event); media_event != nil {
+					this.Emit(media_event)
+				}
+			}
+		case <-this.done:
+			break FOR_LOOP
+		}
+	}
+
+	// Unsubscribe and signal end of goroutine
+	this.input.Unsubscribe(events)
+	close(this.done)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *media) String() string {
+	return fmt.Sprintf("<sys.input.MediaKeys>{ input=%v }", this.input)
+}
+
+func (this *media_event) String() string {
+	if this.device_id == 0 {
+		return fmt.Sprintf("<sys.input.MediaEvent>{ key=%v type=%v ts=%v }", this.key, this.event, this.timestamp)
+	} else {
+		return fmt.Sprintf("<sys.input.MediaEvent>{ key=%v type=%v device_id=0x%08X ts=%v }", this.key, this.event, this.device_id, this.timestamp)
+	}
+}
+
+func (c ControlType) String() string {
+	if c == CONTROL_NONE {
+		return "CONTROL_NONE"
+	}
+	flags := make([]string, 0, 2)
+	if c&CONTROL_CONSUMER != 0 {
+		flags = append(flags, "CONTROL_CONSUMER")
+	}
+	if c&CONTROL_SYSTEM != 0 {
+		flags = append(flags, "CONTROL_SYSTEM")
+	}
+	if c&^(CONTROL_CONSUMER|CONTROL_SYSTEM) != 0 {
+		flags = append(flags, "[?? Invalid ControlType value]")
+	}
+	return strings.Join(flags, "|")
+}
+
+func (k MediaKey) String() string {
+	switch k {
+	case MEDIA_KEY_NONE:
+		return "MEDIA_KEY_NONE"
+	case MEDIA_KEY_PLAYPAUSE:
+		return "MEDIA_KEY_PLAYPAUSE"
+	case MEDIA_KEY_PLAY:
+		return "MEDIA_KEY_PLAY"
+	case MEDIA_KEY_PAUSE:
+		return "MEDIA_KEY_PAUSE"
+	case MEDIA_KEY_STOP:
+		return "MEDIA_KEY_STOP"
+	case MEDIA_KEY_NEXT:
+		return "MEDIA_KEY_NEXT"
+	case MEDIA_KEY_PREVIOUS:
+		return "MEDIA_KEY_PREVIOUS"
+	case MEDIA_KEY_FASTFORWARD:
+		return "MEDIA_KEY_FASTFORWARD"
+	case MEDIA_KEY_REWIND:
+		return "MEDIA_KEY_REWIND"
+	case MEDIA_KEY_RECORD:
+		return "MEDIA_KEY_RECORD"
+	case MEDIA_KEY_EJECT:
+		return "MEDIA_KEY_EJECT"
+	case MEDIA_KEY_VOLUMEUP:
+		return "MEDIA_KEY_VOLUMEUP"
+	case MEDIA_KEY_VOLUMEDOWN:
+		return "MEDIA_KEY_VOLUMEDOWN"
+	case MEDIA_KEY_MUTE:
+		return "MEDIA_KEY_MUTE"
+	case MEDIA_KEY_POWER:
+		return "MEDIA_KEY_POWER"
+	case MEDIA_KEY_SLEEP:
+		return "MEDIA_KEY_SLEEP"
+	case MEDIA_KEY_WAKEUP:
+		return "MEDIA_KEY_WAKEUP"
+	default:
+		return "[?? Invalid MediaKey value]"
+	}
+}
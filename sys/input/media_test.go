@@ -0,0 +1,81 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"testing"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST MEDIA KEYS
+
+func TestMediaKeys_000(t *testing.T) {
+	tests := map[gopi.KeyCode]MediaKey{
+		KEYCODE_PLAYPAUSE:     MEDIA_KEY_PLAYPAUSE,
+		KEYCODE_NEXTSONG:      MEDIA_KEY_NEXT,
+		gopi.KEYCODE_VOLUMEUP: MEDIA_KEY_VOLUMEUP,
+		gopi.KEYCODE_POWER:    MEDIA_KEY_POWER,
+		gopi.KEYCODE_A:        MEDIA_KEY_NONE,
+		gopi.KEYCODE_ENTER:    MEDIA_KEY_NONE,
+	}
+	for key_code, expected := range tests {
+		if key := MediaKeyFor(key_code); key != expected {
+			t.Errorf("%v: Expected %v, got %v", key_code, expected, key)
+		}
+	}
+	if MEDIA_KEY_MUTE.Control() != CONTROL_CONSUMER || MEDIA_KEY_SLEEP.Control() != CONTROL_SYSTEM {
+		t.Error("Unexpected control type")
+	}
+}
+
+func TestMediaKeys_001(t *testing.T) {
+	remote := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	remote.device_id = 0x12345678
+
+	// Key events for media keys are media events
+	events := testDecode(remote, []evEvent{
+		{Type: EV_KEY, Code: evKeyCode(KEYCODE_PLAYPAUSE), Value: 1},
+		syn_report,
+		{Type: EV_KEY, Code: evKeyCode(KEYCODE_PLAYPAUSE), Value: 0},
+		{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_A), Value: 1},
+		syn_report,
+	})
+	if len(events) != 3 {
+		t.Fatalf("Unexpected events: %v", events)
+	}
+	if evt := NewMediaEvent(nil, events[0]); evt == nil {
+		t.Error("Expected media event")
+	} else if evt.Key() != MEDIA_KEY_PLAYPAUSE || evt.EventType() != gopi.INPUT_EVENT_KEYPRESS || evt.Device() != remote || evt.DeviceID() != remote.device_id {
+		t.Errorf("Unexpected media event: %v", evt)
+	}
+	if evt := NewMediaEvent(nil, events[1]); evt == nil || evt.EventType() != gopi.INPUT_EVENT_KEYRELEASE {
+		t.Errorf("Unexpected media event: %v", evt)
+	}
+	if evt := NewMediaEvent(nil, events[2]); evt != nil {
+		t.Errorf("Unexpected media event: %v", evt)
+	}
+}
+
+func TestMediaKeys_002(t *testing.T) {
+	// The driver can be closed after the input manager has
+	// closed the channel of events
+	events := make(chan gopi.Event)
+	this := &media{log: &testLogger{t}, input: &manager{}, done: make(chan struct{})}
+	close(events)
+	go this.receiveEvents(events)
+	if err := this.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,244 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// RemapRule maps a key or button to another key or button, for the
+// device with a name, or for all devices when the name is empty. A
+// rule which maps to KEYCODE_NONE disables the key
+type RemapRule struct {
+	Device string
+	From   gopi.KeyCode
+	To     gopi.KeyCode
+}
+
+// Remap rewrites key and button events using a set of rules. The rules
+// can be replaced while keys are held down, and a key is repeated and
+// released with the key code it was pressed with
+type Remap struct {
+	rules   []RemapRule
+	pressed map[remapKey]gopi.KeyCode
+	sync.Mutex
+}
+
+// Identifies a key on a device
+type remapKey struct {
+	device gopi.InputDevice
+	key    gopi.KeyCode
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	remap_names map[string]gopi.KeyCode
+	remap_once  sync.Once
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// REMAP
+
+// NewRemap returns a remap with a set of rules
+func NewRemap(rules []RemapRule) *Remap {
+	this := new(Remap)
+	this.pressed = make(map[remapKey]gopi.KeyCode)
+	this.SetRules(rules)
+	return this
+}
+
+// SetRules replaces the rules
+func (this *Remap) SetRules(rules []RemapRule) {
+	this.Lock()
+	defer this.Unlock()
+	this.rules = append([]RemapRule{}, rules...)
+}
+
+// Rules returns the rules
+func (this *Remap) Rules() []RemapRule {
+	this.Lock()
+	defer this.Unlock()
+	return append([]RemapRule{}, this.rules...)
+}
+
+// KeyCodes returns the key codes which keys are mapped to
+func (this *Remap) KeyCodes() []gopi.KeyCode {
+	this.Lock()
+	defer this.Unlock()
+	key_codes := make([]gopi.KeyCode, 0, len(this.rules))
+	for _, rule := range this.rules {
+		if rule.To != gopi.KEYCODE_NONE {
+			key_codes = append(key_codes, rule.To)
+		}
+	}
+	return key_codes
+}
+
+// Apply returns an event with the key code mapped by the rules, or nil if
+// the key is disabled. Events which are not key events, or which come
+// from a source which is not an input device, are returned unchanged
+func (this *Remap) Apply(evt gopi.InputEvent) gopi.InputEvent {
+	device, ok := evt.Source().(gopi.InputDevice)
+	if ok == false {
+		return evt
+	}
+	switch evt.EventType() {
+	case gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYREPEAT, gopi.INPUT_EVENT_KEYRELEASE:
+		break
+	default:
+		return evt
+	}
+
+	this.Lock()
+	defer this.Unlock()
+
+	key := remapKey{device, evt.KeyCode()}
+	to, pressed := this.pressed[key]
+	switch {
+	case evt.EventType() == gopi.INPUT_EVENT_KEYPRESS || pressed == false:
+		to = this.lookup(device.Name(), evt.KeyCode())
+		if evt.EventType() == gopi.INPUT_EVENT_KEYPRESS {
+			this.pressed[key] = to
+		}
+	case evt.EventType() == gopi.INPUT_EVENT_KEYRELEASE:
+		delete(this.pressed, key)
+	}
+
+	switch to {
+	case gopi.KEYCODE_NONE:
+		return nil
+	case evt.KeyCode():
+		return evt
+	default:
+		// The scan code belongs to the original key, so is not kept
+		return NewInputEvent(device, evt.Timestamp(), evt.EventType(), to, 0, evt.Slot(), evt.Position(), evt.Relative())
+	}
+}
+
+// lookup returns the key code for a key on a device. Rules for
+// the device take precedence over rules for all devices
+func (this *Remap) lookup(device string, key_code gopi.KeyCode) gopi.KeyCode {
+	to, found := key_code, false
+	for _, rule := range this.rules {
+		if rule.From != key_code {
+			continue
+		}
+		if rule.Device == device {
+			return rule.To
+		} else if rule.Device == "" && found == false {
+			to, found = rule.To, true
+		}
+	}
+	return to
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// REMAP FILES
+
+// ReadRemapRules reads rules from a file in the format read by
+// ParseRemapRules
+func ReadRemapRules(path string) ([]RemapRule, error) {
+	if file, err := os.Open(path); err != nil {
+		return nil, err
+	} else {
+		defer file.Close()
+		return ParseRemapRules(file)
+	}
+}
+
+// ParseRemapRules reads rules, one per line, in the form "map <from> <to>",
+// "swap <key> <key>" or "disable <key>". Keys are named without the
+// KEYCODE_ prefix (for example, capslock, leftctrl or btnleft) or are
+// numbers. Rules following a line "[<name>]" are for the device with
+// that name, and lines starting with '#' are comments
+func ParseRemapRules(r io.Reader) ([]RemapRule, error) {
+	rules := make([]RemapRule, 0)
+	device := ""
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			device = strings.TrimSpace(text[1 : len(text)-1])
+			continue
+		}
+		fields := strings.Fields(text)
+		key_codes := make([]gopi.KeyCode, 0, 2)
+		for _, field := range fields[1:] {
+			if key_code, err := ParseKeyCode(field); err != nil {
+				return nil, fmt.Errorf("Line %v: %v", line, err)
+			} else {
+				key_codes = append(key_codes, key_code)
+			}
+		}
+		switch {
+		case fields[0] == "map" && len(key_codes) == 2:
+			rules = append(rules, RemapRule{device, key_codes[0], key_codes[1]})
+		case fields[0] == "swap" && len(key_codes) == 2:
+			rules = append(rules, RemapRule{device, key_codes[0], key_codes[1]}, RemapRule{device, key_codes[1], key_codes[0]})
+		case fields[0] == "disable" && len(key_codes) == 1:
+			rules = append(rules, RemapRule{device, key_codes[0], gopi.KEYCODE_NONE})
+		default:
+			return nil, fmt.Errorf("Line %v: Invalid rule: %v", line, text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ParseKeyCode returns a key code from a name, with or without the
+// KEYCODE_ prefix and in any case, or from a number
+func ParseKeyCode(value string) (gopi.KeyCode, error) {
+	remap_once.Do(func() {
+		remap_names = make(map[string]gopi.KeyCode)
+		for key_code := gopi.KeyCode(0); key_code < gopi.KEYCODE_MAX; key_code++ {
+			if name := fmt.Sprint(key_code); strings.HasPrefix(name, "KEYCODE_") {
+				remap_names[strings.ToLower(strings.TrimPrefix(name, "KEYCODE_"))] = key_code
+			}
+		}
+	})
+	name := strings.ToLower(strings.TrimPrefix(strings.ToUpper(value), "KEYCODE_"))
+	if key_code, exists := remap_names[name]; exists {
+		return key_code, nil
+	} else if key_code, err := strconv.ParseUint(value, 0, 16); err == nil && key_code <= uint64(gopi.KEYCODE_MAX) {
+		return gopi.KeyCode(key_code), nil
+	} else {
+		return gopi.KEYCODE_NONE, fmt.Errorf("Invalid key: %v", value)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r RemapRule) String() string {
+	if r.Device == "" {
+		return fmt.Sprintf("<input.RemapRule>{ from=%v to=%v }", r.From, r.To)
+	} else {
+		return fmt.Sprintf("<input.RemapRule>{ device=%v from=%v to=%v }", strconv.Quote(r.Device), r.From, r.To)
+	}
+}
@@ -0,0 +1,111 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"strings"
+	"testing"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST REMAP
+
+const (
+	testRemapRules = `
+# Swap caps lock and control on all keyboards
+swap capslock KEYCODE_LEFTCTRL
+disable 0x6E
+
+[Logitech USB Optical Mouse]
+swap btnleft btnright
+
+[PCsensor FootSwitch]
+map b pagedown
+map capslock esc
+`
+)
+
+func TestParseRemapRules_000(t *testing.T) {
+	rules, err := ParseRemapRules(strings.NewReader(testRemapRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []RemapRule{
+		{"", gopi.KEYCODE_CAPSLOCK, gopi.KEYCODE_LEFTCTRL},
+		{"", gopi.KEYCODE_LEFTCTRL, gopi.KEYCODE_CAPSLOCK},
+		{"", gopi.KEYCODE_INSERT, gopi.KEYCODE_NONE},
+		{"Logitech USB Optical Mouse", gopi.KEYCODE_BTNLEFT, gopi.KEYCODE_BTNRIGHT},
+		{"Logitech USB Optical Mouse", gopi.KEYCODE_BTNRIGHT, gopi.KEYCODE_BTNLEFT},
+		{"PCsensor FootSwitch", gopi.KEYCODE_B, gopi.KEYCODE_PAGEDOWN},
+		{"PCsensor FootSwitch", gopi.KEYCODE_CAPSLOCK, gopi.KEYCODE_ESC},
+	}
+	if len(rules) != len(expected) {
+		t.Fatalf("Expected %v rules, got %v", len(expected), rules)
+	}
+	for i, rule := range rules {
+		if rule != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], rule)
+		}
+	}
+	for _, invalid := range []string{"map a", "swap a nokey", "unmap a b", "disable 0x1000"} {
+		if _, err := ParseRemapRules(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected error for %v", invalid)
+		}
+	}
+}
+
+func TestRemap_000(t *testing.T) {
+	rules, err := ParseRemapRules(strings.NewReader(testRemapRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	remap := NewRemap(rules)
+	keyboard, pedal := testDevice(t, gopi.INPUT_TYPE_KEYBOARD), testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	keyboard.name, pedal.name = "AT Translated Set 2 keyboard", "PCsensor FootSwitch"
+	key := func(device *device, event_type gopi.InputEventType, key_code gopi.KeyCode) gopi.InputEvent {
+		return remap.Apply(NewInputEvent(device, 0, event_type, key_code, 0x3A, 0, gopi.ZeroPoint, gopi.ZeroPoint))
+	}
+	expect := func(evt gopi.InputEvent, key_code gopi.KeyCode) {
+		if evt == nil || evt.KeyCode() != key_code {
+			t.Errorf("Expected %v, got %v", key_code, evt)
+		}
+	}
+
+	// Rules for all devices, and rules for a device which take precedence
+	expect(key(keyboard, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_CAPSLOCK), gopi.KEYCODE_LEFTCTRL)
+	expect(key(pedal, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_CAPSLOCK), gopi.KEYCODE_ESC)
+	expect(key(pedal, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_B), gopi.KEYCODE_PAGEDOWN)
+	expect(key(keyboard, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_B), gopi.KEYCODE_B)
+	if evt := key(keyboard, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_INSERT); evt != nil {
+		t.Errorf("Expected disabled key, got %v", evt)
+	}
+	if evt := key(keyboard, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_CAPSLOCK); evt.ScanCode() != 0 {
+		t.Errorf("Expected no scan code, got %v", evt.ScanCode())
+	}
+
+	// Held keys repeat and release with the key code they were pressed
+	// with when the rules change
+	remap.SetRules(nil)
+	expect(key(keyboard, gopi.INPUT_EVENT_KEYREPEAT, gopi.KEYCODE_CAPSLOCK), gopi.KEYCODE_LEFTCTRL)
+	expect(key(keyboard, gopi.INPUT_EVENT_KEYRELEASE, gopi.KEYCODE_CAPSLOCK), gopi.KEYCODE_LEFTCTRL)
+	expect(key(pedal, gopi.INPUT_EVENT_KEYRELEASE, gopi.KEYCODE_B), gopi.KEYCODE_PAGEDOWN)
+	expect(key(keyboard, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_CAPSLOCK), gopi.KEYCODE_CAPSLOCK)
+
+	// Other events are unchanged
+	evt := NewScrollEvent(keyboard, 0, gopi.ZeroPoint, gopi.Point{Y: 1})
+	if remap.Apply(evt) != evt {
+		t.Error("Expected unchanged event")
+	}
+}
@@ -0,0 +1,253 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// DeviceRule sets an alias and policy for the devices which match
+// a matcher. Empty values keep the settings of the input manager
+type DeviceRule struct {
+	// Devices which the rule applies to
+	Matcher *Matcher
+
+	// Alias for the device, which is matched by OpenDevicesByName
+	Alias string
+
+	// Don't open the device
+	Ignore bool
+
+	// Whether to get exclusive use of the device
+	Grab GrabMode
+
+	// Path to the calibration file for the device
+	Calibration string
+
+	// Type of device, or INPUT_TYPE_NONE to determine the type
+	// from the capabilities of the device
+	Type gopi.InputDeviceType
+}
+
+// GrabMode determines whether exclusive use of a device is obtained
+type GrabMode uint
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	GRAB_DEFAULT   GrabMode = iota // Use the setting of the input manager
+	GRAB_EXCLUSIVE                 // Obtain exclusive use
+	GRAB_SHARED                    // Don't obtain exclusive use
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	ErrIgnoredDevice = errors.New("Device is ignored by the device rules")
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// DEVICE RULES
+
+// DeviceRuleFor returns the settings of all the rules which match
+// a device, where later rules take precedence over earlier rules. Rules
+// can match the alias set by an earlier rule
+func DeviceRuleFor(rules []DeviceRule, device gopi.InputDevice) DeviceRule {
+	result := DeviceRule{}
+	if identity, ok := device.(IdentityDevice); ok {
+		result.Alias = identity.Alias()
+	}
+	for _, rule := range rules {
+		if rule.Matcher != nil && rule.Matcher.matches(device, result.Alias) == false {
+			continue
+		}
+		if rule.Alias != "" {
+			result.Alias = rule.Alias
+		}
+		if rule.Ignore {
+			result.Ignore = true
+		}
+		if rule.Grab != GRAB_DEFAULT {
+			result.Grab = rule.Grab
+		}
+		if rule.Calibration != "" {
+			result.Calibration = rule.Calibration
+		}
+		if rule.Type != gopi.INPUT_TYPE_NONE {
+			result.Type = rule.Type
+		}
+	}
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// DEVICE RULE FILES
+
+// ReadDeviceRules reads rules from a file in the format read by
+// ParseDeviceRules
+func ReadDeviceRules(path string) ([]DeviceRule, error) {
+	if file, err := os.Open(path); err != nil {
+		return nil, err
+	} else {
+		defer file.Close()
+		return ParseDeviceRules(file)
+	}
+}
+
+// ParseDeviceRules reads rules, where each rule starts with a line
+// "[<matcher>]" (as parsed by ParseMatcher) followed by one setting
+// per line:
+//
+//	alias <alias>             Alias for the device
+//	ignore                    Don't open the device
+//	exclusive <yes|no>        Whether to get exclusive use of the device
+//	calibration <path>        Path to the calibration file for the device
+//	type <type>,...           Type of device (keyboard, mouse, touchscreen, ...)
+//
+// Lines starting with '#' are comments
+func ParseDeviceRules(r io.Reader) ([]DeviceRule, error) {
+	rules := make([]DeviceRule, 0)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			if matcher, err := ParseMatcher(text[1 : len(text)-1]); err != nil {
+				return nil, fmt.Errorf("Line %v: %v", line, err)
+			} else {
+				rules = append(rules, DeviceRule{Matcher: matcher})
+			}
+			continue
+		}
+		if len(rules) == 0 {
+			return nil, fmt.Errorf("Line %v: Missing [<matcher>] before %v", line, text)
+		}
+		rule := &rules[len(rules)-1]
+		name, value := text, ""
+		if i := strings.IndexAny(text, " \t"); i >= 0 {
+			name, value = text[:i], strings.TrimSpace(text[i:])
+		}
+		switch name = strings.ToLower(name); {
+		case name == "alias" && value != "":
+			rule.Alias = value
+		case name == "ignore" && value == "":
+			rule.Ignore = true
+		case name == "exclusive":
+			if exclusive, err := strconv.ParseBool(rulesBool(value)); err != nil {
+				return nil, fmt.Errorf("Line %v: Invalid exclusive value: %v", line, value)
+			} else if exclusive {
+				rule.Grab = GRAB_EXCLUSIVE
+			} else {
+				rule.Grab = GRAB_SHARED
+			}
+		case name == "calibration" && value != "":
+			rule.Calibration = value
+		case name == "type" && value != "":
+			if device_type, err := ParseDeviceType(value); err != nil {
+				return nil, fmt.Errorf("Line %v: %v", line, err)
+			} else {
+				rule.Type = device_type
+			}
+		default:
+			return nil, fmt.Errorf("Line %v: Invalid setting: %v", line, text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ParseDeviceType returns a device type from names separated by commas,
+// such as "keyboard" or "keyboard,mouse"
+func ParseDeviceType(value string) (gopi.InputDeviceType, error) {
+	device_type := gopi.INPUT_TYPE_NONE
+	for _, name := range strings.Split(value, ",") {
+		found := false
+		name = strings.ToUpper(strings.TrimSpace(name))
+		for flag := gopi.InputDeviceType(1); flag != 0; flag <<= 1 {
+			if DeviceTypeString(flag) == "INPUT_TYPE_"+name {
+				device_type, found = device_type|flag, true
+			}
+		}
+		if found == false {
+			return gopi.INPUT_TYPE_NONE, fmt.Errorf("Invalid type: %v", strings.ToLower(name))
+		}
+	}
+	return device_type, nil
+}
+
+// rulesBool allows yes/no and on/off as well as the values accepted
+// by strconv.ParseBool
+func rulesBool(value string) string {
+	switch strings.ToLower(value) {
+	case "yes", "on":
+		return "true"
+	case "no", "off":
+		return "false"
+	default:
+		return value
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r DeviceRule) String() string {
+	parts := make([]string, 0, 6)
+	if r.Matcher != nil {
+		parts = append(parts, fmt.Sprint("matcher=", r.Matcher))
+	}
+	if r.Alias != "" {
+		parts = append(parts, fmt.Sprint("alias=", strconv.Quote(r.Alias)))
+	}
+	if r.Ignore {
+		parts = append(parts, "ignore=true")
+	}
+	if r.Grab != GRAB_DEFAULT {
+		parts = append(parts, fmt.Sprint("grab=", r.Grab))
+	}
+	if r.Calibration != "" {
+		parts = append(parts, fmt.Sprint("calibration=", strconv.Quote(r.Calibration)))
+	}
+	if r.Type != gopi.INPUT_TYPE_NONE {
+		parts = append(parts, fmt.Sprint("type=", DeviceTypeString(r.Type)))
+	}
+	return fmt.Sprintf("<input.DeviceRule>{ %v }", strings.Join(parts, " "))
+}
+
+func (m GrabMode) String() string {
+	switch m {
+	case GRAB_DEFAULT:
+		return "GRAB_DEFAULT"
+	case GRAB_EXCLUSIVE:
+		return "GRAB_EXCLUSIVE"
+	case GRAB_SHARED:
+		return "GRAB_SHARED"
+	default:
+		return "[?? Invalid GrabMode value]"
+	}
+}
@@ -0,0 +1,169 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST DEVICE RULES
+
+const (
+	testDeviceRules = `
+# Barcode scanners
+[phys=usb-3f980000.usb-1.2/input0]
+alias front-scanner
+exclusive yes
+
+[phys=usb-3f980000.usb-1.3/input0]
+alias	back-scanner
+type keyboard,remote
+
+# Keyboards are shared, except the front scanner
+[has=leftctrl]
+exclusive no
+
+[front-scanner]
+exclusive on
+
+[name=vc4-hdmi*]
+ignore
+`
+)
+
+func TestDeviceRules_000(t *testing.T) {
+	rules, err := ParseDeviceRules(strings.NewReader(testDeviceRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 5 {
+		t.Fatalf("Expected 5 rules, got %v", rules)
+	}
+	if rules[0].Alias != "front-scanner" || rules[0].Grab != GRAB_EXCLUSIVE {
+		t.Errorf("Unexpected rule: %v", rules[0])
+	}
+	if rules[1].Alias != "back-scanner" || rules[1].Type != gopi.INPUT_TYPE_KEYBOARD|gopi.INPUT_TYPE_REMOTE || rules[1].Grab != GRAB_DEFAULT {
+		t.Errorf("Unexpected rule: %v", rules[1])
+	}
+	if rules[2].Grab != GRAB_SHARED || rules[4].Ignore == false {
+		t.Errorf("Unexpected rules: %v", rules)
+	}
+	for _, invalid := range []string{"alias scanner", "[]\nalias", "[]\nexclusive maybe", "[]\ntype printer", "[]\nignore always", "[]\nunknown", "[bus=firewire]"} {
+		if _, err := ParseDeviceRules(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected error for %v", strings.Replace(invalid, "\n", " ", -1))
+		}
+	}
+}
+
+func TestDeviceRules_001(t *testing.T) {
+	rules, err := ParseDeviceRules(strings.NewReader(testDeviceRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	front, back := testDevice(t, gopi.INPUT_TYPE_KEYBOARD), testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	front.phys, back.phys = "usb-3f980000.usb-1.2/input0", "usb-3f980000.usb-1.3/input0"
+	front.caps.Keys = []gopi.KeyCode{gopi.KEYCODE_LEFTCTRL}
+	back.caps.Keys = []gopi.KeyCode{gopi.KEYCODE_LEFTCTRL}
+
+	// Later rules take precedence, and rules can match an alias set by an
+	// earlier rule once the alias has been applied
+	if err := front.evApplyRule(DeviceRuleFor(rules, front)); err != nil {
+		t.Fatal(err)
+	} else if front.alias != "front-scanner" || front.exclusive == false {
+		t.Errorf("Unexpected device: alias=%v exclusive=%v", front.alias, front.exclusive)
+	}
+	back.exclusive = true
+	if err := back.evApplyRule(DeviceRuleFor(rules, back)); err != nil {
+		t.Fatal(err)
+	} else if back.alias != "back-scanner" || back.exclusive || back.device_type != gopi.INPUT_TYPE_KEYBOARD|gopi.INPUT_TYPE_REMOTE {
+		t.Errorf("Unexpected device: alias=%v exclusive=%v type=%v", back.alias, back.exclusive, DeviceTypeString(back.device_type))
+	}
+
+	// Devices can be ignored
+	cec := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+	cec.name = "vc4-hdmi"
+	if err := cec.evApplyRule(DeviceRuleFor(rules, cec)); err != ErrIgnoredDevice {
+		t.Errorf("Expected ErrIgnoredDevice, got %v", err)
+	}
+
+	// Aliases are matched
+	if front.Matches("front-scanner", gopi.INPUT_TYPE_ANY, gopi.INPUT_BUS_ANY) == false {
+		t.Error("Expected match for front-scanner")
+	} else if back.Matches("alias=*-scanner;!front-scanner", gopi.INPUT_TYPE_REMOTE, gopi.INPUT_BUS_ANY) == false {
+		t.Error("Expected match for back-scanner")
+	}
+}
+
+func TestDeviceRules_002(t *testing.T) {
+	folder, err := ioutil.TempDir("", "rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+
+	// Calibration is read from the file in the rule
+	path := filepath.Join(folder, "touchscreen.calibration")
+	calibration := Calibration{A: -1, C: 800, E: 1}
+	if err := WriteCalibration(path, calibration); err != nil {
+		t.Fatal(err)
+	}
+	touchscreen := testDevice(t, gopi.INPUT_TYPE_TOUCHSCREEN)
+	if err := touchscreen.evApplyRule(DeviceRule{Calibration: path}); err != nil {
+		t.Fatal(err)
+	} else if testCalibrationEqual(touchscreen.calibration, calibration) == false {
+		t.Errorf("Expected %v, got %v", calibration, touchscreen.calibration)
+	}
+	if err := touchscreen.evApplyRule(DeviceRule{Calibration: filepath.Join(folder, "missing")}); err == nil {
+		t.Error("Expected error for missing calibration file")
+	}
+}
+
+func TestDeviceRules_003(t *testing.T) {
+	rules, err := ParseDeviceRules(strings.NewReader("[controls=consumer]\ntype remote\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := testReadFixtures(t)
+	consumer := testFixtureDevice(t, fixtures["Logitech USB Receiver Consumer Control"])
+	controls := consumer.Controls()
+	if controls&CONTROL_CONSUMER == 0 {
+		t.Fatalf("Expected consumer control device, got %v", controls)
+	}
+
+	// Controls are determined again when the rule changes the type
+	if err := consumer.evApplyRule(DeviceRuleFor(rules, consumer)); err != nil {
+		t.Fatal(err)
+	} else if consumer.device_type != gopi.INPUT_TYPE_REMOTE || consumer.Controls() != CONTROL_NONE {
+		t.Errorf("Unexpected device: type=%v controls=%v", DeviceTypeString(consumer.device_type), consumer.Controls())
+	}
+	if err := consumer.evApplyRule(DeviceRule{Type: gopi.INPUT_TYPE_KEYBOARD}); err != nil {
+		t.Fatal(err)
+	} else if consumer.Controls() != controls {
+		t.Errorf("Expected %v, got %v", controls, consumer.Controls())
+	}
+
+	// A full keyboard is not a control device, whatever the type
+	keyboard := testFixtureDevice(t, fixtures["AT Translated Set 2 keyboard"])
+	if err := keyboard.evApplyRule(DeviceRule{Type: gopi.INPUT_TYPE_KEYBOARD}); err != nil {
+		t.Fatal(err)
+	} else if keyboard.Controls() != CONTROL_NONE {
+		t.Errorf("Unexpected controls: %v", keyboard.Controls())
+	}
+}
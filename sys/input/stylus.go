@@ -0,0 +1,142 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"strings"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACES
+
+// StylusEvent is an input event from a graphics tablet or pen display,
+// which reports the state of the stylus. Events from tablets are
+// INPUT_EVENT_STYLUS when the stylus moves or the pressure, tilt or
+// distance changes, INPUT_EVENT_PROXIMITYIN and INPUT_EVENT_PROXIMITYOUT
+// when a tool comes into or goes out of range, and key events for the
+// tip (KEYCODE_BTNTOUCH) and barrel buttons. The stylus state is zero
+// for events from other devices
+type StylusEvent interface {
+	gopi.InputEvent
+
+	// The tool which is in proximity, or STYLUS_TOOL_NONE
+	Tool() StylusTool
+
+	// The pressure of the tip, between 0.0 and 1.0
+	Pressure() float32
+
+	// The tilt of the stylus from vertical in degrees, where positive
+	// values tilt to the right (X) and towards the user (Y)
+	Tilt() gopi.Point
+
+	// The distance of the stylus from the surface when hovering,
+	// between 0.0 and 1.0
+	Distance() float32
+
+	// The tip and barrel buttons which are pressed
+	Buttons() StylusButton
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// StylusTool is the type of tool used on a graphics tablet
+type StylusTool uint16
+
+// StylusButton is a combination of stylus tip and barrel buttons
+type StylusButton uint
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Event types for graphics tablets, in addition to the
+// gopi.InputEventType values
+const (
+	INPUT_EVENT_STYLUS       gopi.InputEventType = 0x000E // Stylus movement, pressure, tilt or distance
+	INPUT_EVENT_PROXIMITYIN  gopi.InputEventType = 0x000F // Tool comes into range
+	INPUT_EVENT_PROXIMITYOUT gopi.InputEventType = 0x0010 // Tool goes out of range
+)
+
+// Barrel button key codes, in addition to the gopi.KeyCode values
+const (
+	KEYCODE_BTNSTYLUS3 gopi.KeyCode = 0x0149
+	KEYCODE_BTNSTYLUS  gopi.KeyCode = 0x014B
+	KEYCODE_BTNSTYLUS2 gopi.KeyCode = 0x014C
+)
+
+// Stylus tools
+const (
+	STYLUS_TOOL_NONE     StylusTool = 0x0000
+	STYLUS_TOOL_PEN      StylusTool = 0x0140
+	STYLUS_TOOL_RUBBER   StylusTool = 0x0141 // Eraser end of a pen
+	STYLUS_TOOL_BRUSH    StylusTool = 0x0142
+	STYLUS_TOOL_PENCIL   StylusTool = 0x0143
+	STYLUS_TOOL_AIRBRUSH StylusTool = 0x0144
+	STYLUS_TOOL_MOUSE    StylusTool = 0x0146 // Tablet puck
+	STYLUS_TOOL_LENS     StylusTool = 0x0147 // Tablet lens cursor
+)
+
+// Stylus buttons
+const (
+	STYLUS_BUTTON_NONE    StylusButton = 0x00
+	STYLUS_BUTTON_TIP     StylusButton = 0x01 // Tip is touching the surface
+	STYLUS_BUTTON_BARREL  StylusButton = 0x02 // Lower barrel button
+	STYLUS_BUTTON_BARREL2 StylusButton = 0x04 // Upper barrel button
+	STYLUS_BUTTON_BARREL3 StylusButton = 0x08 // Third barrel button
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (t StylusTool) String() string {
+	switch t {
+	case STYLUS_TOOL_NONE:
+		return "STYLUS_TOOL_NONE"
+	case STYLUS_TOOL_PEN:
+		return "STYLUS_TOOL_PEN"
+	case STYLUS_TOOL_RUBBER:
+		return "STYLUS_TOOL_RUBBER"
+	case STYLUS_TOOL_BRUSH:
+		return "STYLUS_TOOL_BRUSH"
+	case STYLUS_TOOL_PENCIL:
+		return "STYLUS_TOOL_PENCIL"
+	case STYLUS_TOOL_AIRBRUSH:
+		return "STYLUS_TOOL_AIRBRUSH"
+	case STYLUS_TOOL_MOUSE:
+		return "STYLUS_TOOL_MOUSE"
+	case STYLUS_TOOL_LENS:
+		return "STYLUS_TOOL_LENS"
+	default:
+		return "[?? Invalid StylusTool value]"
+	}
+}
+
+func (b StylusButton) String() string {
+	if b == STYLUS_BUTTON_NONE {
+		return "STYLUS_BUTTON_NONE"
+	}
+	flags := ""
+	if b&STYLUS_BUTTON_TIP != STYLUS_BUTTON_NONE {
+		flags = flags + "|STYLUS_BUTTON_TIP"
+	}
+	if b&STYLUS_BUTTON_BARREL != STYLUS_BUTTON_NONE {
+		flags = flags + "|STYLUS_BUTTON_BARREL"
+	}
+	if b&STYLUS_BUTTON_BARREL2 != STYLUS_BUTTON_NONE {
+		flags = flags + "|STYLUS_BUTTON_BARREL2"
+	}
+	if b&STYLUS_BUTTON_BARREL3 != STYLUS_BUTTON_NONE {
+		flags = flags + "|STYLUS_BUTTON_BARREL3"
+	}
+	return strings.TrimLeft(flags, "|")
+}
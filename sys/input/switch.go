@@ -0,0 +1,111 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACES
+
+// SwitchDevice is implemented by input devices which report switches,
+// such as a laptop lid, tablet mode or headphone jack
+type SwitchDevice interface {
+	gopi.InputDevice
+
+	// Return the state of a switch
+	SwitchState(code SwitchCode) bool
+
+	// Return the switches which are currently on
+	Switches() []SwitchCode
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SwitchCode identifies a switch
+type SwitchCode uint16
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Device which only reports switches, in addition to the
+	// gopi.InputDeviceType values
+	INPUT_TYPE_SWITCH gopi.InputDeviceType = 0x20
+)
+
+// Switches
+const (
+	SW_LID                  SwitchCode = 0x0000 // On when the lid is shut
+	SW_TABLET_MODE          SwitchCode = 0x0001 // On when in tablet mode
+	SW_HEADPHONE_INSERT     SwitchCode = 0x0002 // On when headphones are inserted
+	SW_RFKILL_ALL           SwitchCode = 0x0003 // On when radios are enabled
+	SW_MICROPHONE_INSERT    SwitchCode = 0x0004 // On when a microphone is inserted
+	SW_DOCK                 SwitchCode = 0x0005 // On when plugged into a dock
+	SW_LINEOUT_INSERT       SwitchCode = 0x0006 // On when line out is inserted
+	SW_JACK_PHYSICAL_INSERT SwitchCode = 0x0007 // On when a jack is physically inserted
+	SW_VIDEOOUT_INSERT      SwitchCode = 0x0008 // On when video out is inserted
+	SW_CAMERA_LENS_COVER    SwitchCode = 0x0009 // On when the lens is covered
+	SW_KEYPAD_SLIDE         SwitchCode = 0x000A // On when the keypad slide is out
+	SW_FRONT_PROXIMITY      SwitchCode = 0x000B // On when the front proximity sensor is active
+	SW_ROTATE_LOCK          SwitchCode = 0x000C // On when rotation is locked
+	SW_LINEIN_INSERT        SwitchCode = 0x000D // On when line in is inserted
+	SW_MUTE_DEVICE          SwitchCode = 0x000E // On when the device is muted
+	SW_PEN_INSERTED         SwitchCode = 0x000F // On when a pen is inserted
+	SW_MACHINE_COVER        SwitchCode = 0x0010 // On when the cover is closed
+	SW_MAX                  SwitchCode = 0x0010
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (c SwitchCode) String() string {
+	switch c {
+	case SW_LID:
+		return "SW_LID"
+	case SW_TABLET_MODE:
+		return "SW_TABLET_MODE"
+	case SW_HEADPHONE_INSERT:
+		return "SW_HEADPHONE_INSERT"
+	case SW_RFKILL_ALL:
+		return "SW_RFKILL_ALL"
+	case SW_MICROPHONE_INSERT:
+		return "SW_MICROPHONE_INSERT"
+	case SW_DOCK:
+		return "SW_DOCK"
+	case SW_LINEOUT_INSERT:
+		return "SW_LINEOUT_INSERT"
+	case SW_JACK_PHYSICAL_INSERT:
+		return "SW_JACK_PHYSICAL_INSERT"
+	case SW_VIDEOOUT_INSERT:
+		return "SW_VIDEOOUT_INSERT"
+	case SW_CAMERA_LENS_COVER:
+		return "SW_CAMERA_LENS_COVER"
+	case SW_KEYPAD_SLIDE:
+		return "SW_KEYPAD_SLIDE"
+	case SW_FRONT_PROXIMITY:
+		return "SW_FRONT_PROXIMITY"
+	case SW_ROTATE_LOCK:
+		return "SW_ROTATE_LOCK"
+	case SW_LINEIN_INSERT:
+		return "SW_LINEIN_INSERT"
+	case SW_MUTE_DEVICE:
+		return "SW_MUTE_DEVICE"
+	case SW_PEN_INSERTED:
+		return "SW_PEN_INSERTED"
+	case SW_MACHINE_COVER:
+		return "SW_MACHINE_COVER"
+	default:
+		return "[?? Invalid SwitchCode value]"
+	}
+}
@@ -0,0 +1,31 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TIMESTAMPS
+
+// Timestamp returns the current time on the time base of event
+// timestamps, which is the monotonic clock on linux. Devices which
+// create events should use it so that timestamps from different
+// devices can be compared
+func Timestamp() time.Duration {
+	return clockMonotonic()
+}
+
+// WallClock returns the wall-clock time for an event timestamp,
+// for logging or sending to remote clients
+func WallClock(ts time.Duration) time.Time {
+	return time.Now().Add(ts - Timestamp()).Round(0)
+}
@@ -0,0 +1,44 @@
+// +build linux
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// Clock used for event timestamps, as set by EVIOCSCLOCKID
+	CLOCK_MONOTONIC = 1
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// clockMonotonic returns the time of the monotonic clock, which
+// is the clock the kernel uses for event timestamps. The monotonic
+// clock is always supported, so errors are not returned
+func clockMonotonic() time.Duration {
+	var ts syscall.Timespec
+	syscall.Syscall(syscall.SYS_CLOCK_GETTIME, CLOCK_MONOTONIC, uintptr(unsafe.Pointer(&ts)), 0)
+	return time.Duration(ts.Nano())
+}
+
+// clockRealtimeOffset returns the difference between the realtime
+// and monotonic clocks, which is subtracted from realtime timestamps
+func clockRealtimeOffset() time.Duration {
+	return time.Duration(time.Now().UnixNano()) - clockMonotonic()
+}
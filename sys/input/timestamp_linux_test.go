@@ -0,0 +1,55 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"testing"
+	"time"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST TIMESTAMPS
+
+func TestTimestamp_000(t *testing.T) {
+	ts := Timestamp()
+	if ts <= 0 || Timestamp() < ts {
+		t.Errorf("Unexpected timestamp: %v", ts)
+	}
+
+	// Timestamps are converted to wall-clock time
+	if delta := time.Since(WallClock(ts)); delta < 0 || delta > time.Second {
+		t.Errorf("Unexpected wall-clock time: %v", WallClock(ts))
+	}
+	if delta := WallClock(ts + time.Minute).Sub(WallClock(ts)); delta < time.Minute-time.Millisecond || delta > time.Minute+time.Millisecond {
+		t.Errorf("Unexpected wall-clock difference: %v", delta)
+	}
+}
+
+func TestTimestamp_001(t *testing.T) {
+	keyboard := testDevice(t, gopi.INPUT_TYPE_KEYBOARD)
+
+	// Timestamps on the realtime clock are converted to the monotonic clock
+	keyboard.clock_realtime = true
+	now := time.Now()
+	events := testDecode(keyboard, []evEvent{
+		{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_A), Value: 1},
+		{Type: EV_SYN, Code: EV_CODE_SYN_REPORT, Second: uint32(now.Unix()), Microsecond: uint32(now.Nanosecond() / 1000)},
+	})
+	if len(events) != 1 {
+		t.Fatalf("Unexpected events: %v", events)
+	} else if delta := Timestamp() - events[0].Timestamp(); delta < 0 || delta > time.Second {
+		t.Errorf("Unexpected timestamp: %v", events[0].Timestamp())
+	}
+}
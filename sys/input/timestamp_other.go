@@ -0,0 +1,29 @@
+// +build !linux
+
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"time"
+)
+
+var (
+	// Process start, which is the time base without a monotonic clock
+	clockStart = time.Now()
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// clockMonotonic returns the time since the process started
+func clockMonotonic() time.Duration {
+	return time.Since(clockStart)
+}
@@ -0,0 +1,92 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"fmt"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACES
+
+// TouchpadDevice is implemented by touchpads, which can convert finger
+// movement into relative pointer movement, scrolling and button presses
+type TouchpadDevice interface {
+	gopi.InputDevice
+
+	// Return the touchpad settings
+	Touchpad() Touchpad
+
+	// Set the touchpad settings
+	SetTouchpad(touchpad Touchpad) error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Touchpad determines how finger movement on a touchpad is converted
+// into mouse-style events. Speed is the distance the pointer moves for
+// each millimetre of finger movement, and the speed is increased by
+// Acceleration for each millimetre per millisecond of finger velocity.
+// When Tap is true, tapping with one, two or three fingers presses the
+// left, right or middle button
+type Touchpad struct {
+	Mode         TouchpadMode
+	Tap          bool
+	Speed        float32
+	Acceleration float32
+}
+
+// TouchpadMode determines whether a touchpad reports touches or
+// behaves like a mouse
+type TouchpadMode uint
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Touchpad modes
+const (
+	TOUCHPAD_RAW     TouchpadMode = iota // Touches are reported as for touchscreens
+	TOUCHPAD_POINTER                     // Touches are converted to mouse-style events
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBAL VARIABLES
+
+var (
+	// DefaultTouchpad moves the pointer and scrolls, without tap to click
+	DefaultTouchpad = Touchpad{Mode: TOUCHPAD_POINTER, Speed: 10, Acceleration: 2}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (t Touchpad) String() string {
+	switch t.Mode {
+	case TOUCHPAD_POINTER:
+		return fmt.Sprintf("<input.Touchpad>{ mode=%v tap=%v speed=%v acceleration=%v }", t.Mode, t.Tap, t.Speed, t.Acceleration)
+	default:
+		return fmt.Sprintf("<input.Touchpad>{ mode=%v }", t.Mode)
+	}
+}
+
+func (m TouchpadMode) String() string {
+	switch m {
+	case TOUCHPAD_RAW:
+		return "TOUCHPAD_RAW"
+	case TOUCHPAD_POINTER:
+		return "TOUCHPAD_POINTER"
+	default:
+		return "[?? Invalid TouchpadMode value]"
+	}
+}
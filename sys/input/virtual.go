@@ -0,0 +1,187 @@
+/*
+  Go Language Raspberry Pi Interface
+  (c) Copyright David Thorpe 2016-2018
+  All Rights Reserved
+
+  Documentation http://djthorpe.github.io/gopi/
+  For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INTERFACES
+
+// VirtualInputDevice is a virtual keyboard, mouse, touchscreen or gamepad
+// which other applications receive input events from
+type VirtualInputDevice interface {
+	gopi.Driver
+
+	// Return the name of the device
+	Name() string
+
+	// Return the type of device
+	Type() gopi.InputDeviceType
+
+	// Return the codes and properties supported by the device
+	Capabilities() Capabilities
+
+	// Emit events from the device. Each event is written as a
+	// separate frame of raw events
+	Emit(events ...gopi.InputEvent) error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Buttons for the default virtual mouse and gamepad
+const (
+	KEYCODE_BTNSIDE   gopi.KeyCode = 0x0113
+	KEYCODE_BTNEXTRA  gopi.KeyCode = 0x0114
+	KEYCODE_BTNSOUTH  gopi.KeyCode = 0x0130
+	KEYCODE_BTNEAST   gopi.KeyCode = 0x0131
+	KEYCODE_BTNNORTH  gopi.KeyCode = 0x0133
+	KEYCODE_BTNWEST   gopi.KeyCode = 0x0134
+	KEYCODE_BTNTL     gopi.KeyCode = 0x0136
+	KEYCODE_BTNTR     gopi.KeyCode = 0x0137
+	KEYCODE_BTNSELECT gopi.KeyCode = 0x013A
+	KEYCODE_BTNSTART  gopi.KeyCode = 0x013B
+	KEYCODE_BTNMODE   gopi.KeyCode = 0x013C
+	KEYCODE_BTNTHUMBL gopi.KeyCode = 0x013D
+	KEYCODE_BTNTHUMBR gopi.KeyCode = 0x013E
+)
+
+const (
+	// Maximum number of contacts for the default virtual touchscreen
+	VIRTUAL_MAX_CONTACTS = 10
+
+	// Range of the absolute axes for the default virtual touchscreen
+	VIRTUAL_ABS_MAX = 0x7FFF
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// DefaultVirtualCapabilities returns the capabilities of a virtual device
+// for a keyboard, mouse, touchscreen or gamepad (INPUT_TYPE_JOYSTICK). The
+// types can be OR'd together to combine the capabilities
+func DefaultVirtualCapabilities(device_type gopi.InputDeviceType) Capabilities {
+	caps := Capabilities{
+		Keys:       make([]gopi.KeyCode, 0),
+		RelAxes:    make([]RelAxis, 0),
+		AbsAxes:    make(map[AbsAxis]AbsInfo),
+		Switches:   make([]SwitchCode, 0),
+		LEDs:       make([]LEDCode, 0),
+		Effects:    make([]EffectType, 0),
+		Waveforms:  make([]EffectWaveform, 0),
+		Properties: make([]InputProperty, 0),
+	}
+	if device_type&gopi.INPUT_TYPE_KEYBOARD != 0 {
+		for key_code := gopi.KeyCode(1); key_code <= 0xFF; key_code++ {
+			caps.Keys = append(caps.Keys, key_code)
+		}
+		caps.LEDs = append(caps.LEDs, LED_NUML, LED_CAPSL, LED_SCROLLL)
+	}
+	if device_type&gopi.INPUT_TYPE_MOUSE != 0 {
+		caps.Keys = append(caps.Keys, gopi.KEYCODE_BTNLEFT, gopi.KEYCODE_BTNRIGHT, gopi.KEYCODE_BTNMIDDLE, KEYCODE_BTNSIDE, KEYCODE_BTNEXTRA)
+		caps.RelAxes = append(caps.RelAxes, REL_X, REL_Y, REL_HWHEEL, REL_WHEEL)
+	}
+	if device_type&gopi.INPUT_TYPE_TOUCHSCREEN != 0 {
+		caps.Keys = append(caps.Keys, gopi.KEYCODE_BTNTOUCH)
+		caps.AbsAxes[ABS_X] = AbsInfo{Maximum: VIRTUAL_ABS_MAX}
+		caps.AbsAxes[ABS_Y] = AbsInfo{Maximum: VIRTUAL_ABS_MAX}
+		caps.AbsAxes[ABS_MT_SLOT] = AbsInfo{Maximum: VIRTUAL_MAX_CONTACTS - 1}
+		caps.AbsAxes[ABS_MT_POSITION_X] = AbsInfo{Maximum: VIRTUAL_ABS_MAX}
+		caps.AbsAxes[ABS_MT_POSITION_Y] = AbsInfo{Maximum: VIRTUAL_ABS_MAX}
+		caps.AbsAxes[ABS_MT_TRACKING_ID] = AbsInfo{Maximum: 0xFFFF}
+		caps.Properties = append(caps.Properties, INPUT_PROP_DIRECT)
+	}
+	if device_type&gopi.INPUT_TYPE_JOYSTICK != 0 {
+		caps.Keys = append(caps.Keys,
+			KEYCODE_BTNSOUTH, KEYCODE_BTNEAST, KEYCODE_BTNNORTH, KEYCODE_BTNWEST, KEYCODE_BTNTL, KEYCODE_BTNTR,
+			KEYCODE_BTNSELECT, KEYCODE_BTNSTART, KEYCODE_BTNMODE, KEYCODE_BTNTHUMBL, KEYCODE_BTNTHUMBR,
+		)
+		for _, axis := range []AbsAxis{ABS_X, ABS_Y, ABS_RX, ABS_RY} {
+			caps.AbsAxes[axis] = AbsInfo{Minimum: -0x8000, Maximum: 0x7FFF, Fuzz: 16, Flat: 128}
+		}
+		caps.AbsAxes[ABS_Z] = AbsInfo{Maximum: 0xFF}
+		caps.AbsAxes[ABS_RZ] = AbsInfo{Maximum: 0xFF}
+		caps.AbsAxes[ABS_HAT0X] = AbsInfo{Minimum: -1, Maximum: 1}
+		caps.AbsAxes[ABS_HAT0Y] = AbsInfo{Minimum: -1, Maximum: 1}
+	}
+	return caps
+}
+
+// MergeCapabilities returns the capabilities of a virtual device which can
+// emit the events decoded from all the devices, and the key codes. Touchpads
+// which convert touches into pointer movement emit relative movement,
+// scrolling and buttons rather than the touches, so have the axes and
+// buttons of a mouse rather than their own absolute axes
+func MergeCapabilities(devices []gopi.InputDevice, key_codes []gopi.KeyCode) Capabilities {
+	caps := DefaultVirtualCapabilities(gopi.INPUT_TYPE_NONE)
+	for _, device := range devices {
+		if device_, ok := device.(CapabilitiesDevice); ok == false {
+			continue
+		} else if touchpad, ok := device.(TouchpadDevice); ok && touchpad.Touchpad().Mode == TOUCHPAD_POINTER {
+			mergeCapabilities(&caps, touchpadCapabilities(device_.Capabilities()))
+		} else {
+			mergeCapabilities(&caps, device_.Capabilities())
+		}
+	}
+	mergeCapabilities(&caps, Capabilities{Keys: key_codes})
+	return caps
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// touchpadCapabilities returns the capabilities of a touchpad in pointer
+// mode, which doesn't emit the touch and tool buttons (BTN_DIGI to
+// BTN_TOOL_QUADTAP) or absolute axes
+func touchpadCapabilities(touchpad Capabilities) Capabilities {
+	caps := DefaultVirtualCapabilities(gopi.INPUT_TYPE_NONE)
+	caps.Keys = append(caps.Keys, gopi.KEYCODE_BTNLEFT, gopi.KEYCODE_BTNRIGHT, gopi.KEYCODE_BTNMIDDLE)
+	for _, key_code := range touchpad.Keys {
+		if key_code < 0x0140 || key_code > 0x014F {
+			caps.Keys = append(caps.Keys, key_code)
+		}
+	}
+	caps.RelAxes = append(caps.RelAxes, REL_X, REL_Y, REL_HWHEEL, REL_WHEEL)
+	caps.Switches = append(caps.Switches, touchpad.Switches...)
+	return caps
+}
+
+// mergeCapabilities adds the codes and properties which are not already
+// in the capabilities
+func mergeCapabilities(caps *Capabilities, other Capabilities) {
+	for _, key_code := range other.Keys {
+		if caps.HasKey(key_code) == false {
+			caps.Keys = append(caps.Keys, key_code)
+		}
+	}
+	for _, axis := range other.RelAxes {
+		if caps.HasRelAxis(axis) == false {
+			caps.RelAxes = append(caps.RelAxes, axis)
+		}
+	}
+	for axis, info := range other.AbsAxes {
+		if caps.HasAbsAxis(axis) == false {
+			caps.AbsAxes[axis] = info
+		}
+	}
+	for _, switch_code := range other.Switches {
+		if caps.HasSwitch(switch_code) == false {
+			caps.Switches = append(caps.Switches, switch_code)
+		}
+	}
+	for _, property := range other.Properties {
+		if caps.HasProperty(property) == false {
+			caps.Properties = append(caps.Properties, property)
+		}
+	}
+}
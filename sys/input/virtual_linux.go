@@ -0,0 +1,534 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"syscall"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Virtual input device, created with the kernel uinput module
+type VirtualDevice struct {
+	// Path to the uinput device, or empty for INPUT_PATH_UINPUT. When
+	// another path is not a character device, raw events are written
+	// to a file at the path instead, which can be used for testing
+	Path string
+
+	// Name, type and bus of the device
+	Name string
+	Type gopi.InputDeviceType
+	Bus  gopi.InputDeviceBus
+
+	// Product and version
+	Vendor  uint16
+	Product uint16
+	Version uint16
+
+	// Codes and properties supported by the device, or empty to use
+	// DefaultVirtualCapabilities for the type of device
+	Capabilities Capabilities
+
+	// Screen size for absolute and touch positions, which are scaled
+	// to the range of the axes, or zero when positions are emitted
+	// in device units
+	Size gopi.Size
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE TYPES
+
+// Represents a virtual input device
+type virtual struct {
+	log         gopi.Logger
+	path        string
+	name        string
+	device_type gopi.InputDeviceType
+	caps        Capabilities
+	size        gopi.Size
+
+	// Handle to the uinput device or file, and whether it is
+	// a uinput device
+	handle *os.File
+	uinput bool
+
+	// Multi-touch contacts, where each slot has a tracking ID or
+	// -1 if the slot is not active, and the next tracking ID. For
+	// devices without multi-touch slots, whether there is a contact
+	contacts    []int32
+	tracking_id int32
+	touch       bool
+
+	// Lock for writing events
+	sync.Mutex
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OPEN AND CLOSE
+
+// Create a new virtual input device or return error
+func (config VirtualDevice) Open(log gopi.Logger) (gopi.Driver, error) {
+	log.Debug("<sys.input.VirtualDevice.Open>{ path=%v name=%v type=%v bus=%v size=%v }", config.Path, config.Name, DeviceTypeString(config.Type), config.Bus, config.Size)
+
+	// Check incoming configuration parameters
+	if config.Name == "" || config.Type == gopi.INPUT_TYPE_NONE {
+		return nil, gopi.ErrBadParameter
+	}
+	if config.Size.W < 0 || config.Size.H < 0 {
+		return nil, gopi.ErrBadParameter
+	}
+
+	this := new(virtual)
+	this.log = log
+	this.path = config.Path
+	this.name = config.Name
+	this.device_type = config.Type
+	this.caps = config.Capabilities
+	this.size = config.Size
+	if this.path == "" {
+		this.path = INPUT_PATH_UINPUT
+	}
+	if evEmptyCapabilities(this.caps) {
+		this.caps = DefaultVirtualCapabilities(this.device_type)
+	}
+	if evEmptyCapabilities(this.caps) {
+		return nil, gopi.ErrBadParameter
+	}
+
+	// Set up the multi-touch contacts
+	if info, exists := this.caps.AbsAxes[ABS_MT_SLOT]; exists {
+		num_slots := int(info.Maximum) + 1
+		if num_slots > INPUT_MAX_MULTITOUCH_SLOTS {
+			num_slots = INPUT_MAX_MULTITOUCH_SLOTS
+		}
+		this.contacts = make([]int32, num_slots)
+		for i := range this.contacts {
+			this.contacts[i] = -1
+		}
+	}
+
+	// Open the uinput device, or create a file which records raw events
+	// when a path other than the uinput device is set
+	stat, err := os.Stat(this.path)
+	if this.path == INPUT_PATH_UINPUT && (err != nil || stat.Mode()&os.ModeCharDevice == 0) {
+		return nil, fmt.Errorf("%v: Not a uinput device, check the uinput module is loaded", this.path)
+	}
+	if err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		if handle, err := os.OpenFile(this.path, os.O_WRONLY|syscall.O_NONBLOCK, 0); err != nil {
+			return nil, err
+		} else {
+			this.handle = handle
+			this.uinput = true
+		}
+		if err := this.evUinputCreate(config.Bus, config.Vendor, config.Product, config.Version); err != nil {
+			this.handle.Close()
+			return nil, err
+		}
+	} else if handle, err := os.Create(this.path); err != nil {
+		return nil, err
+	} else {
+		this.handle = handle
+	}
+
+	// Success
+	return this, nil
+}
+
+// Close the virtual input device
+func (this *virtual) Close() error {
+	this.log.Debug("<sys.input.VirtualDevice.Close>{ name=%v path=%v }", this.name, this.path)
+
+	this.Lock()
+	defer this.Unlock()
+
+	// Destroy the device
+	if this.uinput {
+		if err := evUinputCreate(this.handle, false); err != nil {
+			this.log.Warn("<sys.input.VirtualDevice.Close> Error: %v", err)
+		}
+	}
+
+	// Close file handle
+	if err := this.handle.Close(); err != nil {
+		return err
+	}
+
+	// Blank out
+	this.handle = nil
+
+	// return success
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// VirtualInputDevice INTERFACE
+
+func (this *virtual) Name() string {
+	return this.name
+}
+
+func (this *virtual) Type() gopi.InputDeviceType {
+	return this.device_type
+}
+
+func (this *virtual) Capabilities() Capabilities {
+	return this.caps
+}
+
+// Emit writes events to the device, each followed by a SYN_REPORT.
+// Key, position, touch, scroll, axis, hat and switch events can be
+// emitted, and ErrNotImplemented is returned for other events and
+// for codes which are not in the capabilities of the device
+func (this *virtual) Emit(events ...gopi.InputEvent) error {
+	this.Lock()
+	defer this.Unlock()
+
+	if this.handle == nil {
+		return gopi.ErrOutOfOrder
+	}
+	frame := make([]evEvent, 0, len(events)*2)
+	for _, evt := range events {
+		if raw_events, err := this.evEncode(evt); err != nil {
+			return err
+		} else if this.evSupported(raw_events) == false {
+			return gopi.ErrNotImplemented
+		} else {
+			frame = append(frame, raw_events...)
+			frame = append(frame, evEvent{Type: EV_SYN, Code: EV_CODE_SYN_REPORT})
+		}
+	}
+	return binary.Write(this.handle, binary.LittleEndian, frame)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *virtual) String() string {
+	return fmt.Sprintf("<sys.input.VirtualDevice>{ name=%v type=%v path=%v uinput=%v }", this.name, DeviceTypeString(this.device_type), this.path, this.uinput)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// evUinputCreate enables the codes and properties of the device,
+// sets the absolute axis information and creates the device
+func (this *virtual) evUinputCreate(bus gopi.InputDeviceBus, vendor, product, version uint16) error {
+	codes := map[uintptr][]uint16{
+		UI_SET_KEYBIT:  make([]uint16, 0, len(this.caps.Keys)),
+		UI_SET_RELBIT:  make([]uint16, 0, len(this.caps.RelAxes)),
+		UI_SET_ABSBIT:  make([]uint16, 0, len(this.caps.AbsAxes)),
+		UI_SET_SWBIT:   make([]uint16, 0, len(this.caps.Switches)),
+		UI_SET_LEDBIT:  make([]uint16, 0, len(this.caps.LEDs)),
+		UI_SET_PROPBIT: make([]uint16, 0, len(this.caps.Properties)),
+	}
+	for _, key_code := range this.caps.Keys {
+		codes[UI_SET_KEYBIT] = append(codes[UI_SET_KEYBIT], uint16(key_code))
+	}
+	for _, axis := range this.caps.RelAxes {
+		codes[UI_SET_RELBIT] = append(codes[UI_SET_RELBIT], uint16(axis))
+	}
+	for axis := range this.caps.AbsAxes {
+		codes[UI_SET_ABSBIT] = append(codes[UI_SET_ABSBIT], uint16(axis))
+	}
+	for _, code := range this.caps.Switches {
+		codes[UI_SET_SWBIT] = append(codes[UI_SET_SWBIT], uint16(code))
+	}
+	for _, led := range this.caps.LEDs {
+		codes[UI_SET_LEDBIT] = append(codes[UI_SET_LEDBIT], uint16(led))
+	}
+	for _, property := range this.caps.Properties {
+		codes[UI_SET_PROPBIT] = append(codes[UI_SET_PROPBIT], uint16(property))
+	}
+
+	// Enable the event types and codes. Keyboards also report scan codes
+	types := map[uintptr]evType{
+		UI_SET_KEYBIT: EV_KEY, UI_SET_RELBIT: EV_REL, UI_SET_ABSBIT: EV_ABS, UI_SET_SWBIT: EV_SW, UI_SET_LEDBIT: EV_LED,
+	}
+	for name, values := range codes {
+		if len(values) == 0 {
+			continue
+		}
+		if ev, exists := types[name]; exists {
+			if err := evUinputSetBit(this.handle, UI_SET_EVBIT, uint16(ev)); err != nil {
+				return err
+			}
+		}
+		for _, value := range values {
+			if err := evUinputSetBit(this.handle, name, value); err != nil {
+				return err
+			}
+		}
+	}
+	if this.device_type&gopi.INPUT_TYPE_KEYBOARD != 0 {
+		if err := evUinputSetBit(this.handle, UI_SET_EVBIT, uint16(EV_MSC)); err != nil {
+			return err
+		} else if err := evUinputSetBit(this.handle, UI_SET_MSCBIT, uint16(EV_CODE_SCANCODE)); err != nil {
+			return err
+		}
+	}
+
+	// Set the name and identifiers, and the absolute axis information
+	if err := evUinputSetup(this.handle, this.name, uint16(bus), vendor, product, version); err != nil {
+		return err
+	}
+	for axis, info := range this.caps.AbsAxes {
+		if err := evUinputAbsSetup(this.handle, evKeyCode(axis), evAbsInfo(info)); err != nil {
+			return err
+		}
+	}
+
+	// Create the device
+	return evUinputCreate(this.handle, true)
+}
+
+// evEncode returns the raw events for an input event
+func (this *virtual) evEncode(evt gopi.InputEvent) ([]evEvent, error) {
+	frame := make([]evEvent, 0, 2)
+	switch evt.EventType() {
+	case gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYRELEASE, gopi.INPUT_EVENT_KEYREPEAT:
+		if evt.ScanCode() != 0 && this.device_type&gopi.INPUT_TYPE_KEYBOARD != 0 {
+			frame = append(frame, evEvent{Type: EV_MSC, Code: EV_CODE_SCANCODE, Value: evt.ScanCode()})
+		}
+		value := EV_VALUE_KEY_DOWN
+		if evt.EventType() == gopi.INPUT_EVENT_KEYRELEASE {
+			value = EV_VALUE_KEY_UP
+		} else if evt.EventType() == gopi.INPUT_EVENT_KEYREPEAT {
+			value = EV_VALUE_KEY_REPEAT
+		}
+		frame = append(frame, evEvent{Type: EV_KEY, Code: evKeyCode(evt.KeyCode()), Value: uint32(value)})
+	case gopi.INPUT_EVENT_RELPOSITION:
+		if x := evRound(evt.Relative().X); x != 0 {
+			frame = append(frame, evEvent{Type: EV_REL, Code: EV_CODE_X, Value: uint32(x)})
+		}
+		if y := evRound(evt.Relative().Y); y != 0 {
+			frame = append(frame, evEvent{Type: EV_REL, Code: EV_CODE_Y, Value: uint32(y)})
+		}
+	case gopi.INPUT_EVENT_ABSPOSITION:
+		frame = append(frame, this.evEncodeAbs(ABS_X, evt.Position().X, this.size.W))
+		frame = append(frame, this.evEncodeAbs(ABS_Y, evt.Position().Y, this.size.H))
+	case gopi.INPUT_EVENT_TOUCHPRESS, gopi.INPUT_EVENT_TOUCHPOSITION, gopi.INPUT_EVENT_TOUCHRELEASE:
+		return this.evEncodeTouch(evt)
+	case INPUT_EVENT_SCROLL:
+		if scroll_event, ok := evt.(ScrollEvent); ok {
+			frame = append(frame, this.evEncodeScroll(scroll_event.Scroll())...)
+		}
+	case INPUT_EVENT_AXIS:
+		if axis_event, ok := evt.(AxisEvent); ok {
+			frame = append(frame, this.evEncodeAxis(axis_event.Axis(), axis_event.Value()))
+		}
+	case INPUT_EVENT_HAT:
+		if hat_event, ok := evt.(HatEvent); ok {
+			if raw_events, err := evEncodeHat(hat_event.Hat(), hat_event.Direction()); err != nil {
+				return nil, err
+			} else {
+				frame = append(frame, raw_events...)
+			}
+		}
+	case INPUT_EVENT_SWITCH:
+		if switch_event, ok := evt.(SwitchEvent); ok {
+			raw_event := evEvent{Type: EV_SW, Code: evKeyCode(switch_event.Switch())}
+			if switch_event.SwitchState() {
+				raw_event.Value = 1
+			}
+			frame = append(frame, raw_event)
+		}
+	default:
+		return nil, gopi.ErrNotImplemented
+	}
+	return frame, nil
+}
+
+// evSupported returns true if the codes of all the raw events are
+// in the capabilities of the device
+func (this *virtual) evSupported(raw_events []evEvent) bool {
+	for _, raw_event := range raw_events {
+		switch raw_event.Type {
+		case EV_KEY:
+			if this.caps.HasKey(gopi.KeyCode(raw_event.Code)) == false {
+				return false
+			}
+		case EV_REL:
+			if this.caps.HasRelAxis(RelAxis(raw_event.Code)) == false {
+				return false
+			}
+		case EV_ABS:
+			if this.caps.HasAbsAxis(AbsAxis(raw_event.Code)) == false {
+				return false
+			}
+		case EV_SW:
+			if this.caps.HasSwitch(SwitchCode(raw_event.Code)) == false {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// evEncodeTouch returns the raw events for a touch press, position or
+// release. Devices with multi-touch slots report each contact in a slot,
+// and all touch devices report the position of the last contact
+// which changed and whether any contact is touching
+func (this *virtual) evEncodeTouch(evt gopi.InputEvent) ([]evEvent, error) {
+	frame := make([]evEvent, 0, 6)
+	slot := evt.Slot()
+	touching := this.evTouching()
+	if this.contacts != nil {
+		if slot >= uint(len(this.contacts)) {
+			return nil, gopi.ErrBadParameter
+		}
+		frame = append(frame, evEvent{Type: EV_ABS, Code: EV_CODE_SLOT, Value: uint32(slot)})
+		switch {
+		case evt.EventType() == gopi.INPUT_EVENT_TOUCHPRESS && this.contacts[slot] == -1:
+			this.contacts[slot] = this.tracking_id
+			this.tracking_id = (this.tracking_id + 1) & 0xFFFF
+			frame = append(frame, evEvent{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: uint32(this.contacts[slot])})
+		case evt.EventType() == gopi.INPUT_EVENT_TOUCHRELEASE && this.contacts[slot] != -1:
+			this.contacts[slot] = -1
+			frame = append(frame, evEvent{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: uint32(0xFFFFFFFF)})
+		}
+		if evt.EventType() != gopi.INPUT_EVENT_TOUCHRELEASE {
+			frame = append(frame, this.evEncodeAbs(ABS_MT_POSITION_X, evt.Position().X, this.size.W))
+			frame = append(frame, this.evEncodeAbs(ABS_MT_POSITION_Y, evt.Position().Y, this.size.H))
+		}
+	} else {
+		this.touch = evt.EventType() != gopi.INPUT_EVENT_TOUCHRELEASE
+	}
+
+	// Single-touch emulation
+	if evt.EventType() != gopi.INPUT_EVENT_TOUCHRELEASE {
+		frame = append(frame, this.evEncodeAbs(ABS_X, evt.Position().X, this.size.W))
+		frame = append(frame, this.evEncodeAbs(ABS_Y, evt.Position().Y, this.size.H))
+	}
+	if state := this.evTouching(); state != touching {
+		raw_event := evEvent{Type: EV_KEY, Code: EV_CODE_BTN_TOUCH}
+		if state {
+			raw_event.Value = uint32(EV_VALUE_KEY_DOWN)
+		}
+		frame = append(frame, raw_event)
+	}
+	return frame, nil
+}
+
+// evTouching returns true if any contact is touching
+func (this *virtual) evTouching() bool {
+	if this.contacts == nil {
+		return this.touch
+	}
+	for _, id := range this.contacts {
+		if id != -1 {
+			return true
+		}
+	}
+	return false
+}
+
+// evEncodeScroll returns the raw events for scrolling in detents,
+// including the high resolution events when they are supported
+func (this *virtual) evEncodeScroll(scroll gopi.Point) []evEvent {
+	frame := make([]evEvent, 0, 4)
+	if y := evRound(scroll.Y); y != 0 {
+		frame = append(frame, evEvent{Type: EV_REL, Code: EV_CODE_WHEEL, Value: uint32(y)})
+	}
+	if x := evRound(scroll.X); x != 0 {
+		frame = append(frame, evEvent{Type: EV_REL, Code: EV_CODE_HWHEEL, Value: uint32(x)})
+	}
+	if y := evRound(scroll.Y * EV_SCROLL_HI_RES_DETENT); y != 0 && this.caps.HasRelAxis(REL_WHEEL_HI_RES) {
+		frame = append(frame, evEvent{Type: EV_REL, Code: EV_CODE_WHEEL_HI_RES, Value: uint32(y)})
+	}
+	if x := evRound(scroll.X * EV_SCROLL_HI_RES_DETENT); x != 0 && this.caps.HasRelAxis(REL_HWHEEL_HI_RES) {
+		frame = append(frame, evEvent{Type: EV_REL, Code: EV_CODE_HWHEEL_HI_RES, Value: uint32(x)})
+	}
+	return frame
+}
+
+// evEncodeAbs returns the raw event for an absolute position, which
+// is scaled from the size to the range of the axis when the size is
+// not zero
+func (this *virtual) evEncodeAbs(axis AbsAxis, value, size float32) evEvent {
+	info, exists := this.caps.AbsAxes[axis]
+	if exists && size > 0 && info.Maximum > info.Minimum {
+		value = float32(info.Minimum) + value/size*float32(info.Maximum-info.Minimum)
+	}
+	return evEvent{Type: EV_ABS, Code: evKeyCode(axis), Value: uint32(evClamp(evRound(value), info, exists))}
+}
+
+// evEncodeAxis returns the raw event for a joystick axis, where the value
+// is between 0.0 and 1.0 for triggers and between -1.0 and 1.0 for sticks
+func (this *virtual) evEncodeAxis(axis AbsAxis, value float32) evEvent {
+	info, exists := this.caps.AbsAxes[axis]
+	if exists && info.Maximum > info.Minimum {
+		if evIsTrigger(evKeyCode(axis)) == false || info.Minimum < 0 {
+			value = (value + 1) / 2
+		}
+		value = float32(info.Minimum) + value*float32(info.Maximum-info.Minimum)
+	}
+	return evEvent{Type: EV_ABS, Code: evKeyCode(axis), Value: uint32(evClamp(evRound(value), info, exists))}
+}
+
+// evEncodeHat returns the raw events for the direction of a hat, or
+// ErrBadParameter if there is no hat with that number
+func evEncodeHat(hat uint, direction HatDirection) ([]evEvent, error) {
+	var x, y int32
+	if hat >= EV_JOYSTICK_HATS {
+		return nil, gopi.ErrBadParameter
+	}
+	switch {
+	case direction&HAT_LEFT != 0:
+		x = -1
+	case direction&HAT_RIGHT != 0:
+		x = 1
+	}
+	switch {
+	case direction&HAT_UP != 0:
+		y = -1
+	case direction&HAT_DOWN != 0:
+		y = 1
+	}
+	code := EV_CODE_HAT0X + evKeyCode(hat<<1)
+	return []evEvent{
+		{Type: EV_ABS, Code: code, Value: uint32(x)},
+		{Type: EV_ABS, Code: code + 1, Value: uint32(y)},
+	}, nil
+}
+
+// evEmptyCapabilities returns true if no codes are supported
+func evEmptyCapabilities(caps Capabilities) bool {
+	return len(caps.Keys) == 0 && len(caps.RelAxes) == 0 && len(caps.AbsAxes) == 0 && len(caps.Switches) == 0
+}
+
+// evRound returns a value rounded to the nearest integer
+func evRound(value float32) int32 {
+	return int32(math.Round(float64(value)))
+}
+
+// evClamp returns a value limited to the range of an axis
+func evClamp(value int32, info AbsInfo, exists bool) int32 {
+	switch {
+	case exists == false || info.Maximum <= info.Minimum:
+		return value
+	case value < info.Minimum:
+		return info.Minimum
+	case value > info.Maximum:
+		return info.Maximum
+	default:
+		return value
+	}
+}
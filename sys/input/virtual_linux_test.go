@@ -0,0 +1,206 @@
+// +build linux
+
+/*
+	Go Language Raspberry Pi Interface
+	(c) Copyright David Thorpe 2016-2018
+	All Rights Reserved
+
+	Documentation http://djthorpe.github.io/gopi/
+	For Licensing and Usage information, please see LICENSE.md
+*/
+
+package input
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	// Frameworks
+	"github.com/djthorpe/gopi"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST VIRTUAL DEVICE
+
+// testVirtualEvents returns the raw events recorded in a file
+func testVirtualEvents(t *testing.T, path string) []evEvent {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw_events := make([]evEvent, stat.Size()/int64(binary.Size(evEvent{})))
+	if err := binary.Read(file, binary.LittleEndian, raw_events); err != nil {
+		t.Fatal(err)
+	}
+	return raw_events
+}
+
+func TestVirtualDevice_000(t *testing.T) {
+	// Device type is required
+	if _, err := (VirtualDevice{Name: "Virtual"}).Open(&testLogger{t}); err != gopi.ErrBadParameter {
+		t.Errorf("Expected ErrBadParameter, got %v", err)
+	}
+	caps := DefaultVirtualCapabilities(gopi.INPUT_TYPE_KEYBOARD | gopi.INPUT_TYPE_MOUSE)
+	if caps.HasKey(gopi.KEYCODE_A) == false || caps.HasKey(gopi.KEYCODE_BTNLEFT) == false || caps.HasRelAxis(REL_WHEEL) == false {
+		t.Errorf("Unexpected capabilities: %v", caps)
+	}
+}
+
+func TestVirtualDevice_001(t *testing.T) {
+	folder, err := ioutil.TempDir("", "virtual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	path := filepath.Join(folder, "uinput")
+
+	driver, err := gopi.Open(VirtualDevice{
+		Path: path,
+		Name: "Virtual Device",
+		Type: gopi.INPUT_TYPE_KEYBOARD | gopi.INPUT_TYPE_MOUSE | gopi.INPUT_TYPE_TOUCHSCREEN,
+		Size: gopi.Size{W: 100, H: 100},
+	}, &testLogger{t})
+	if err != nil {
+		t.Fatal(err)
+	}
+	virtual := driver.(VirtualInputDevice)
+	source := testDevice(t, virtual.Type())
+	if err := virtual.Emit(
+		NewInputEvent(source, 0, gopi.INPUT_EVENT_KEYPRESS, gopi.KEYCODE_A, 0x1E, 0, gopi.ZeroPoint, gopi.ZeroPoint),
+		NewInputEvent(source, 0, gopi.INPUT_EVENT_RELPOSITION, gopi.KEYCODE_NONE, 0, 0, gopi.ZeroPoint, gopi.Point{X: 5, Y: -3}),
+		NewScrollEvent(source, 0, gopi.ZeroPoint, gopi.Point{Y: 1}),
+		NewInputEvent(source, 0, gopi.INPUT_EVENT_TOUCHPRESS, gopi.KEYCODE_BTNTOUCH, 0, 2, gopi.Point{X: 50, Y: 100}, gopi.ZeroPoint),
+		NewInputEvent(source, 0, gopi.INPUT_EVENT_TOUCHRELEASE, gopi.KEYCODE_BTNTOUCH, 0, 2, gopi.Point{X: 50, Y: 100}, gopi.ZeroPoint),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := virtual.Emit(NewInputEvent(source, 0, gopi.INPUT_EVENT_NONE, gopi.KEYCODE_NONE, 0, 0, gopi.ZeroPoint, gopi.ZeroPoint)); err != gopi.ErrNotImplemented {
+		t.Errorf("Expected ErrNotImplemented, got %v", err)
+	}
+
+	// Codes which are not in the capabilities are not emitted
+	if err := virtual.Emit(NewInputEvent(source, 0, gopi.INPUT_EVENT_KEYPRESS, gopi.KeyCode(0x2C0), 0, 0, gopi.ZeroPoint, gopi.ZeroPoint)); err != gopi.ErrNotImplemented {
+		t.Errorf("Expected ErrNotImplemented, got %v", err)
+	}
+	if err := virtual.Emit(NewHatEvent(source, 0, 0, HAT_UP)); err != gopi.ErrNotImplemented {
+		t.Errorf("Expected ErrNotImplemented, got %v", err)
+	}
+	if err := virtual.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	syn := evEvent{Type: EV_SYN, Code: EV_CODE_SYN_REPORT}
+	expected := []evEvent{
+		{Type: EV_MSC, Code: EV_CODE_SCANCODE, Value: 0x1E},
+		{Type: EV_KEY, Code: evKeyCode(gopi.KEYCODE_A), Value: 1},
+		syn,
+		{Type: EV_REL, Code: EV_CODE_X, Value: 5},
+		{Type: EV_REL, Code: EV_CODE_Y, Value: 0xFFFFFFFD},
+		syn,
+		{Type: EV_REL, Code: EV_CODE_WHEEL, Value: 1},
+		syn,
+		{Type: EV_ABS, Code: EV_CODE_SLOT, Value: 2},
+		{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: 0},
+		{Type: EV_ABS, Code: EV_CODE_SLOT_X, Value: 16384},
+		{Type: EV_ABS, Code: EV_CODE_SLOT_Y, Value: VIRTUAL_ABS_MAX},
+		{Type: EV_ABS, Code: EV_CODE_X, Value: 16384},
+		{Type: EV_ABS, Code: EV_CODE_Y, Value: VIRTUAL_ABS_MAX},
+		{Type: EV_KEY, Code: EV_CODE_BTN_TOUCH, Value: 1},
+		syn,
+		{Type: EV_ABS, Code: EV_CODE_SLOT, Value: 2},
+		{Type: EV_ABS, Code: EV_CODE_SLOT_ID, Value: 0xFFFFFFFF},
+		{Type: EV_KEY, Code: EV_CODE_BTN_TOUCH, Value: 0},
+		syn,
+	}
+	raw_events := testVirtualEvents(t, path)
+	if len(raw_events) != len(expected) {
+		t.Fatalf("Expected %v events, got %v", len(expected), raw_events)
+	}
+	for i, raw_event := range raw_events {
+		if raw_event != expected[i] {
+			t.Errorf("Event %v: Expected %v, got %v", i, expected[i], raw_event)
+		}
+	}
+
+	// The recorded events can be decoded by an input device
+	events := testDecode(testDevice(t, gopi.INPUT_TYPE_MOUSE), raw_events[3:8])
+	if len(events) != 2 || events[0].Relative() != (gopi.Point{X: 5, Y: -3}) || events[1].EventType() != INPUT_EVENT_SCROLL {
+		t.Errorf("Unexpected decoded events: %v", events)
+	}
+}
+
+func TestVirtualDevice_002(t *testing.T) {
+	this := &virtual{caps: DefaultVirtualCapabilities(gopi.INPUT_TYPE_JOYSTICK)}
+	tests := []struct {
+		axis     AbsAxis
+		value    float32
+		expected int32
+	}{
+		{ABS_X, -1, -0x8000}, {ABS_X, 1, 0x7FFF}, {ABS_Z, 1, 0xFF}, {ABS_RZ, 0, 0}, {ABS_RX, 2, 0x7FFF},
+	}
+	for _, test := range tests {
+		if raw_event := this.evEncodeAxis(test.axis, test.value); int32(raw_event.Value) != test.expected {
+			t.Errorf("%v %v: Expected %v, got %v", test.axis, test.value, test.expected, int32(raw_event.Value))
+		}
+	}
+	if raw_events, err := evEncodeHat(1, HAT_UP|HAT_RIGHT); err != nil {
+		t.Error(err)
+	} else if raw_events[0].Code != EV_CODE_HAT0X+2 || int32(raw_events[0].Value) != 1 || int32(raw_events[1].Value) != -1 {
+		t.Errorf("Unexpected hat events: %v", raw_events)
+	}
+	if raw_events, err := evEncodeHat(3, HAT_DOWN); err != nil {
+		t.Error(err)
+	} else if raw_events[1].Code != EV_CODE_HAT0X+7 || int32(raw_events[1].Value) != 1 {
+		t.Errorf("Unexpected hat events: %v", raw_events)
+	}
+
+	// There are four hats
+	if _, err := evEncodeHat(4, HAT_UP); err != gopi.ErrBadParameter {
+		t.Errorf("Expected ErrBadParameter, got %v", err)
+	}
+}
+
+func TestMergeCapabilities_000(t *testing.T) {
+	fixtures := testReadFixtures(t)
+	keyboard := testFixtureDevice(t, fixtures["AT Translated Set 2 keyboard"])
+	touchpad := testFixtureDevice(t, fixtures["SynPS/2 Synaptics TouchPad"])
+	if touchpad.device_type&INPUT_TYPE_TOUCHPAD == 0 || touchpad.Touchpad().Mode != TOUCHPAD_POINTER {
+		t.Fatalf("Expected touchpad in pointer mode: %v", touchpad)
+	}
+
+	// A touchpad in pointer mode emits relative movement, scrolling and
+	// buttons, but not touches or absolute positions
+	caps := MergeCapabilities([]gopi.InputDevice{keyboard, touchpad}, []gopi.KeyCode{gopi.KEYCODE_F13})
+	for _, axis := range []RelAxis{REL_X, REL_Y, REL_WHEEL, REL_HWHEEL} {
+		if caps.HasRelAxis(axis) == false {
+			t.Errorf("Expected %v", axis)
+		}
+	}
+	for _, key_code := range []gopi.KeyCode{gopi.KEYCODE_A, gopi.KEYCODE_BTNLEFT, gopi.KEYCODE_BTNRIGHT, gopi.KEYCODE_BTNMIDDLE, gopi.KEYCODE_F13} {
+		if caps.HasKey(key_code) == false {
+			t.Errorf("Expected %v", key_code)
+		}
+	}
+	if caps.HasKey(gopi.KEYCODE_BTNTOUCH) || len(caps.AbsAxes) != 0 {
+		t.Errorf("Unexpected touch capabilities: %v", caps)
+	}
+
+	// A touchpad which reports touches has its own axes
+	if err := touchpad.SetTouchpad(Touchpad{Mode: TOUCHPAD_RAW}); err != nil {
+		t.Fatal(err)
+	}
+	caps = MergeCapabilities([]gopi.InputDevice{touchpad}, nil)
+	if caps.HasKey(gopi.KEYCODE_BTNTOUCH) == false || caps.HasAbsAxis(ABS_MT_POSITION_X) == false {
+		t.Errorf("Expected touch capabilities: %v", caps)
+	} else if caps.HasRelAxis(REL_X) {
+		t.Errorf("Unexpected relative axes: %v", caps)
+	}
+}